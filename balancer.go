@@ -0,0 +1,26 @@
+package nero
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// Balancer picks the replica that a read query is sent to. It is only
+// called with a non-empty list of replicas and must be safe for concurrent use.
+type Balancer func(replicas []DB) DB
+
+// RoundRobinBalancer returns a Balancer that picks the replicas in turn
+func RoundRobinBalancer() Balancer {
+	var n uint64
+	return func(replicas []DB) DB {
+		i := atomic.AddUint64(&n, 1) - 1
+		return replicas[i%uint64(len(replicas))]
+	}
+}
+
+// RandomBalancer returns a Balancer that picks a replica at random
+func RandomBalancer() Balancer {
+	return func(replicas []DB) DB {
+		return replicas[rand.Intn(len(replicas))]
+	}
+}
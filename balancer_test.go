@@ -0,0 +1,28 @@
+package nero_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sf9v/nero"
+)
+
+func TestBalancer(t *testing.T) {
+	replicas := []nero.DB{&sql.DB{}, &sql.DB{}, &sql.DB{}}
+
+	t.Run("RoundRobin", func(t *testing.T) {
+		balancer := nero.RoundRobinBalancer()
+		for i := 0; i < 2*len(replicas); i++ {
+			assert.Same(t, replicas[i%len(replicas)], balancer(replicas))
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		balancer := nero.RandomBalancer()
+		for i := 0; i < 10; i++ {
+			assert.Contains(t, replicas, balancer(replicas))
+		}
+	})
+}
@@ -25,6 +25,24 @@ const (
 	In
 	// In is used to check if a value is not in the list
 	NotIn
+	// Exists is used to check if an edge has related rows that match the
+	// predicates of the target i.e. Arg is an *Edge
+	Exists
+	// PathEq is used to check if the value at a path in a JSON field is equal
+	// to a string i.e. Arg is a *PathValue
+	PathEq
+	// Contains is used to check if an array contains all the elements of
+	// the argument
+	Contains
+	// ContainedBy is used to check if all the elements of an array are in
+	// the argument
+	ContainedBy
+	// Overlap is used to check if an array has any element in common with
+	// the argument
+	Overlap
+	// AnyEq is used to check if any element of an array is equal to the
+	// argument
+	AnyEq
 )
 
 func (o Operator) String() string {
@@ -39,6 +57,12 @@ func (o Operator) String() string {
 		"IsNotNull",
 		"In",
 		"NotIn",
+		"Exists",
+		"PathEq",
+		"Contains",
+		"ContainedBy",
+		"Overlap",
+		"AnyEq",
 	}[o]
 }
 
@@ -55,5 +79,11 @@ func (o Operator) Desc() string {
 		"is not null",
 		"in",
 		"not in",
+		"exists",
+		"path equal",
+		"contains",
+		"contained by",
+		"overlap",
+		"any equal",
 	}[o]
 }
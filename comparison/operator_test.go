@@ -63,6 +63,36 @@ func TestOperatorStrings(t *testing.T) {
 			wantStr:  "NotIn",
 			wantDesc: "not in",
 		},
+		{
+			op:       comparison.Exists,
+			wantStr:  "Exists",
+			wantDesc: "exists",
+		},
+		{
+			op:       comparison.PathEq,
+			wantStr:  "PathEq",
+			wantDesc: "path equal",
+		},
+		{
+			op:       comparison.Contains,
+			wantStr:  "Contains",
+			wantDesc: "contains",
+		},
+		{
+			op:       comparison.ContainedBy,
+			wantStr:  "ContainedBy",
+			wantDesc: "contained by",
+		},
+		{
+			op:       comparison.Overlap,
+			wantStr:  "Overlap",
+			wantDesc: "overlap",
+		},
+		{
+			op:       comparison.AnyEq,
+			wantStr:  "AnyEq",
+			wantDesc: "any equal",
+		},
 	}
 
 	for _, tc := range tests {
@@ -6,3 +6,41 @@ type Predicate struct {
 	Op    Operator
 	Arg   interface{}
 }
+
+// PathValue is the argument of a JSON path predicate. Path is a
+// dot-separated list of keys e.g. "owner.name".
+type PathValue struct {
+	Path  string
+	Value string
+}
+
+// FieldArg is implemented by the Field types of the generated repositories.
+// An argument that implements it is compared as a column instead of a value.
+type FieldArg interface {
+	Column() string
+}
+
+// Edge is the argument of an edge predicate. It describes the tables of the
+// edge so the predicate can be rendered by the repository of any schema e.g.
+// when it's nested in the predicates of another edge.
+type Edge struct {
+	// Name is the name of the edge
+	Name string
+	// Namespace and Collection are the table of the target
+	Namespace  string
+	Collection string
+	// Column of the target that matches the Ref column of the source, or the
+	// ThroughTo column of the join table
+	Column string
+	Ref    string
+	// ThroughNamespace and Through are the join table of a many-to-many edge,
+	// ThroughFrom references the source and ThroughTo references the target
+	ThroughNamespace string
+	Through          string
+	ThroughFrom      string
+	ThroughTo        string
+	// TenantField is the tenant field of the target, if it's tenant-scoped
+	TenantField string
+	// Preds are the predicates of the target
+	Preds []*Predicate
+}
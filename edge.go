@@ -0,0 +1,130 @@
+package nero
+
+import (
+	"github.com/sf9v/mira"
+	stringsx "github.com/sf9v/nero/x/strings"
+)
+
+// EdgeType is the relation type of an edge
+type EdgeType int
+
+// List of edge types
+const (
+	// ManyToOne is a relation where the foreign key is a field of the schema
+	// e.g. a player belongs to a team
+	ManyToOne EdgeType = iota
+	// OneToMany is a relation where the foreign key is a field of the
+	// target schema e.g. a team has many players
+	OneToMany
+	// ManyToMany is a relation where the foreign keys are columns
+	// of a join table e.g. a player has many friends
+	ManyToMany
+)
+
+func (t EdgeType) String() string {
+	return [...]string{
+		"ManyToOne",
+		"OneToMany",
+		"ManyToMany",
+	}[t]
+}
+
+// Edge is a relation to another schema
+type Edge struct {
+	// name is the edge name
+	name string
+	// typ is the edge type
+	typ EdgeType
+	// typeInfo is the edge struct field type info
+	typeInfo *mira.TypeInfo
+	// structField overrides the struct field
+	structField string
+	// target is the schemaer of the target schema
+	target Schemaer
+	// schema is the resolved target schema
+	schema *Schema
+	// foreignKey is the name of the foreign key field
+	foreignKey string
+	// through is the join table of a many-to-many edge
+	through string
+	// throughFrom and throughTo are the join table columns that
+	// reference the schema and the target schema respectively
+	throughFrom,
+	throughTo string
+}
+
+// Name returns the edge name
+func (e *Edge) Name() string {
+	return e.name
+}
+
+// Type returns the edge type
+func (e *Edge) Type() EdgeType {
+	return e.typ
+}
+
+// TypeInfo returns the type info of the edge struct field
+func (e *Edge) TypeInfo() *mira.TypeInfo {
+	return e.typeInfo
+}
+
+// StructField returns the struct field
+func (e *Edge) StructField() string {
+	structField := stringsx.ToCamel(e.name)
+	if len(e.structField) > 0 {
+		structField = e.structField
+	}
+
+	return structField
+}
+
+// Identifier returns the lower-camelized struct field
+func (e *Edge) Identifier() string {
+	return stringsx.ToLowerCamel(e.StructField())
+}
+
+// Schema returns the target schema. It is resolved lazily so
+// that two schemas can have edges that point to each other.
+func (e *Edge) Schema() *Schema {
+	if e.schema == nil && e.target != nil {
+		e.schema = e.target.Schema()
+	}
+
+	return e.schema
+}
+
+// ForeignKey returns the name of the foreign key field. It is a field of the
+// schema for a ManyToOne edge and a field of the target schema for a OneToMany edge.
+func (e *Edge) ForeignKey() string {
+	return e.foreignKey
+}
+
+// Through returns the join table of a ManyToMany edge
+func (e *Edge) Through() string {
+	return e.through
+}
+
+// ThroughFrom returns the join table column that references the schema
+func (e *Edge) ThroughFrom() string {
+	return e.throughFrom
+}
+
+// ThroughTo returns the join table column that references the target schema
+func (e *Edge) ThroughTo() string {
+	return e.throughTo
+}
+
+// IsManyToOne returns true if the edge is a ManyToOne edge
+func (e *Edge) IsManyToOne() bool {
+	return e.typ == ManyToOne
+}
+
+// IsOneToMany returns true if the edge is a OneToMany edge
+func (e *Edge) IsOneToMany() bool {
+	return e.typ == OneToMany
+}
+
+// IsManyToMany returns true if the edge is a ManyToMany edge
+func (e *Edge) IsManyToMany() bool {
+	return e.typ == ManyToMany
+}
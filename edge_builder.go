@@ -0,0 +1,69 @@
+package nero
+
+import "github.com/sf9v/mira"
+
+// EdgeBuilder is an edge builder
+type EdgeBuilder struct {
+	e *Edge
+}
+
+// NewEdgeBuilder takes an edge name and the value of the struct field where
+// the related rows are loaded and returns an EdgeBuilder. The struct field must
+// be a pointer to the target model for a ManyToOne edge and a slice of pointers
+// to the target model for the OneToMany and ManyToMany edges.
+func NewEdgeBuilder(name string, v interface{}) *EdgeBuilder {
+	return &EdgeBuilder{&Edge{
+		name:     name,
+		typeInfo: mira.NewTypeInfo(v),
+	}}
+}
+
+// ManyToOne sets a many-to-one relation to the target
+// where foreignKey is a field of the schema
+func (eb *EdgeBuilder) ManyToOne(target Schemaer, foreignKey string) *EdgeBuilder {
+	eb.e.typ = ManyToOne
+	eb.e.target = target
+	eb.e.foreignKey = foreignKey
+	return eb
+}
+
+// OneToMany sets a one-to-many relation to the target
+// where foreignKey is a field of the target schema
+func (eb *EdgeBuilder) OneToMany(target Schemaer, foreignKey string) *EdgeBuilder {
+	eb.e.typ = OneToMany
+	eb.e.target = target
+	eb.e.foreignKey = foreignKey
+	return eb
+}
+
+// ManyToMany sets a many-to-many relation to the target through a join table,
+// where from references the schema and to references the target schema
+func (eb *EdgeBuilder) ManyToMany(target Schemaer, through, from, to string) *EdgeBuilder {
+	eb.e.typ = ManyToMany
+	eb.e.target = target
+	eb.e.through = through
+	eb.e.throughFrom = from
+	eb.e.throughTo = to
+	return eb
+}
+
+// StructField sets the struct field
+func (eb *EdgeBuilder) StructField(structField string) *EdgeBuilder {
+	eb.e.structField = structField
+	return eb
+}
+
+// Build builds the edge
+func (eb *EdgeBuilder) Build() *Edge {
+	return &Edge{
+		name:        eb.e.name,
+		typ:         eb.e.typ,
+		typeInfo:    eb.e.typeInfo,
+		structField: eb.e.structField,
+		target:      eb.e.target,
+		foreignKey:  eb.e.foreignKey,
+		through:     eb.e.through,
+		throughFrom: eb.e.throughFrom,
+		throughTo:   eb.e.throughTo,
+	}
+}
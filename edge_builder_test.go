@@ -0,0 +1,120 @@
+package nero_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sf9v/nero"
+)
+
+type Group struct {
+	ID      int64
+	Name    string
+	Members []*Member
+}
+
+func (g Group) Schema() *nero.Schema {
+	return nero.NewSchemaBuilder(&g).
+		PkgName("grouprepo").Collection("groups").
+		Identity(nero.NewFieldBuilder("id", g.ID).StructField("ID").Build()).
+		Fields(nero.NewFieldBuilder("name", g.Name).Build()).
+		Edges(nero.NewEdgeBuilder("members", g.Members).
+			OneToMany(Member{}, "group_id").Build()).
+		Build()
+}
+
+type Member struct {
+	ID      int64
+	GroupID int64
+	Group   *Group
+	Friends []*Member
+}
+
+func (m Member) Schema() *nero.Schema {
+	return nero.NewSchemaBuilder(&m).
+		PkgName("memberrepo").Collection("members").
+		Identity(nero.NewFieldBuilder("id", m.ID).StructField("ID").Build()).
+		Fields(nero.NewFieldBuilder("group_id", m.GroupID).StructField("GroupID").Build()).
+		Edges(
+			nero.NewEdgeBuilder("group", m.Group).
+				ManyToOne(Group{}, "group_id").Build(),
+			nero.NewEdgeBuilder("friends", m.Friends).
+				ManyToMany(Member{}, "friendships", "member_id", "friend_id").
+				StructField("Friends").Build(),
+		).
+		Build()
+}
+
+func TestEdgeBuilder(t *testing.T) {
+	schema := Member{}.Schema()
+	assert.Len(t, schema.Edges(), 2)
+	assert.Equal(t, "group_id", schema.Field("group_id").Name())
+	assert.Equal(t, "id", schema.Field("id").Name())
+	assert.Nil(t, schema.Field("group"))
+
+	group := schema.Edges()[0]
+	assert.Equal(t, "group", group.Name())
+	assert.Equal(t, nero.ManyToOne, group.Type())
+	assert.Equal(t, "ManyToOne", group.Type().String())
+	assert.True(t, group.IsManyToOne())
+	assert.NotNil(t, group.TypeInfo())
+	assert.Equal(t, "Group", group.StructField())
+	assert.Equal(t, "group", group.Identifier())
+	assert.Equal(t, "group_id", group.ForeignKey())
+
+	// the target schemas are resolved lazily
+	target := group.Schema()
+	assert.Equal(t, "groups", target.Collection())
+	assert.Same(t, target, group.Schema())
+	members := target.Edges()[0]
+	assert.True(t, members.IsOneToMany())
+	assert.Equal(t, "members", members.Schema().Collection())
+
+	friends := schema.Edges()[1]
+	assert.True(t, friends.IsManyToMany())
+	assert.Equal(t, "ManyToMany", friends.Type().String())
+	assert.Equal(t, "friendships", friends.Through())
+	assert.Equal(t, "member_id", friends.ThroughFrom())
+	assert.Equal(t, "friend_id", friends.ThroughTo())
+	assert.False(t, schema.HasTenantEdges())
+
+	// no target
+	edge := nero.NewEdgeBuilder("none", nil).Build()
+	assert.Nil(t, edge.Schema())
+}
+
+type Project struct {
+	ID       int64
+	TenantID string
+}
+
+func (p Project) Schema() *nero.Schema {
+	return nero.NewSchemaBuilder(&p).
+		PkgName("projectrepo").Collection("projects").
+		Identity(nero.NewFieldBuilder("id", p.ID).StructField("ID").Build()).
+		TenantField(nero.NewFieldBuilder("tenant_id", p.TenantID).
+			StructField("TenantID").Build()).
+		Build()
+}
+
+type Task struct {
+	ID        int64
+	ProjectID int64
+	Project   *Project
+}
+
+func (tk Task) Schema() *nero.Schema {
+	return nero.NewSchemaBuilder(&tk).
+		PkgName("taskrepo").Collection("tasks").
+		Identity(nero.NewFieldBuilder("id", tk.ID).StructField("ID").Build()).
+		Fields(nero.NewFieldBuilder("project_id", tk.ProjectID).StructField("ProjectID").Build()).
+		Edges(nero.NewEdgeBuilder("project", tk.Project).
+			ManyToOne(Project{}, "project_id").Build()).
+		Build()
+}
+
+func TestHasTenantEdges(t *testing.T) {
+	assert.True(t, Task{}.Schema().HasTenantEdges())
+	assert.False(t, Project{}.Schema().HasTenantEdges())
+}
@@ -1,9 +1,13 @@
 package nero
 
 import (
+	"errors"
 	"fmt"
 )
 
+// ErrNoTenant is returned when the context doesn't carry a tenant ID
+var ErrNoTenant = errors.New("no tenant in context")
+
 // ErrRequiredField is a required field error
 type ErrRequiredField struct {
 	field string
@@ -17,3 +21,58 @@ func NewErrRequiredField(field string) *ErrRequiredField {
 func (e *ErrRequiredField) Error() string {
 	return fmt.Sprintf("%s field is required", e.field)
 }
+
+// ErrInvalidValue is returned when a field value is not one of the allowed values
+type ErrInvalidValue struct {
+	field string
+	value interface{}
+}
+
+// NewErrInvalidValue returns an ErrInvalidValue error
+func NewErrInvalidValue(field string, value interface{}) *ErrInvalidValue {
+	return &ErrInvalidValue{field: field, value: value}
+}
+
+func (e *ErrInvalidValue) Error() string {
+	return fmt.Sprintf("%v is not a valid value for %s field", e.value, e.field)
+}
+
+// ErrValidation is returned when a field value breaks a validation rule
+type ErrValidation struct {
+	// Field is the field name
+	Field string
+	// Rule is the rule name e.g. "min"
+	Rule string
+	// Value is the invalid value
+	Value interface{}
+	// Err is the error returned by a custom validation function
+	Err error
+}
+
+func (e *ErrValidation) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s field: %v", e.Field, e.Err)
+	}
+
+	return fmt.Sprintf("%s field: %v breaks the %s rule", e.Field, e.Value, e.Rule)
+}
+
+// Unwrap returns the error of the custom validation function
+func (e *ErrValidation) Unwrap() error {
+	return e.Err
+}
+
+// ErrUnsupported is returned when a feature is not supported by a back-end
+type ErrUnsupported struct {
+	feature string
+	backend string
+}
+
+// NewErrUnsupported returns an ErrUnsupported error
+func NewErrUnsupported(feature, backend string) *ErrUnsupported {
+	return &ErrUnsupported{feature: feature, backend: backend}
+}
+
+func (e *ErrUnsupported) Error() string {
+	return fmt.Sprintf("%s is not supported by %s", e.feature, e.backend)
+}
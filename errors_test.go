@@ -1,6 +1,7 @@
 package nero_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/sf9v/nero"
@@ -12,3 +13,28 @@ func TestErrRequiredField(t *testing.T) {
 	expect := `Name field is required`
 	assert.Equal(t, expect, err.Error())
 }
+
+func TestErrInvalidValue(t *testing.T) {
+	err := nero.NewErrInvalidValue("race", "orc")
+	expect := `orc is not a valid value for race field`
+	assert.Equal(t, expect, err.Error())
+}
+
+func TestErrValidation(t *testing.T) {
+	err := &nero.ErrValidation{Field: "age", Rule: "min", Value: 10}
+	expect := `age field: 10 breaks the min rule`
+	assert.Equal(t, expect, err.Error())
+	assert.Nil(t, err.Unwrap())
+
+	errTooShort := errors.New("too short")
+	err = &nero.ErrValidation{Field: "name", Rule: "func", Value: "x", Err: errTooShort}
+	expect = `name field: too short`
+	assert.Equal(t, expect, err.Error())
+	assert.True(t, errors.Is(err, errTooShort))
+}
+
+func TestErrUnsupported(t *testing.T) {
+	err := nero.NewErrUnsupported("row-level locking", "sqlite")
+	expect := `row-level locking is not supported by sqlite`
+	assert.Equal(t, expect, err.Error())
+}
@@ -1,10 +1,14 @@
 package nero
 
 import (
+	"path"
 	"reflect"
+	"runtime"
+	"strings"
 
 	"github.com/jinzhu/inflection"
 	"github.com/sf9v/mira"
+	"github.com/sf9v/nero/validation"
 	stringsx "github.com/sf9v/nero/x/strings"
 )
 
@@ -19,7 +23,23 @@ type Field struct {
 	// Auto is the auto-filled flag
 	auto,
 	// Optional is the optional flag
-	optional bool
+	optional,
+	// json is the JSON-encoded flag
+	json,
+	// unique is the unique flag
+	unique bool
+	// tenant is the tenant flag, see SchemaBuilder.TenantField
+	tenant bool
+	// generator is the function that generates the field value
+	generator interface{}
+	// enum is the list of allowed values
+	enum []interface{}
+	// rules are the validation rules
+	rules []*validation.Rule
+	// defaultValue is the value that is set on create when the field is not set
+	defaultValue interface{}
+	// defaultFunc is the function that returns the default value
+	defaultFunc interface{}
 }
 
 // TypeInfo returns the type info
@@ -59,6 +79,20 @@ func (f *Field) IsArray() bool {
 		kind == reflect.Slice
 }
 
+// HasArrayOps returns true if the field is a slice that is rendered as a
+// native array i.e. it has the array predicates. Byte slices are excluded
+// since they're rendered as binary strings.
+func (f *Field) HasArrayOps() bool {
+	t := f.typeInfo.T()
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 &&
+		!f.IsValueScanner() && !f.json
+}
+
+// IsPointer returns true if the field is a pointer
+func (f *Field) IsPointer() bool {
+	return f.typeInfo.T().Kind() == reflect.Ptr
+}
+
 // IsNillable returns true if the field is nillable
 func (f *Field) IsNillable() bool {
 	return f.typeInfo.IsNillable()
@@ -84,9 +118,126 @@ func (f *Field) IsOptional() bool {
 	return f.optional
 }
 
+// IsUnique returns the unique flag
+func (f *Field) IsUnique() bool {
+	return f.unique
+}
+
+// IsTenant returns the tenant flag
+func (f *Field) IsTenant() bool {
+	return f.tenant
+}
+
+// IsJSON returns the JSON-encoded flag
+func (f *Field) IsJSON() bool {
+	return f.json
+}
+
+// IsEnum returns true if the field has a list of allowed values
+func (f *Field) IsEnum() bool {
+	return len(f.enum) > 0
+}
+
+// EnumValues returns the list of allowed values
+func (f *Field) EnumValues() []interface{} {
+	return f.enum[:]
+}
+
+// Rules returns the validation rules
+func (f *Field) Rules() []*validation.Rule {
+	return f.rules[:]
+}
+
+// HasValidation returns true if the field is an enum or has validation rules
+func (f *Field) HasValidation() bool {
+	return f.IsEnum() || len(f.rules) > 0
+}
+
 // IsComparable returns true if field is comparable i.e. with comparisong operators
 func (f *Field) IsComparable() bool {
+	if f.json {
+		return false
+	}
+
 	kind := f.typeInfo.T().Kind()
 	return !(kind == reflect.Map ||
 		kind == reflect.Slice)
 }
+
+// Generator returns the generator function or nil
+func (f *Field) Generator() interface{} {
+	return f.generator
+}
+
+// HasGenerator returns true if the field has a generator function
+func (f *Field) HasGenerator() bool {
+	return f.generator != nil
+}
+
+// HasDefault returns true if the field has a default value or function
+func (f *Field) HasDefault() bool {
+	return f.defaultValue != nil || f.defaultFunc != nil
+}
+
+// DefaultValue returns the default value or nil
+func (f *Field) DefaultValue() interface{} {
+	return f.defaultValue
+}
+
+// DefaultFunc returns the default function or nil
+func (f *Field) DefaultFunc() interface{} {
+	return f.defaultFunc
+}
+
+// DefaultFuncNeedsConversion returns true if the value returned by the
+// default function has to be converted to the type of the field
+func (f *Field) DefaultFuncNeedsConversion() bool {
+	return reflect.TypeOf(f.defaultFunc).Out(0) != f.typeInfo.T()
+}
+
+// GeneratorPkgPath returns the package path of the generator function
+func (f *Field) GeneratorPkgPath() string {
+	pkgPath, _ := f.generatorName()
+	return pkgPath
+}
+
+// GeneratorCall returns the qualified generator function e.g. idgen.UUIDv4
+func (f *Field) GeneratorCall() string {
+	return FuncCall(f.generator)
+}
+
+// GeneratorReturnsError returns true if the generator function returns an error
+func (f *Field) GeneratorReturnsError() bool {
+	return reflect.TypeOf(f.generator).NumOut() == 2
+}
+
+// GeneratorNeedsConversion returns true if the value returned by the
+// generator function has to be converted to the type of the field
+func (f *Field) GeneratorNeedsConversion() bool {
+	return reflect.TypeOf(f.generator).Out(0) != f.typeInfo.T()
+}
+
+// generatorName returns the package path and the name of the generator function
+func (f *Field) generatorName() (pkgPath, name string) {
+	return funcName(f.generator)
+}
+
+// funcName returns the package path and the name of a top-level function
+func funcName(fn interface{}) (pkgPath, name string) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return "", ""
+	}
+
+	// e.g. github.com/sf9v/nero/idgen.UUIDv4
+	fullName := runtime.FuncForPC(v.Pointer()).Name()
+	slash := strings.LastIndex(fullName, "/")
+	dot := slash + 1 + strings.Index(fullName[slash+1:], ".")
+	return fullName[:dot], fullName[dot+1:]
+}
+
+// FuncCall returns the qualified name of a top-level function e.g. idgen.UUIDv4
+func FuncCall(fn interface{}) string {
+	pkgPath, name := funcName(fn)
+	return path.Base(pkgPath) + "." + name
+}
@@ -1,6 +1,9 @@
 package nero
 
-import "github.com/sf9v/mira"
+import (
+	"github.com/sf9v/mira"
+	"github.com/sf9v/nero/validation"
+)
 
 // FieldBuilder is a field builder
 type FieldBuilder struct {
@@ -27,19 +30,82 @@ func (fb *FieldBuilder) Optional() *FieldBuilder {
 	return fb
 }
 
+// Unique sets the unique flag i.e. it's a shorthand for a unique index
+// of the field. See SchemaBuilder.Indexes.
+func (fb *FieldBuilder) Unique() *FieldBuilder {
+	fb.f.unique = true
+	return fb
+}
+
+// JSON sets the JSON-encoded flag. The field value is marshaled to JSON on
+// write and unmarshaled on scan i.e. the column is JSONB on Postgres and TEXT on SQLite.
+func (fb *FieldBuilder) JSON() *FieldBuilder {
+	fb.f.json = true
+	return fb
+}
+
+// Default sets the value that is set on create when the field is not set
+// e.g. Default(0) makes the field no longer required. The value must be a
+// scalar that is convertible to the field type, or to the element type of a
+// pointer field. See DefaultFunc for the other types.
+func (fb *FieldBuilder) Default(v interface{}) *FieldBuilder {
+	fb.f.defaultValue = v
+	return fb
+}
+
+// DefaultFunc sets the function that returns the default value e.g. time.Now.
+// It must be a top-level function of the form func() T, where T is
+// convertible to the field type, and the name of its package must be the
+// same as the last element of its import path.
+func (fb *FieldBuilder) DefaultFunc(fn interface{}) *FieldBuilder {
+	fb.f.defaultFunc = fn
+	return fb
+}
+
+// Enum sets the list of allowed values e.g. the constants of a string
+// type. The values must be convertible to the field type.
+func (fb *FieldBuilder) Enum(values ...interface{}) *FieldBuilder {
+	fb.f.enum = append(fb.f.enum, values...)
+	return fb
+}
+
+// Validate adds validation rules e.g. validation.MaxLen(50). The rules are
+// checked by the generated Creator.Validate and Updater.Validate methods.
+func (fb *FieldBuilder) Validate(rules ...*validation.Rule) *FieldBuilder {
+	fb.f.rules = append(fb.f.rules, rules...)
+	return fb
+}
+
 // StructField sets the struct field
 func (fb *FieldBuilder) StructField(structField string) *FieldBuilder {
 	fb.f.structField = structField
 	return fb
 }
 
+// Generator sets the function that generates the field value when it's left
+// empty on create e.g. idgen.UUIDv4 for client-supplied IDs. It must be a
+// top-level function of the form func() T or func() (T, error), where T is
+// convertible to the field type, and the name of its package must be the
+// same as the last element of its import path.
+func (fb *FieldBuilder) Generator(fn interface{}) *FieldBuilder {
+	fb.f.generator = fn
+	return fb
+}
+
 // Build builds the field
 func (fb *FieldBuilder) Build() *Field {
 	return &Field{
-		name:        fb.f.name,
-		typeInfo:    fb.f.typeInfo,
-		auto:        fb.f.auto,
-		optional:    fb.f.optional,
-		structField: fb.f.structField,
+		name:         fb.f.name,
+		typeInfo:     fb.f.typeInfo,
+		auto:         fb.f.auto,
+		optional:     fb.f.optional,
+		json:         fb.f.json,
+		unique:       fb.f.unique,
+		structField:  fb.f.structField,
+		generator:    fb.f.generator,
+		enum:         append([]interface{}{}, fb.f.enum...),
+		rules:        append([]*validation.Rule{}, fb.f.rules...),
+		defaultValue: fb.f.defaultValue,
+		defaultFunc:  fb.f.defaultFunc,
 	}
 }
@@ -2,10 +2,13 @@ package nero_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/idgen"
+	"github.com/sf9v/nero/validation"
 )
 
 func TestFieldBuilder(t *testing.T) {
@@ -25,3 +28,107 @@ func TestFieldBuilder(t *testing.T) {
 	assert.Equal(t, false, field.IsNillable())
 	assert.Equal(t, false, field.IsValueScanner())
 }
+
+func TestFieldBuilderGenerator(t *testing.T) {
+	field := nero.NewFieldBuilder("id", "").StructField("ID").
+		Generator(idgen.UUIDv4).Build()
+	assert.True(t, field.HasGenerator())
+	assert.NotNil(t, field.Generator())
+	assert.Equal(t, "github.com/sf9v/nero/idgen", field.GeneratorPkgPath())
+	assert.Equal(t, "idgen.UUIDv4", field.GeneratorCall())
+	assert.True(t, field.GeneratorReturnsError())
+	assert.False(t, field.GeneratorNeedsConversion())
+
+	type UUID [16]byte
+	field = nero.NewFieldBuilder("uuid", UUID{}).
+		Generator(idgen.RawUUIDv7).Build()
+	assert.Equal(t, "idgen.RawUUIDv7", field.GeneratorCall())
+	assert.True(t, field.GeneratorNeedsConversion())
+
+	field = nero.NewFieldBuilder("name", "").Generator("not a func").Build()
+	assert.Empty(t, field.GeneratorPkgPath())
+
+	field = nero.NewFieldBuilder("name", "").Build()
+	assert.False(t, field.HasGenerator())
+}
+
+func TestFieldBuilderUnique(t *testing.T) {
+	field := nero.NewFieldBuilder("email", "").Unique().Build()
+	assert.True(t, field.IsUnique())
+
+	field = nero.NewFieldBuilder("name", "").Build()
+	assert.False(t, field.IsUnique())
+}
+
+func TestFieldBuilderJSON(t *testing.T) {
+	field := nero.NewFieldBuilder("meta", map[string]string{}).JSON().Build()
+	assert.True(t, field.IsJSON())
+	assert.False(t, field.IsComparable())
+
+	type Item struct{ Name string }
+	field = nero.NewFieldBuilder("item", Item{}).JSON().Build()
+	assert.True(t, field.IsJSON())
+	assert.False(t, field.IsComparable())
+
+	field = nero.NewFieldBuilder("name", "").Build()
+	assert.False(t, field.IsJSON())
+
+	field = nero.NewFieldBuilder("tags", []string{}).JSON().Build()
+	assert.False(t, field.HasArrayOps())
+}
+
+func TestFieldBuilderArray(t *testing.T) {
+	field := nero.NewFieldBuilder("tags", []string{}).Build()
+	assert.True(t, field.IsArray())
+	assert.True(t, field.HasArrayOps())
+
+	field = nero.NewFieldBuilder("name", "").Build()
+	assert.False(t, field.HasArrayOps())
+
+	field = nero.NewFieldBuilder("uuid", [16]byte{}).Build()
+	assert.False(t, field.HasArrayOps())
+
+	field = nero.NewFieldBuilder("data", []byte{}).Build()
+	assert.False(t, field.HasArrayOps())
+}
+
+func TestFieldBuilderEnum(t *testing.T) {
+	type Race string
+	field := nero.NewFieldBuilder("race", Race("")).
+		Enum(Race("human"), Race("norn")).Build()
+	assert.True(t, field.IsEnum())
+	assert.Equal(t, []interface{}{Race("human"), Race("norn")}, field.EnumValues())
+
+	field = nero.NewFieldBuilder("name", "").Build()
+	assert.False(t, field.IsEnum())
+}
+
+func TestFieldBuilderValidate(t *testing.T) {
+	field := nero.NewFieldBuilder("name", "").
+		Validate(validation.MinLen(1), validation.MaxLen(50)).Build()
+	assert.True(t, field.HasValidation())
+	assert.Len(t, field.Rules(), 2)
+	assert.False(t, field.IsPointer())
+
+	field = nero.NewFieldBuilder("name", (*string)(nil)).Build()
+	assert.False(t, field.HasValidation())
+	assert.True(t, field.IsPointer())
+
+	assert.Equal(t, "validation.IsEmail", nero.FuncCall(validation.IsEmail))
+}
+
+func TestFieldBuilderDefault(t *testing.T) {
+	field := nero.NewFieldBuilder("age", 0).Default(18).Build()
+	assert.True(t, field.HasDefault())
+	assert.Equal(t, 18, field.DefaultValue())
+	assert.Nil(t, field.DefaultFunc())
+
+	field = nero.NewFieldBuilder("created_at", time.Time{}).
+		DefaultFunc(time.Now).Build()
+	assert.True(t, field.HasDefault())
+	assert.NotNil(t, field.DefaultFunc())
+	assert.False(t, field.DefaultFuncNeedsConversion())
+
+	field = nero.NewFieldBuilder("name", "").Build()
+	assert.False(t, field.HasDefault())
+}
@@ -0,0 +1,74 @@
+package gen
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/sf9v/nero"
+)
+
+// validateEdges validates the edges of the schema against their target schemas
+func validateEdges(schema *nero.Schema) error {
+	for _, edge := range schema.Edges() {
+		target := edge.Schema()
+		if target == nil || target.Identity() == nil {
+			return errors.Errorf("edge %q: target schema must have an identity", edge.Name())
+		}
+
+		if schema.HasCompositeKey() || target.HasCompositeKey() {
+			return errors.Errorf("edge %q: schemas with composite keys are not supported", edge.Name())
+		}
+
+		// e.g. *Team or []*Team
+		want := target.TypeInfo().T()
+		if !edge.IsManyToOne() {
+			want = reflect.SliceOf(want)
+		}
+
+		if got := edge.TypeInfo().T(); got != want {
+			return errors.Errorf("edge %q: expecting struct field type to be %s but got %s",
+				edge.Name(), want, got)
+		}
+
+		switch edge.Type() {
+		case nero.ManyToOne:
+			fk := schema.Field(edge.ForeignKey())
+			if fk == nil {
+				return errors.Errorf("edge %q: foreign key %q is not a field of %s",
+					edge.Name(), edge.ForeignKey(), schema.TypeName())
+			}
+
+			if !sameKeyType(fk, target.Identity()) {
+				return errors.Errorf("edge %q: foreign key %q must be of the same type as the identity of %s",
+					edge.Name(), edge.ForeignKey(), target.TypeName())
+			}
+		case nero.OneToMany:
+			fk := target.Field(edge.ForeignKey())
+			if fk == nil {
+				return errors.Errorf("edge %q: foreign key %q is not a field of %s",
+					edge.Name(), edge.ForeignKey(), target.TypeName())
+			}
+
+			if !sameKeyType(fk, schema.Identity()) {
+				return errors.Errorf("edge %q: foreign key %q must be of the same type as the identity of %s",
+					edge.Name(), edge.ForeignKey(), schema.TypeName())
+			}
+		case nero.ManyToMany:
+			if edge.Through() == "" || edge.ThroughFrom() == "" || edge.ThroughTo() == "" {
+				return errors.Errorf("edge %q: join table and columns are required", edge.Name())
+			}
+		}
+	}
+
+	return nil
+}
+
+// sameKeyType returns true if the (pointer to) foreign key and the identity have the same type
+func sameKeyType(fk, identity *nero.Field) bool {
+	t := fk.TypeInfo().T()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t == identity.TypeInfo().T()
+}
@@ -0,0 +1,125 @@
+package gen_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/gen"
+)
+
+type team struct {
+	ID   int64
+	Name string
+}
+
+func (t team) Schema() *nero.Schema {
+	return nero.NewSchemaBuilder(&t).
+		PkgName("teamrepo").Collection("teams").
+		Identity(nero.NewFieldBuilder("id", t.ID).StructField("ID").Build()).
+		Fields(nero.NewFieldBuilder("name", t.Name).Build()).
+		Build()
+}
+
+type member struct {
+	ID     int64
+	TeamID *int64
+	Email  string
+	Team   *team
+	Teams  []*team
+}
+
+func newMemberSchema(edges ...*nero.Edge) *nero.Schema {
+	m := member{}
+	return nero.NewSchemaBuilder(&m).
+		PkgName("memberrepo").Collection("members").
+		Identity(nero.NewFieldBuilder("id", m.ID).StructField("ID").Build()).
+		Fields(
+			nero.NewFieldBuilder("team_id", m.TeamID).StructField("TeamID").Build(),
+			nero.NewFieldBuilder("email", m.Email).Build(),
+		).
+		Edges(edges...).
+		Build()
+}
+
+func TestGenerateEdges(t *testing.T) {
+	m := member{}
+	tests := []struct {
+		name    string
+		edge    *nero.Edge
+		wantErr string
+	}{
+		{
+			name: "many to one",
+			edge: nero.NewEdgeBuilder("team", m.Team).
+				ManyToOne(team{}, "team_id").Build(),
+		},
+		{
+			name: "many to many",
+			edge: nero.NewEdgeBuilder("teams", m.Teams).
+				ManyToMany(team{}, "team_members", "member_id", "team_id").Build(),
+		},
+		{
+			name:    "no target",
+			edge:    nero.NewEdgeBuilder("team", m.Team).Build(),
+			wantErr: "validate edges: edge \"team\": target schema must have an identity",
+		},
+		{
+			name: "wrong struct field type",
+			edge: nero.NewEdgeBuilder("team", m.Teams).
+				ManyToOne(team{}, "team_id").Build(),
+			wantErr: "validate edges: edge \"team\": expecting struct field type to be *gen_test.team but got []*gen_test.team",
+		},
+		{
+			name: "unknown foreign key",
+			edge: nero.NewEdgeBuilder("team", m.Team).
+				ManyToOne(team{}, "group_id").Build(),
+			wantErr: "validate edges: edge \"team\": foreign key \"group_id\" is not a field of member",
+		},
+		{
+			name: "wrong foreign key type",
+			edge: nero.NewEdgeBuilder("team", m.Team).
+				ManyToOne(team{}, "email").Build(),
+			wantErr: "validate edges: edge \"team\": foreign key \"email\" must be of the same type as the identity of team",
+		},
+		{
+			name: "unknown foreign key of target",
+			edge: nero.NewEdgeBuilder("teams", m.Teams).
+				OneToMany(team{}, "member_id").Build(),
+			wantErr: "validate edges: edge \"teams\": foreign key \"member_id\" is not a field of team",
+		},
+		{
+			name: "no join table",
+			edge: nero.NewEdgeBuilder("teams", m.Teams).
+				ManyToMany(team{}, "", "member_id", "team_id").Build(),
+			wantErr: "validate edges: edge \"teams\": join table and columns are required",
+		},
+	}
+
+	// composite key
+	schema := nero.NewSchemaBuilder(&m).
+		PkgName("memberrepo").Collection("members").
+		Identity(
+			nero.NewFieldBuilder("id", m.ID).StructField("ID").Build(),
+			nero.NewFieldBuilder("email", m.Email).Build(),
+		).
+		Fields(nero.NewFieldBuilder("team_id", m.TeamID).StructField("TeamID").Build()).
+		Edges(nero.NewEdgeBuilder("team", m.Team).ManyToOne(team{}, "team_id").Build()).
+		Build()
+	_, err := gen.Generate(schema)
+	assert.EqualError(t, err, "validate edges: edge \"team\": schemas with composite keys are not supported")
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := gen.Generate(newMemberSchema(tc.edge))
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, files)
+		})
+	}
+}
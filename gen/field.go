@@ -0,0 +1,274 @@
+package gen
+
+import (
+	"go/token"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/validation"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// validateGenerators validates the generator functions of the fields
+func validateGenerators(schema *nero.Schema) error {
+	for _, field := range schema.AllFields() {
+		if !field.HasGenerator() {
+			continue
+		}
+
+		if field.IsAuto() {
+			return errors.Errorf("field %q: auto fields can't have a generator", field.Name())
+		}
+
+		t := reflect.TypeOf(field.Generator())
+		if t.Kind() != reflect.Func || t.NumIn() != 0 ||
+			t.NumOut() == 0 || t.NumOut() > 2 ||
+			(t.NumOut() == 2 && t.Out(1) != errorType) {
+			return errors.Errorf("field %q: expecting generator to be of the form func() T or func() (T, error) but got %s",
+				field.Name(), t)
+		}
+
+		if !t.Out(0).ConvertibleTo(field.TypeInfo().T()) {
+			return errors.Errorf("field %q: generator returns %s which is not convertible to %s",
+				field.Name(), t.Out(0), field.TypeInfo().T())
+		}
+
+		// closures and methods can't be referenced by the generated code
+		if call := field.GeneratorCall(); !isTopLevel(call) {
+			return errors.Errorf("field %q: generator must be an exported top-level function but got %s",
+				field.Name(), call)
+		}
+	}
+
+	return nil
+}
+
+// validateDefaults validates the default values and functions of the fields
+func validateDefaults(schema *nero.Schema) error {
+	for _, field := range schema.AllFields() {
+		if !field.HasDefault() {
+			continue
+		}
+
+		if field.IsAuto() {
+			return errors.Errorf("field %q: auto fields can't have a default", field.Name())
+		}
+
+		if field.DefaultValue() != nil && field.DefaultFunc() != nil {
+			return errors.Errorf("field %q: expecting either a default value or function", field.Name())
+		}
+
+		if field.DefaultValue() != nil {
+			switch elemType(field).Kind() {
+			case reflect.Array, reflect.Chan, reflect.Func, reflect.Interface,
+				reflect.Map, reflect.Ptr, reflect.Slice, reflect.Struct:
+				return errors.Errorf("field %q: expecting a default function for %s",
+					field.Name(), field.TypeInfo().T())
+			}
+
+			if err := validateValues(field, []interface{}{field.DefaultValue()}); err != nil {
+				return errors.Wrap(err, "default")
+			}
+
+			continue
+		}
+
+		t := reflect.TypeOf(field.DefaultFunc())
+		if t.Kind() != reflect.Func || t.NumIn() != 0 || t.NumOut() != 1 {
+			return errors.Errorf("field %q: expecting default function to be of the form func() T but got %s",
+				field.Name(), t)
+		}
+
+		if !t.Out(0).ConvertibleTo(field.TypeInfo().T()) {
+			return errors.Errorf("field %q: default function returns %s which is not convertible to %s",
+				field.Name(), t.Out(0), field.TypeInfo().T())
+		}
+
+		if call := nero.FuncCall(field.DefaultFunc()); !isTopLevel(call) {
+			return errors.Errorf("field %q: default function must be an exported top-level function but got %s",
+				field.Name(), call)
+		}
+	}
+
+	return nil
+}
+
+// validateEnums validates the allowed values of the fields
+func validateEnums(schema *nero.Schema) error {
+	for _, field := range schema.AllFields() {
+		if !field.IsEnum() {
+			continue
+		}
+
+		t := elemType(field)
+		switch t.Kind() {
+		case reflect.Array, reflect.Chan, reflect.Func, reflect.Interface,
+			reflect.Map, reflect.Ptr, reflect.Slice, reflect.Struct:
+			return errors.Errorf("field %q: %s can't be an enum", field.Name(), field.TypeInfo().T())
+		}
+
+		if err := validateValues(field, field.EnumValues()); err != nil {
+			return errors.Wrap(err, "enum")
+		}
+	}
+
+	return nil
+}
+
+// validateRules validates the validation rules of the fields
+func validateRules(schema *nero.Schema) error {
+	for _, field := range schema.AllFields() {
+		t := elemType(field)
+		for _, rule := range field.Rules() {
+			var err error
+			switch rule.Name() {
+			case validation.RuleMin, validation.RuleMax:
+				if !isNumeric(t) {
+					err = errors.Errorf("expecting a numeric field but got %s", field.TypeInfo().T())
+				} else if n := rule.Arg().(float64); isInteger(t) && n != math.Trunc(n) {
+					err = errors.Errorf("%v is not an integer", n)
+				}
+			case validation.RuleMinLen, validation.RuleMaxLen:
+				switch t.Kind() {
+				case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+				default:
+					err = errors.Errorf("expecting a string, slice or map field but got %s", field.TypeInfo().T())
+				}
+			case validation.RuleMatch, validation.RuleEmail:
+				if t.Kind() != reflect.String {
+					err = errors.Errorf("expecting a string field but got %s", field.TypeInfo().T())
+				} else if rule.Name() == validation.RuleMatch {
+					_, err = regexp.Compile(rule.Arg().(string))
+				}
+			case validation.RuleOneOf:
+				err = validateValues(field, rule.Args())
+			case validation.RuleFunc:
+				err = validateFunc(field, rule.Arg())
+			default:
+				err = errors.New("unknown rule")
+			}
+
+			if err != nil {
+				return errors.Wrapf(err, "field %q: %s rule", field.Name(), rule.Name())
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateValues validates that the values are convertible to the field type
+func validateValues(field *nero.Field, values []interface{}) error {
+	t := elemType(field)
+	for _, value := range values {
+		// ints are convertible to strings but as runes
+		vt := reflect.TypeOf(value)
+		if vt == nil || !vt.ConvertibleTo(t) ||
+			(t.Kind() == reflect.String && vt.Kind() != reflect.String) {
+			return errors.Errorf("field %q: value %#v is not convertible to %s",
+				field.Name(), value, t)
+		}
+	}
+
+	return nil
+}
+
+// validateFunc validates a custom validation function
+func validateFunc(field *nero.Field, fn interface{}) error {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func || t.NumIn() != 1 ||
+		t.NumOut() != 1 || t.Out(0) != errorType || t.In(0) != elemType(field) {
+		return errors.Errorf("expecting a function of the form func(%s) error but got %s",
+			elemType(field), t)
+	}
+
+	if call := nero.FuncCall(fn); !isTopLevel(call) {
+		return errors.Errorf("expecting an exported top-level function but got %s", call)
+	}
+
+	return nil
+}
+
+// elemType returns the type of the field or its element type if it's a pointer
+func elemType(field *nero.Field) reflect.Type {
+	t := field.TypeInfo().T()
+	if field.IsPointer() {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// isNumeric returns true if t is an int, uint or float
+func isNumeric(t reflect.Type) bool {
+	return isInteger(t) || t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+}
+
+// isInteger returns true if t is an int or uint
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// isTopLevel returns true if the qualified function name is of an exported
+// top-level function i.e. not a closure or a method
+func isTopLevel(call string) bool {
+	parts := strings.Split(call, ".")
+	return len(parts) == 2 && token.IsExported(parts[1])
+}
+
+// validateIndexes validates the indexes
+func validateIndexes(schema *nero.Schema) error {
+	methods := map[string]bool{}
+	for _, index := range schema.Indexes() {
+		if len(index.Columns()) == 0 {
+			return errors.New("expecting index to have at least one field")
+		}
+
+		for i, field := range index.Fields() {
+			if field == nil {
+				return errors.Errorf("index %v: unknown field %q", index.Columns(), index.Columns()[i])
+			}
+
+			if !field.IsComparable() {
+				return errors.Errorf("index %v: field %q is not comparable", index.Columns(), field.Name())
+			}
+		}
+
+		if methods[index.StructField()] {
+			return errors.Errorf("index %v: duplicate unique index", index.Columns())
+		}
+		methods[index.StructField()] = true
+	}
+
+	return nil
+}
+
+func validateTenant(schema *nero.Schema) error {
+	field := schema.TenantField()
+	if field == nil {
+		return nil
+	}
+
+	if field.IsAuto() || field.IsOptional() || field.IsNillable() || !field.IsComparable() {
+		return errors.Errorf("tenant field %q must be a required non-nillable field", field.Name())
+	}
+
+	for _, fld := range schema.AllFields() {
+		if fld != field && fld.Name() == field.Name() {
+			return errors.Errorf("tenant field %q is already declared", field.Name())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,479 @@
+package gen_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/gen"
+	"github.com/sf9v/nero/idgen"
+	"github.com/sf9v/nero/validation"
+)
+
+type item struct {
+	ID   string
+	Name string
+}
+
+func NewItemID() string {
+	return "item"
+}
+
+func newItemID() (string, error) {
+	return "", errors.New("unexported")
+}
+
+func TestGenerateGenerators(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator interface{}
+		auto      bool
+		wantErr   string
+	}{
+		{
+			name:      "returns error",
+			generator: idgen.UUIDv4,
+		},
+		{
+			name:      "no error",
+			generator: NewItemID,
+		},
+		{
+			name:      "auto",
+			generator: idgen.UUIDv4,
+			auto:      true,
+			wantErr:   "validate generators: field \"id\": auto fields can't have a generator",
+		},
+		{
+			name:      "not a func",
+			generator: "uuid",
+			wantErr:   "validate generators: field \"id\": expecting generator to be of the form func() T or func() (T, error) but got string",
+		},
+		{
+			name:      "wrong signature",
+			generator: func(string) string { return "" },
+			wantErr:   "validate generators: field \"id\": expecting generator to be of the form func() T or func() (T, error) but got func(string) string",
+		},
+		{
+			name:      "wrong second return value",
+			generator: func() (string, bool) { return "", false },
+			wantErr:   "validate generators: field \"id\": expecting generator to be of the form func() T or func() (T, error) but got func() (string, bool)",
+		},
+		{
+			name:      "not convertible",
+			generator: idgen.RawUUIDv4,
+			wantErr:   "validate generators: field \"id\": generator returns [16]uint8 which is not convertible to string",
+		},
+		{
+			name:      "closure",
+			generator: func() string { return "" },
+			wantErr:   "validate generators: field \"id\": generator must be an exported top-level function but got gen_test.TestGenerateGenerators.func3",
+		},
+		{
+			name:      "unexported",
+			generator: newItemID,
+			wantErr:   "validate generators: field \"id\": generator must be an exported top-level function but got gen_test.newItemID",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			i := item{}
+			idBuilder := nero.NewFieldBuilder("id", i.ID).StructField("ID").
+				Generator(tc.generator)
+			if tc.auto {
+				idBuilder = idBuilder.Auto()
+			}
+
+			schema := nero.NewSchemaBuilder(&i).
+				PkgName("itemrepo").Collection("items").
+				Identity(idBuilder.Build()).
+				Fields(nero.NewFieldBuilder("name", i.Name).Build()).
+				Build()
+			_, err := gen.Generate(schema)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGenerateEnums(t *testing.T) {
+	type Race string
+	type player struct {
+		ID   int64
+		Race Race
+		Tags []string
+	}
+
+	tests := []struct {
+		name    string
+		field   *nero.Field
+		wantErr string
+	}{
+		{
+			name:  "typed values",
+			field: nero.NewFieldBuilder("race", Race("")).Enum(Race("human"), Race("norn")).Build(),
+		},
+		{
+			name:  "untyped values",
+			field: nero.NewFieldBuilder("race", Race("")).Enum("human", "norn").Build(),
+		},
+		{
+			name:  "pointer",
+			field: nero.NewFieldBuilder("race", (*Race)(nil)).Enum("human").Build(),
+		},
+		{
+			name:    "not convertible",
+			field:   nero.NewFieldBuilder("race", Race("")).Enum(1.5).Build(),
+			wantErr: "validate enums: enum: field \"race\": value 1.5 is not convertible to gen_test.Race",
+		},
+		{
+			name:    "int to string",
+			field:   nero.NewFieldBuilder("race", Race("")).Enum(1).Build(),
+			wantErr: "validate enums: enum: field \"race\": value 1 is not convertible to gen_test.Race",
+		},
+		{
+			name:    "nil value",
+			field:   nero.NewFieldBuilder("race", Race("")).Enum(nil).Build(),
+			wantErr: "validate enums: enum: field \"race\": value <nil> is not convertible to gen_test.Race",
+		},
+		{
+			name:    "slice",
+			field:   nero.NewFieldBuilder("tags", []string{}).Enum("a").Build(),
+			wantErr: "validate enums: field \"tags\": []string can't be an enum",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := player{}
+			schema := nero.NewSchemaBuilder(&p).
+				PkgName("playerrepo").Collection("players").
+				Identity(nero.NewFieldBuilder("id", p.ID).StructField("ID").Build()).
+				Fields(tc.field).
+				Build()
+			_, err := gen.Generate(schema)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func ValidateName(string) error {
+	return nil
+}
+
+func TestGenerateRules(t *testing.T) {
+	type user struct {
+		ID       int64
+		Name     string
+		Nickname *string
+		Age      int
+		Score    float64
+		Tags     []string
+	}
+
+	u := user{}
+	tests := []struct {
+		name    string
+		field   *nero.Field
+		wantErr string
+	}{
+		{
+			name: "string",
+			field: nero.NewFieldBuilder("name", u.Name).Validate(
+				validation.MinLen(1), validation.MaxLen(50),
+				validation.Match("^[a-z]+$"), validation.Email(),
+				validation.OneOf("a", "b"), validation.Func(ValidateName),
+			).Build(),
+		},
+		{
+			name: "pointer",
+			field: nero.NewFieldBuilder("nickname", u.Nickname).
+				Validate(validation.MaxLen(50), validation.Func(ValidateName)).Build(),
+		},
+		{
+			name:  "numeric",
+			field: nero.NewFieldBuilder("age", u.Age).Validate(validation.Min(0), validation.Max(150)).Build(),
+		},
+		{
+			name:  "float",
+			field: nero.NewFieldBuilder("score", u.Score).Validate(validation.Max(0.5)).Build(),
+		},
+		{
+			name:  "slice",
+			field: nero.NewFieldBuilder("tags", u.Tags).Validate(validation.MaxLen(5)).Build(),
+		},
+		{
+			name:    "min on string",
+			field:   nero.NewFieldBuilder("name", u.Name).Validate(validation.Min(1)).Build(),
+			wantErr: "validate rules: field \"name\": min rule: expecting a numeric field but got string",
+		},
+		{
+			name:    "fraction on int",
+			field:   nero.NewFieldBuilder("age", u.Age).Validate(validation.Min(0.5)).Build(),
+			wantErr: "validate rules: field \"age\": min rule: 0.5 is not an integer",
+		},
+		{
+			name:    "minlen on int",
+			field:   nero.NewFieldBuilder("age", u.Age).Validate(validation.MinLen(1)).Build(),
+			wantErr: "validate rules: field \"age\": minlen rule: expecting a string, slice or map field but got int",
+		},
+		{
+			name:    "email on slice",
+			field:   nero.NewFieldBuilder("tags", u.Tags).Validate(validation.Email()).Build(),
+			wantErr: "validate rules: field \"tags\": email rule: expecting a string field but got []string",
+		},
+		{
+			name:    "bad pattern",
+			field:   nero.NewFieldBuilder("name", u.Name).Validate(validation.Match("(")).Build(),
+			wantErr: "validate rules: field \"name\": match rule: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name:    "not convertible",
+			field:   nero.NewFieldBuilder("age", u.Age).Validate(validation.OneOf("a")).Build(),
+			wantErr: "validate rules: field \"age\": oneof rule: field \"age\": value \"a\" is not convertible to int",
+		},
+		{
+			name:    "wrong func signature",
+			field:   nero.NewFieldBuilder("age", u.Age).Validate(validation.Func(ValidateName)).Build(),
+			wantErr: "validate rules: field \"age\": func rule: expecting a function of the form func(int) error but got func(string) error",
+		},
+		{
+			name: "closure",
+			field: nero.NewFieldBuilder("name", u.Name).
+				Validate(validation.Func(func(string) error { return nil })).Build(),
+			wantErr: "validate rules: field \"name\": func rule: expecting an exported top-level function but got gen_test.TestGenerateRules.func1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schema := nero.NewSchemaBuilder(&u).
+				PkgName("userrepo").Collection("users").
+				Identity(nero.NewFieldBuilder("id", u.ID).StructField("ID").Build()).
+				Fields(tc.field).
+				Build()
+			_, err := gen.Generate(schema)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func NewTags() []string {
+	return []string{}
+}
+
+func TestGenerateDefaults(t *testing.T) {
+	type user struct {
+		ID        int64
+		Age       int
+		Nickname  *string
+		Tags      []string
+		CreatedAt time.Time
+	}
+
+	u := user{}
+	tests := []struct {
+		name    string
+		field   *nero.Field
+		wantErr string
+	}{
+		{
+			name:  "value",
+			field: nero.NewFieldBuilder("age", u.Age).Default(18).Build(),
+		},
+		{
+			name:  "pointer",
+			field: nero.NewFieldBuilder("nickname", u.Nickname).Default("anon").Build(),
+		},
+		{
+			name:  "func",
+			field: nero.NewFieldBuilder("tags", u.Tags).DefaultFunc(NewTags).Build(),
+		},
+		{
+			name:  "time",
+			field: nero.NewFieldBuilder("created_at", u.CreatedAt).DefaultFunc(time.Now).Build(),
+		},
+		{
+			name:    "both",
+			field:   nero.NewFieldBuilder("tags", u.Tags).Default("a").DefaultFunc(NewTags).Build(),
+			wantErr: "validate defaults: field \"tags\": expecting either a default value or function",
+		},
+		{
+			name:    "auto",
+			field:   nero.NewFieldBuilder("age", u.Age).Auto().Default(18).Build(),
+			wantErr: "validate defaults: field \"age\": auto fields can't have a default",
+		},
+		{
+			name:    "slice value",
+			field:   nero.NewFieldBuilder("tags", u.Tags).Default([]string{}).Build(),
+			wantErr: "validate defaults: field \"tags\": expecting a default function for []string",
+		},
+		{
+			name:    "not convertible",
+			field:   nero.NewFieldBuilder("age", u.Age).Default("18").Build(),
+			wantErr: "validate defaults: default: field \"age\": value \"18\" is not convertible to int",
+		},
+		{
+			name:    "wrong func signature",
+			field:   nero.NewFieldBuilder("age", u.Age).DefaultFunc(time.Now).Build(),
+			wantErr: "validate defaults: field \"age\": default function returns time.Time which is not convertible to int",
+		},
+		{
+			name:    "closure",
+			field:   nero.NewFieldBuilder("age", u.Age).DefaultFunc(func() int { return 1 }).Build(),
+			wantErr: "validate defaults: field \"age\": default function must be an exported top-level function but got gen_test.TestGenerateDefaults.func1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schema := nero.NewSchemaBuilder(&u).
+				PkgName("userrepo").Collection("users").
+				Identity(nero.NewFieldBuilder("id", u.ID).StructField("ID").Build()).
+				Fields(tc.field).
+				Build()
+			_, err := gen.Generate(schema)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGenerateIndexes(t *testing.T) {
+	type user struct {
+		ID    int64
+		Email string
+		Name  string
+		Meta  map[string]string
+	}
+
+	u := user{}
+	tests := []struct {
+		name    string
+		indexes []*nero.SchemaIndex
+		wantErr string
+	}{
+		{
+			name:    "ok",
+			indexes: []*nero.SchemaIndex{nero.UniqueIndex("email"), nero.UniqueIndex("name", "email")},
+		},
+		{
+			name:    "no fields",
+			indexes: []*nero.SchemaIndex{nero.UniqueIndex()},
+			wantErr: "validate indexes: expecting index to have at least one field",
+		},
+		{
+			name:    "unknown field",
+			indexes: []*nero.SchemaIndex{nero.UniqueIndex("age")},
+			wantErr: "validate indexes: index [age]: unknown field \"age\"",
+		},
+		{
+			name:    "not comparable",
+			indexes: []*nero.SchemaIndex{nero.UniqueIndex("meta")},
+			wantErr: "validate indexes: index [meta]: field \"meta\" is not comparable",
+		},
+		{
+			name:    "duplicate",
+			indexes: []*nero.SchemaIndex{nero.UniqueIndex("email"), nero.UniqueIndex("email")},
+			wantErr: "validate indexes: index [email]: duplicate unique index",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schema := nero.NewSchemaBuilder(&u).
+				PkgName("userrepo").Collection("users").
+				Identity(nero.NewFieldBuilder("id", u.ID).StructField("ID").Build()).
+				Fields(
+					nero.NewFieldBuilder("email", u.Email).Build(),
+					nero.NewFieldBuilder("name", u.Name).Build(),
+					nero.NewFieldBuilder("meta", u.Meta).JSON().Build(),
+				).
+				Indexes(tc.indexes...).
+				Build()
+			_, err := gen.Generate(schema)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGenerateTenant(t *testing.T) {
+	type user struct {
+		ID       int64
+		Name     string
+		TenantID string
+		OrgID    *string
+	}
+
+	u := user{}
+	tests := []struct {
+		name    string
+		tenant  *nero.Field
+		wantErr string
+	}{
+		{
+			name:   "ok",
+			tenant: nero.NewFieldBuilder("tenant_id", u.TenantID).StructField("TenantID").Build(),
+		},
+		{
+			name:    "nillable",
+			tenant:  nero.NewFieldBuilder("org_id", u.OrgID).StructField("OrgID").Build(),
+			wantErr: "validate tenant: tenant field \"org_id\" must be a required non-nillable field",
+		},
+		{
+			name:    "optional",
+			tenant:  nero.NewFieldBuilder("tenant_id", u.TenantID).StructField("TenantID").Optional().Build(),
+			wantErr: "validate tenant: tenant field \"tenant_id\" must be a required non-nillable field",
+		},
+		{
+			name:    "duplicate",
+			tenant:  nero.NewFieldBuilder("name", u.Name).Build(),
+			wantErr: "validate tenant: tenant field \"name\" is already declared",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schema := nero.NewSchemaBuilder(&u).
+				PkgName("userrepo").Collection("users").
+				Identity(nero.NewFieldBuilder("id", u.ID).StructField("ID").Build()).
+				Fields(nero.NewFieldBuilder("name", u.Name).Build()).
+				TenantField(tc.tenant).
+				Build()
+			_, err := gen.Generate(schema)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
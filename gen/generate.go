@@ -7,6 +7,34 @@ import (
 
 // Generate generates the repository code
 func Generate(schema *nero.Schema) ([]*File, error) {
+	if err := validateGenerators(schema); err != nil {
+		return nil, errors.Wrap(err, "validate generators")
+	}
+
+	if err := validateDefaults(schema); err != nil {
+		return nil, errors.Wrap(err, "validate defaults")
+	}
+
+	if err := validateEnums(schema); err != nil {
+		return nil, errors.Wrap(err, "validate enums")
+	}
+
+	if err := validateRules(schema); err != nil {
+		return nil, errors.Wrap(err, "validate rules")
+	}
+
+	if err := validateIndexes(schema); err != nil {
+		return nil, errors.Wrap(err, "validate indexes")
+	}
+
+	if err := validateTenant(schema); err != nil {
+		return nil, errors.Wrap(err, "validate tenant")
+	}
+
+	if err := validateEdges(schema); err != nil {
+		return nil, errors.Wrap(err, "validate edges")
+	}
+
 	files := []*File{}
 	file, err := newMetaFile(schema)
 	if err != nil {
@@ -39,16 +39,19 @@ type Field int
 // String returns the string representation of the field
 func (f Field) String() string {
 	return [...]string{
-	"{{.Identity.Name}}",
-    {{range .Fields -}}
+    {{range .AllFields -}}
 		"{{.Name}}",
     {{end -}}
 	}[f]
 }
 
+// Column implements comparison.FieldArg
+func (f Field) Column() string {
+	return f.String()
+}
+
 const (
-	Field{{.Identity.StructField}} Field = iota
-	{{range $e := .Fields -}}
-		Field{{$e.StructField}}
+	{{range $i, $e := .AllFields -}}
+		Field{{$e.StructField}} {{if eq $i 0}}Field = iota{{end}}
     {{end -}}
 )`
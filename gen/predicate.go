@@ -16,11 +16,12 @@ func newPredicateFile(schema *nero.Schema) (*File, error) {
 	}
 
 	data := struct {
-		EqOps   []comparison.Operator
-		LtGtOps []comparison.Operator
-		NullOps []comparison.Operator
-		InOps   []comparison.Operator
-		Schema  *nero.Schema
+		EqOps    []comparison.Operator
+		LtGtOps  []comparison.Operator
+		NullOps  []comparison.Operator
+		InOps    []comparison.Operator
+		ArrayOps []comparison.Operator
+		Schema   *nero.Schema
 	}{
 		EqOps: []comparison.Operator{
 			comparison.Eq,
@@ -40,6 +41,11 @@ func newPredicateFile(schema *nero.Schema) (*File, error) {
 			comparison.In,
 			comparison.NotIn,
 		},
+		ArrayOps: []comparison.Operator{
+			comparison.Contains,
+			comparison.ContainedBy,
+			comparison.Overlap,
+		},
 		Schema: schema,
 	}
 
@@ -58,14 +64,13 @@ const predicatesTmpl = `
 package {{.Schema.PkgName}}
 
 import (
-	"github.com/lib/pq"
 	"github.com/sf9v/nero/comparison"
 	{{range $import := .Schema.Imports -}}
 		"{{$import}}"
 	{{end -}}
 )
 
-{{ $fields := prependToFields .Schema.Identity .Schema.Fields }}
+{{ $fields := .Schema.AllFields }}
 
 {{range $field := $fields -}}
 	{{if $field.IsComparable  -}}
@@ -76,11 +81,7 @@ import (
                     return append(preds, &comparison.Predicate{
                         Field: "{{$field.Name}}",
                         Op: comparison.{{$op.String}},
-                        {{if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
-                            Arg: pq.Array({{$field.Identifier}}),
-                        {{else -}}
-                            Arg: {{$field.Identifier}},
-                        {{end -}}
+                        Arg: {{$field.Identifier}},
                     })
                 }
             }
@@ -94,11 +95,7 @@ import (
                         return append(preds, &comparison.Predicate{
                             Field: "{{$field.Name}}",
                             Op: comparison.{{$op.String}},
-                            {{if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
-                                Arg: pq.Array({{$field.Identifier}}),
-                            {{else -}}
-                                Arg: {{$field.Identifier}},
-                            {{end -}}
+                            Arg: {{$field.Identifier}},
                         })
                     }
                 }
@@ -137,8 +134,112 @@ import (
             }
         {{end}}
 	{{end}}
+
+	{{if $field.HasArrayOps -}}
+        {{ range $op := $.ArrayOps }}
+            // {{$field.StructField}}{{$op.String}} {{$op.Desc}} operator on {{$field.StructField}} field
+            func {{$field.StructField}}{{$op.String}} ({{$field.Identifier}} {{rawType $field.TypeInfo.V}}) comparison.PredFunc {
+                return func(preds []*comparison.Predicate) []*comparison.Predicate {
+                    return append(preds, &comparison.Predicate{
+                        Field: "{{$field.Name}}",
+                        Op: comparison.{{$op.String}},
+                        Arg: {{$field.Identifier}},
+                    })
+                }
+            }
+        {{end}}
+
+        // {{$field.StructField}}AnyEq any equal operator on {{$field.StructField}} field
+        func {{$field.StructField}}AnyEq (v {{elemType $field.TypeInfo.V}}) comparison.PredFunc {
+            return func(preds []*comparison.Predicate) []*comparison.Predicate {
+                return append(preds, &comparison.Predicate{
+                    Field: "{{$field.Name}}",
+                    Op: comparison.AnyEq,
+                    Arg: v,
+                })
+            }
+        }
+	{{end}}
+
+	{{if $field.IsJSON -}}
+        // {{$field.StructField}}PathEq checks if the value at the path of {{$field.StructField}} field
+        // is equal to value. The path is a dot-separated list of keys e.g. "owner.name".
+        func {{$field.StructField}}PathEq (path, value string) comparison.PredFunc {
+            return func(preds []*comparison.Predicate) []*comparison.Predicate {
+                return append(preds, &comparison.Predicate{
+                    Field: "{{$field.Name}}",
+                    Op: comparison.PathEq,
+                    Arg: &comparison.PathValue{Path: path, Value: value},
+                })
+            }
+        }
+	{{end}}
 {{end -}}
 
+{{if .Schema.HasCompositeKey -}}
+    // KeyEq equal operator on the composite key fields
+    func KeyEq(key Key) comparison.PredFunc {
+        return func(preds []*comparison.Predicate) []*comparison.Predicate {
+            return append(preds,
+                {{range $field := .Schema.Identities -}}
+                    &comparison.Predicate{
+                        Field: "{{$field.Name}}",
+                        Op: comparison.Eq,
+                        Arg: key.{{$field.StructField}},
+                    },
+                {{end -}}
+            )
+        }
+    }
+{{end}}
+
+{{range $edge := .Schema.Edges -}}
+    // Has{{$edge.StructField}} checks if the {{$edge.Name}} edge has related rows
+    func Has{{$edge.StructField}}() comparison.PredFunc {
+        return Has{{$edge.StructField}}With()
+    }
+
+    // Has{{$edge.StructField}}With checks if the {{$edge.Name}} edge has related rows that match the
+    // predicates. The predicates are the ones from the repository of the target schema.
+    func Has{{$edge.StructField}}With(predFuncs ...comparison.PredFunc) comparison.PredFunc {
+        return func(preds []*comparison.Predicate) []*comparison.Predicate {
+            edgePreds := []*comparison.Predicate{}
+            for _, predFunc := range predFuncs {
+                edgePreds = predFunc(edgePreds)
+            }
+
+            {{$target := $edge.Schema -}}
+            return append(preds, &comparison.Predicate{
+                Field: "{{$edge.Name}}",
+                Op: comparison.Exists,
+                Arg: &comparison.Edge{
+                    Name: "{{$edge.Name}}",
+                    Namespace: "{{$target.Namespace}}",
+                    Collection: "{{$target.Collection}}",
+                    {{if $edge.IsManyToOne -}}
+                        Column: "{{$target.Identity.Name}}",
+                        Ref: "{{$edge.ForeignKey}}",
+                    {{else if $edge.IsOneToMany -}}
+                        Column: "{{$edge.ForeignKey}}",
+                        Ref: "{{$.Schema.Identity.Name}}",
+                    {{else -}}
+                        Column: "{{$target.Identity.Name}}",
+                        Ref: "{{$.Schema.Identity.Name}}",
+                        ThroughNamespace: "{{$.Schema.Namespace}}",
+                        Through: "{{$edge.Through}}",
+                        ThroughFrom: "{{$edge.ThroughFrom}}",
+                        ThroughTo: "{{$edge.ThroughTo}}",
+                    {{end -}}
+                    {{if $target.TenantField -}}
+                        TenantField: "{{$target.TenantField.Name}}",
+                    {{end -}}
+                    Preds: edgePreds,
+                },
+            })
+        }
+    }
+{{end}}
+
 {{ range $op := $.EqOps }} 
     // FieldX{{$op.String}}FieldY fieldX {{$op.Desc}} fieldY
     //
@@ -30,31 +30,47 @@ package {{.PkgName}}
 
 import (
 	"context"
+	"database/sql"
 	"reflect"
+	"regexp"
+	"time"
 	"github.com/pkg/errors"
 	"github.com/sf9v/nero"
 	"github.com/sf9v/nero/comparison"
 	"github.com/sf9v/nero/sort"
 	"github.com/sf9v/nero/aggregate"
+	"github.com/sf9v/nero/validation"
 	multierror "github.com/hashicorp/go-multierror"
 	{{range $import := .Imports -}}
 		"{{$import}}"
 	{{end -}}
 )
 
+{{- $keyType := rawType .Identity.TypeInfo.V -}}
+{{- if .HasCompositeKey -}}
+	{{- $keyType = "Key" -}}
+{{- end }}
 // Repository is an interface that wraps the methods 
 // for interacting with a {{.TypeInfo.Name}} repository
 type Repository interface {
 	// Tx begins a new transaction
 	Tx(context.Context) (nero.Tx, error)
+	// TxWithOptions begins a new transaction with the given options
+	TxWithOptions(context.Context, *sql.TxOptions) (nero.Tx, error)
+	// RunInTx runs a function in a transaction and retries it on serialization failures
+	RunInTx(context.Context, *nero.TxOptions, func(nero.Tx) error) error
 	// Create creates a {{.TypeName}}
-	Create(context.Context, *Creator) (id {{rawType .Identity.TypeInfo.V}}, err error)
+	Create(context.Context, *Creator) (id {{$keyType}}, err error)
 	// CreateTx creates a {{.TypeName}} in a transaction
-	CreateTx(context.Context, nero.Tx, *Creator) (id {{rawType .Identity.TypeInfo.V}}, err error)
+	CreateTx(context.Context, nero.Tx, *Creator) (id {{$keyType}}, err error)
 	// CreateMany batch creates {{.TypeNamePlural}}
 	CreateMany(context.Context, ...*Creator) error
 	// CreateManyTx batch creates {{.TypeNamePlural}} in a transaction
 	CreateManyTx(context.Context, nero.Tx, ...*Creator) error
+	// BulkInsert inserts a large number of {{.TypeNamePlural}}
+	BulkInsert(context.Context, ...*Creator) error
+	// BulkInsertTx inserts a large number of {{.TypeNamePlural}} in a transaction
+	BulkInsertTx(context.Context, nero.Tx, ...*Creator) error
 	// Query queries {{.TypeNamePlural}}
 	Query(context.Context, *Queryer) ([]{{rawType .TypeInfo.V}}, error)
 	// QueryTx queries {{.TypeNamePlural}} in a transaction
@@ -63,22 +79,61 @@ type Repository interface {
 	QueryOne(context.Context, *Queryer) ({{rawType .TypeInfo.V}}, error)
 	// QueryOneTx queries a {{.TypeName}} in a transaction
 	QueryOneTx(context.Context, nero.Tx, *Queryer) ({{rawType .TypeInfo.V}}, error)
+	// Exists checks if there's any {{.TypeName}} that matches the queryer
+	Exists(context.Context, *Queryer) (bool, error)
+	// ExistsTx checks if there's any {{.TypeName}} that matches the queryer in a transaction
+	ExistsTx(context.Context, nero.Tx, *Queryer) (bool, error)
+	// Count counts the {{.TypeNamePlural}} that match the queryer
+	Count(context.Context, *Queryer) (int64, error)
+	// CountTx counts the {{.TypeNamePlural}} that match the queryer in a transaction
+	CountTx(context.Context, nero.Tx, *Queryer) (int64, error)
 	// Update updates a {{.TypeName}} or many {{.TypeNamePlural}}
 	Update(context.Context, *Updater) (rowsAffected int64, err error)
 	// UpdateTx updates a {{.TypeName}} many {{.TypeNamePlural}} in a transaction
 	UpdateTx(context.Context, nero.Tx, *Updater) (rowsAffected int64, err error)
+	// UpdateReturning updates a {{.TypeName}} or many {{.TypeNamePlural}} and returns the updated {{.TypeNamePlural}}
+	UpdateReturning(context.Context, *Updater) ([]{{rawType .TypeInfo.V}}, error)
+	// UpdateReturningTx updates a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction and returns the updated {{.TypeNamePlural}}
+	UpdateReturningTx(context.Context, nero.Tx, *Updater) ([]{{rawType .TypeInfo.V}}, error)
+	// UpdateMany updates many {{.TypeNamePlural}} with different values
+	UpdateMany(context.Context, []*RowUpdate) (rowsAffected int64, err error)
+	// UpdateManyTx updates many {{.TypeNamePlural}} with different values in a transaction
+	UpdateManyTx(context.Context, nero.Tx, []*RowUpdate) (rowsAffected int64, err error)
 	// Delete deletes a {{.TypeName}} or many {{.TypeNamePlural}}
 	Delete(context.Context, *Deleter) (rowsAffected int64, err error)
 	// Delete deletes a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction
 	DeleteTx(context.Context, nero.Tx, *Deleter) (rowsAffected int64, err error)
+	// DeleteReturning deletes a {{.TypeName}} or many {{.TypeNamePlural}} and returns the deleted {{.TypeNamePlural}}
+	DeleteReturning(context.Context, *Deleter) ([]{{rawType .TypeInfo.V}}, error)
+	// DeleteReturningTx deletes a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction and returns the deleted {{.TypeNamePlural}}
+	DeleteReturningTx(context.Context, nero.Tx, *Deleter) ([]{{rawType .TypeInfo.V}}, error)
 	// Aggregate runs an aggregate query
 	Aggregate(context.Context, *Aggregator) error
 	// Aggregate runs an aggregate query in a transaction
 	AggregateTx(context.Context, nero.Tx, *Aggregator) error
+	{{range $index := .Indexes -}}
+		// QueryBy{{$index.StructField}} queries a {{$.TypeName}} by the unique {{$index.StructField}} index
+		QueryBy{{$index.StructField}}(context.Context, {{range $field := $index.Fields}}{{rawType $field.TypeInfo.V}}, {{end}}) ({{rawType $.TypeInfo.V}}, error)
+		// QueryBy{{$index.StructField}}Tx queries a {{$.TypeName}} by the unique {{$index.StructField}} index in a transaction
+		QueryBy{{$index.StructField}}Tx(context.Context, nero.Tx, {{range $field := $index.Fields}}{{rawType $field.TypeInfo.V}}, {{end}}) ({{rawType $.TypeInfo.V}}, error)
+		// UpsertBy{{$index.StructField}} creates a {{$.TypeName}} or updates the one with the same {{$index.StructField}}
+		UpsertBy{{$index.StructField}}(context.Context, *Creator) (id {{$keyType}}, err error)
+		// UpsertBy{{$index.StructField}}Tx creates a {{$.TypeName}} or updates the one with the same {{$index.StructField}} in a transaction
+		UpsertBy{{$index.StructField}}Tx(context.Context, nero.Tx, *Creator) (id {{$keyType}}, err error)
+	{{end -}}
 }
 
 
-{{ $fields := prependToFields .Identity .Fields }}
+{{ $fields := .AllFields }}
+
+{{if .HasCompositeKey -}}
+	// Key is the composite key of a {{.TypeName}}
+	type Key struct {
+		{{range $field := .Identities -}}
+			{{$field.StructField}} {{rawType $field.TypeInfo.V}}
+		{{end -}}
+	}
+{{end -}}
 
 // Creator is a create builder
 type Creator struct {
@@ -87,6 +142,7 @@ type Creator struct {
 		{{$field.Identifier}} {{rawType $field.TypeInfo.V}}
 		{{end -}}
 	{{end -}}
+	fields fieldSet
 }
 
 // NewCreator returns a Creator
@@ -95,33 +151,184 @@ func NewCreator() *Creator {
 }
 
 {{range $field := $fields }}
-	{{if ne $field.IsAuto true -}}
+	{{if and (ne $field.IsAuto true) (ne $field.IsTenant true) -}}
 		// {{$field.StructField}} sets the {{$field.StructField}} field
 		func (c *Creator) {{$field.StructField}}({{$field.Identifier}} {{rawType $field.TypeInfo.V}}) *Creator {
 			c.{{$field.Identifier}} = {{$field.Identifier}}
+			c.fields.add(Field{{$field.StructField}})
 			return c
 		}
 	{{end -}}
 {{end -}}
 
-// Validate validates the fields
+// Validate validates the fields. The required fields must be set, their zero
+// values are accepted when they are set explicitly.
 func (c *Creator) Validate() error {
 	var err error
-	{{range $field := .Fields -}}
-		{{if and (ne $field.IsOptional true) (ne $field.IsAuto true) -}}
-			if isZero(c.{{$field.Identifier}}) {
+	{{range $field := $fields -}}
+		{{if and (ne $field.IsOptional true) (ne $field.IsAuto true) (ne $field.HasDefault true) (ne $field.IsTenant true) -}}
+			if !c.fields.has(Field{{$field.StructField}}){{if $field.IsNillable}} || c.{{$field.Identifier}} == nil{{end}} {
 				err = multierror.Append(err, nero.NewErrRequiredField("{{$field.Name}}"))
 			}
-		{{end}} 
+
+		{{end -}}
+		{{if and ($field.HasValidation) (ne $field.IsAuto true) -}}
+			{{if $field.IsPointer -}}
+				if c.{{$field.Identifier}} != nil {
+					if e := validate{{$field.StructField}}(*c.{{$field.Identifier}}); e != nil {
+						err = multierror.Append(err, e)
+					}
+				}
+			{{else -}}
+				if !isZero(c.{{$field.Identifier}}) || c.fields.has(Field{{$field.StructField}}) {
+					if e := validate{{$field.StructField}}(c.{{$field.Identifier}}); e != nil {
+						err = multierror.Append(err, e)
+					}
+				}
+			{{end}}
+		{{end -}}
 	{{end}}
 
 	return err
 }
 
+{{range $field := $fields -}}
+	{{if $field.IsEnum -}}
+		// {{$field.StructField}}Values returns the allowed values of the {{$field.StructField}} field
+		func {{$field.StructField}}Values() []{{type $field.TypeInfo.V}} {
+			return []{{type $field.TypeInfo.V}}{
+				{{range $value := $field.EnumValues -}}
+					{{type $field.TypeInfo.V}}({{printf "%#v" $value}}),
+				{{end -}}
+			}
+		}
+
+		// isValid{{$field.StructField}} returns true if v is one of the allowed values of the {{$field.StructField}} field
+		func isValid{{$field.StructField}}(v {{type $field.TypeInfo.V}}) bool {
+			for _, value := range {{$field.StructField}}Values() {
+				if v == value {
+					return true
+				}
+			}
+
+			return false
+		}
+
+	{{end -}}
+{{end -}}
+
+{{range $field := $fields -}}
+	{{range $i, $rule := $field.Rules -}}
+		{{if eq $rule.Name "match" -}}
+			// {{$field.Identifier}}Regexp{{$i}} is a pattern of the {{$field.StructField}} field
+			var {{$field.Identifier}}Regexp{{$i}} = regexp.MustCompile({{printf "%q" $rule.Arg}})
+
+		{{end -}}
+	{{end -}}
+{{end -}}
+
+{{range $field := $fields -}}
+	{{if and ($field.HasValidation) (ne $field.IsAuto true) -}}
+		// validate{{$field.StructField}} validates a {{$field.StructField}} value
+		func validate{{$field.StructField}}(v {{type $field.TypeInfo.V}}) error {
+			var err error
+			{{if $field.IsEnum -}}
+				if !isValid{{$field.StructField}}(v) {
+					err = multierror.Append(err, nero.NewErrInvalidValue("{{$field.Name}}", v))
+				}
+
+			{{end -}}
+			{{range $i, $rule := $field.Rules -}}
+				{{if eq $rule.Name "func" -}}
+					if e := {{funcCall $rule.Arg}}(v); e != nil {
+						err = multierror.Append(err, &nero.ErrValidation{Field: "{{$field.Name}}", Rule: "{{$rule.Name}}", Value: v, Err: e})
+					}
+				{{else -}}
+					{{if eq $rule.Name "min" -}}
+						if v < {{$rule.Arg}} {
+					{{else if eq $rule.Name "max" -}}
+						if v > {{$rule.Arg}} {
+					{{else if eq $rule.Name "minlen" -}}
+						if validation.Len(v) < {{$rule.Arg}} {
+					{{else if eq $rule.Name "maxlen" -}}
+						if validation.Len(v) > {{$rule.Arg}} {
+					{{else if eq $rule.Name "match" -}}
+						if !{{$field.Identifier}}Regexp{{$i}}.MatchString(string(v)) {
+					{{else if eq $rule.Name "email" -}}
+						if !validation.IsEmail(string(v)) {
+					{{else if eq $rule.Name "oneof" -}}
+						if {{range $j, $value := $rule.Args}}{{if $j}} && {{end}}v != {{type $field.TypeInfo.V}}({{printf "%#v" $value}}){{end}} {
+					{{end -}}
+						err = multierror.Append(err, &nero.ErrValidation{Field: "{{$field.Name}}", Rule: "{{$rule.Name}}", Value: v})
+					}
+				{{end}}
+			{{end -}}
+			return err
+		}
+
+	{{end -}}
+{{end -}}
+
+// generate sets the fields that were not set to their default values, and
+// the empty fields that have a generator function e.g. the client-supplied identity
+func (c *Creator) generate() error {
+	{{range $field := $fields -}}
+		{{if $field.HasDefault -}}
+			if !c.fields.has(Field{{$field.StructField}}) {
+				{{if $field.DefaultFunc -}}
+					{{if $field.DefaultFuncNeedsConversion -}}
+						c.{{$field.Identifier}} = ({{rawType $field.TypeInfo.V}})({{funcCall $field.DefaultFunc}}())
+					{{else -}}
+						c.{{$field.Identifier}} = {{funcCall $field.DefaultFunc}}()
+					{{end -}}
+				{{else if $field.IsPointer -}}
+					v := {{type $field.TypeInfo.V}}({{printf "%#v" $field.DefaultValue}})
+					c.{{$field.Identifier}} = &v
+				{{else -}}
+					c.{{$field.Identifier}} = {{type $field.TypeInfo.V}}({{printf "%#v" $field.DefaultValue}})
+				{{end -}}
+			}
+
+		{{end -}}
+	{{end -}}
+	{{range $field := $fields -}}
+		{{if $field.HasGenerator -}}
+			if isZero(c.{{$field.Identifier}}) {
+				{{if $field.GeneratorReturnsError -}}
+					v, err := {{$field.GeneratorCall}}()
+					if err != nil {
+						return errors.Wrap(err, "generate {{$field.Name}}")
+					}
+				{{else -}}
+					v := {{$field.GeneratorCall}}()
+				{{end -}}
+				{{if $field.GeneratorNeedsConversion -}}
+					c.{{$field.Identifier}} = {{rawType $field.TypeInfo.V}}(v)
+				{{else -}}
+					c.{{$field.Identifier}} = v
+				{{end -}}
+				c.fields.add(Field{{$field.StructField}})
+			}
+
+		{{end -}}
+	{{end -}}
+	return nil
+}
+
 // Queryer is a query builder
 type Queryer struct {
 	limit  uint
 	offset uint
+	forUpdate,
+	forShare,
+	skipLocked,
+	noWait,
+	primary,
+	distinct bool
+	distinctOn []Field
+	{{range $edge := .Edges -}}
+		with{{$edge.StructField}} bool
+	{{end -}}
 	predFuncs []comparison.PredFunc
 	sortFuncs []sort.SortFunc
 }
@@ -155,37 +362,242 @@ func (q *Queryer) Offset(offset uint) *Queryer {
 	return q
 }
 
+// Distinct removes the duplicate rows i.e. SELECT DISTINCT ...
+func (q *Queryer) Distinct() *Queryer {
+	q.distinct = true
+	return q
+}
+
+// DistinctOn keeps the first row of each set of rows where the fields are equal i.e.
+// SELECT DISTINCT ON (...) ..., e.g. the latest row per player when it's sorted by
+// the player and then by the creation time in descending order. The fields must be
+// the leftmost ones of the sort. Back-ends that don't support it (e.g. SQLite)
+// return a *nero.ErrUnsupported error.
+func (q *Queryer) DistinctOn(fields ...Field) *Queryer {
+	q.distinctOn = append(q.distinctOn, fields...)
+	return q
+}
+
+// ForUpdate locks the selected rows for update i.e. SELECT ... FOR UPDATE.
+//
+// Row-level locking is only meaningful inside a transaction. Back-ends
+// that don't support row-level locks (e.g. SQLite) return a
+// *nero.ErrUnsupported error when any of the locking options is set.
+func (q *Queryer) ForUpdate() *Queryer {
+	q.forUpdate, q.forShare = true, false
+	return q
+}
+
+// ForShare locks the selected rows in share mode i.e. SELECT ... FOR SHARE.
+// See ForUpdate for the back-end support policy.
+func (q *Queryer) ForShare() *Queryer {
+	q.forShare, q.forUpdate = true, false
+	return q
+}
+
+// SkipLocked skips the rows that are already locked instead of waiting for them.
+// It only takes effect with ForUpdate or ForShare.
+func (q *Queryer) SkipLocked() *Queryer {
+	q.skipLocked, q.noWait = true, false
+	return q
+}
+
+// NoWait fails immediately instead of waiting for locked rows.
+// It only takes effect with ForUpdate or ForShare.
+func (q *Queryer) NoWait() *Queryer {
+	q.noWait, q.skipLocked = true, false
+	return q
+}
+
+// Primary sends the query to the primary db even if the repository has replicas,
+// e.g. for reading the rows that were just written. Locking queries are always
+// sent to the primary db.
+func (q *Queryer) Primary() *Queryer {
+	q.primary = true
+	return q
+}
+
+{{range $edge := .Edges -}}
+	// With{{$edge.StructField}} eager loads the {{$edge.Name}} edge into the {{$edge.StructField}}
+	// field. The related rows are loaded with a single query for all the results.
+	func (q *Queryer) With{{$edge.StructField}}() *Queryer {
+		q.with{{$edge.StructField}} = true
+		return q
+	}
+
+{{end -}}
+
 // Updater is an update builder
 type Updater struct {
 	{{range $field := .Fields -}}
-		{{if ne $field.IsAuto true -}}
+		{{if and (ne $field.IsAuto true) (ne $field.IsTenant true) -}}
 			{{$field.Identifier}} {{rawType $field.TypeInfo.V}}
 		{{end -}}
 	{{end -}}
+	fields fieldSet
+	exprs []*updateExpr
 	predFuncs []comparison.PredFunc
 }
 
+// updateExpr is an update expression
+type updateExpr struct {
+	field Field
+	// delta is the increment added by the AddX methods
+	delta interface{}
+	// expr and args are set by SetExpr
+	expr string
+	args []interface{}
+}
+
 // NewUpdater returns an Updater
 func NewUpdater() *Updater {
 	return &Updater{}
 }
 
 {{range $field := .Fields}}
-	{{if ne $field.IsAuto true -}}
+	{{if and (ne $field.IsAuto true) (ne $field.IsTenant true) -}}
 		// {{$field.StructField}} sets the {{$field.StructField}} field
-		func (c *Updater) {{$field.StructField}}({{$field.Identifier}} {{rawType $field.TypeInfo.V}}) *Updater {
-			c.{{$field.Identifier}} = {{$field.Identifier}}
-			return c
+		func (u *Updater) {{$field.StructField}}({{$field.Identifier}} {{rawType $field.TypeInfo.V}}) *Updater {
+			u.{{$field.Identifier}} = {{$field.Identifier}}
+			u.set(Field{{$field.StructField}})
+			return u
 		}
+
+		{{if $field.TypeInfo.IsNumeric -}}
+			// Add{{$field.StructField}} atomically adds n to the {{$field.StructField}} field
+			func (u *Updater) Add{{$field.StructField}}(n {{rawType $field.TypeInfo.V}}) *Updater {
+				u.setExpr(&updateExpr{field: Field{{$field.StructField}}, delta: n})
+				return u
+			}
+		{{end -}}
+
+		{{if $field.IsNillable -}}
+			// Clear{{$field.StructField}} sets the {{$field.StructField}} field to null
+			func (u *Updater) Clear{{$field.StructField}}() *Updater {
+				u.{{$field.Identifier}} = nil
+				u.set(Field{{$field.StructField}})
+				return u
+			}
+		{{end -}}
 	{{end -}}
 {{end -}}
 
+// SetExpr sets the field to a raw SQL expression e.g. SetExpr(FieldScore, "score * ?", 2).
+// The expression is evaluated by the database so the update is atomic.
+func (u *Updater) SetExpr(field Field, expr string, args ...interface{}) *Updater {
+	u.setExpr(&updateExpr{field: field, expr: expr, args: args})
+	return u
+}
+
+// set marks the field as set to its value. A field is assigned once per update,
+// so the value replaces the previous expression of the field.
+func (u *Updater) set(field Field) {
+	u.fields.add(field)
+	u.removeExpr(field)
+}
+
+// setExpr sets the expression of the field, which replaces
+// the previous value or expression of the field
+func (u *Updater) setExpr(e *updateExpr) {
+	u.fields.remove(e.field)
+	u.removeExpr(e.field)
+	u.exprs = append(u.exprs, e)
+}
+
+// removeExpr removes the expression of the field
+func (u *Updater) removeExpr(field Field) {
+	exprs := u.exprs[:0]
+	for _, e := range u.exprs {
+		if e.field != field {
+			exprs = append(exprs, e)
+		}
+	}
+	u.exprs = exprs
+}
+
+// Validate validates the fields that are set
+func (u *Updater) Validate() error {
+	var err error
+	{{range $field := .Fields -}}
+		{{if and ($field.HasValidation) (ne $field.IsAuto true) (ne $field.IsTenant true) -}}
+			if u.fields.has(Field{{$field.StructField}}){{if $field.IsPointer}} && u.{{$field.Identifier}} != nil{{end}} {
+				if e := validate{{$field.StructField}}({{if $field.IsPointer}}*{{end}}u.{{$field.Identifier}}); e != nil {
+					err = multierror.Append(err, e)
+				}
+			}
+
+		{{end -}}
+	{{end}}
+
+	return err
+}
+
 // Where applies predicates
 func (u *Updater) Where(predFuncs ...comparison.PredFunc) *Updater {
 	u.predFuncs = append(u.predFuncs, predFuncs...)
 	return u
 }
 
+// RowUpdate is the update of a single {{.TypeName}} that's identified by its key. Unlike
+// the Updater, each of the {{.TypeNamePlural}} that are passed to UpdateMany gets its own values.
+type RowUpdate struct {
+	key {{$keyType}}
+	{{range $field := .Fields -}}
+		{{if and (ne $field.IsAuto true) (ne $field.IsTenant true) -}}
+			{{$field.Identifier}} {{rawType $field.TypeInfo.V}}
+		{{end -}}
+	{{end -}}
+	fields fieldSet
+}
+
+// NewRowUpdate returns a RowUpdate of the {{.TypeName}} with the given key
+func NewRowUpdate(key {{$keyType}}) *RowUpdate {
+	return &RowUpdate{key: key}
+}
+
+{{range $field := .Fields}}
+	{{if and (ne $field.IsAuto true) (ne $field.IsTenant true) -}}
+		// {{$field.StructField}} sets the {{$field.StructField}} field
+		func (ru *RowUpdate) {{$field.StructField}}({{$field.Identifier}} {{rawType $field.TypeInfo.V}}) *RowUpdate {
+			ru.{{$field.Identifier}} = {{$field.Identifier}}
+			ru.fields.add(Field{{$field.StructField}})
+			return ru
+		}
+
+		{{if $field.IsNillable -}}
+			// Clear{{$field.StructField}} sets the {{$field.StructField}} field to null
+			func (ru *RowUpdate) Clear{{$field.StructField}}() *RowUpdate {
+				ru.{{$field.Identifier}} = nil
+				ru.fields.add(Field{{$field.StructField}})
+				return ru
+			}
+		{{end -}}
+	{{end -}}
+{{end -}}
+
+// Validate validates the fields that are set
+func (ru *RowUpdate) Validate() error {
+	return ru.updater().Validate()
+}
+
+// updater returns the Updater of the {{.TypeName}} with the values of the row update
+func (ru *RowUpdate) updater() *Updater {
+	u := &Updater{
+		{{range $field := .Fields -}}
+			{{if and (ne $field.IsAuto true) (ne $field.IsTenant true) -}}
+				{{$field.Identifier}}: ru.{{$field.Identifier}},
+			{{end -}}
+		{{end -}}
+		fields: ru.fields,
+	}
+
+	{{if .HasCompositeKey -}}
+		return u.Where(KeyEq(ru.key))
+	{{else -}}
+		return u.Where({{.Identity.StructField}}Eq(ru.key))
+	{{end -}}
+}
+
 // Deleter is a delete builder
 type Deleter struct {
 	predFuncs []comparison.PredFunc
@@ -241,6 +653,56 @@ func (a *Aggregator) GroupBy(fields ...Field) *Aggregator {
 	return a
 }
 
+// runInTx runs fn in a transaction and commits it if fn succeeds,
+// otherwise the transaction is rolled back. The whole transaction is
+// retried when the returned error is retryable.
+func runInTx(ctx context.Context, beginTx func(context.Context, *sql.TxOptions) (nero.Tx, error), 
+	isRetryable func(error) bool, opts *nero.TxOptions, fn func(nero.Tx) error) error {
+	if opts == nil {
+		opts = &nero.TxOptions{}
+	}
+
+	txOpts := &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+	for retry := 1; ; retry++ {
+		err := runTx(ctx, func(ctx context.Context) (nero.Tx, error) {
+			return beginTx(ctx, txOpts)
+		}, fn)
+		if err == nil || retry > opts.MaxRetries || !isRetryable(err) {
+			return err
+		}
+
+		if opts.Backoff != nil {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(opts.Backoff(retry)):
+			}
+		}
+	}
+}
+
+// runTx runs fn in a transaction and rolls it back on error or panic
+func runTx(ctx context.Context, beginTx func(context.Context) (nero.Tx, error), fn func(nero.Tx) error) error {
+	tx, err := beginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		return rollback(tx, err)
+	}
+
+	return tx.Commit()
+}
+
 // rollback performs a rollback
 func rollback(tx nero.Tx, err error) error {
 	rerr := tx.Rollback()
@@ -250,6 +712,68 @@ func rollback(tx nero.Tx, err error) error {
 	return err
 }
 
+// fieldSet is a bitset of fields
+type fieldSet []uint64
+
+// add adds the field to the set
+func (s *fieldSet) add(f Field) {
+	i := int(f) / 64
+	for len(*s) <= i {
+		*s = append(*s, 0)
+	}
+	(*s)[i] |= 1 << (uint(f) % 64)
+}
+
+// remove removes the field from the set
+func (s fieldSet) remove(f Field) {
+	i := int(f) / 64
+	if i < len(s) {
+		s[i] &^= 1 << (uint(f) % 64)
+	}
+}
+
+// has returns true if the field is in the set
+func (s fieldSet) has(f Field) bool {
+	i := int(f) / 64
+	return i < len(s) && s[i]&(1<<(uint(f)%64)) != 0
+}
+
+// contains returns true if the column is in the list
+func contains(columns []string, column string) bool {
+	for _, col := range columns {
+		if col == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// chunkCreators splits the creators into chunks of at most size creators
+func chunkCreators(cs []*Creator, size int) [][]*Creator {
+	if size < 1 {
+		size = 1
+	}
+
+	chunks := [][]*Creator{}
+	for size < len(cs) {
+		cs, chunks = cs[size:], append(chunks, cs[:size])
+	}
+
+	return append(chunks, cs)
+}
+
+// anyHas returns true if the field is set by any of the row updates
+func anyHas(rus []*RowUpdate, f Field) bool {
+	for _, ru := range rus {
+		if ru.fields.has(f) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // isZero checks if v is a zero-value
 func isZero(v interface{}) bool {
 	return reflect.ValueOf(v).IsZero()
@@ -5,8 +5,11 @@ go 1.15
 require (
 	github.com/Masterminds/squirrel v1.5.0
 	github.com/hashicorp/go-multierror v1.1.1
+	github.com/jackc/pgconn v1.9.0
+	github.com/jackc/pgtype v1.8.0
+	github.com/jackc/pgx/v4 v4.12.0
 	github.com/jinzhu/inflection v1.0.0
-	github.com/lib/pq v1.10.1
+	github.com/lib/pq v1.10.2
 	github.com/mattn/go-sqlite3 v1.14.7
 	github.com/pkg/errors v0.9.1
 	github.com/sf9v/mira v0.2.0
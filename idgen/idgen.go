@@ -0,0 +1,132 @@
+// Package idgen provides identity generators that can be used with
+// nero.FieldBuilder.Generator e.g. for tables with client-supplied IDs.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"time"
+)
+
+var (
+	// now and random are replaced in tests
+	now              = time.Now
+	random io.Reader = rand.Reader
+)
+
+// UUIDv4 returns a random UUID in its canonical string form
+func UUIDv4() (string, error) {
+	uuid, err := RawUUIDv4()
+	if err != nil {
+		return "", err
+	}
+
+	return formatUUID(uuid), nil
+}
+
+// RawUUIDv4 returns a random UUID
+func RawUUIDv4() ([16]byte, error) {
+	var uuid [16]byte
+	if _, err := io.ReadFull(random, uuid[:]); err != nil {
+		return uuid, err
+	}
+
+	uuid[6] = uuid[6]&0x0f | 0x40 // version 4
+	uuid[8] = uuid[8]&0x3f | 0x80 // RFC 4122 variant
+	return uuid, nil
+}
+
+// UUIDv7 returns a time-ordered UUID in its canonical string form
+func UUIDv7() (string, error) {
+	uuid, err := RawUUIDv7()
+	if err != nil {
+		return "", err
+	}
+
+	return formatUUID(uuid), nil
+}
+
+// RawUUIDv7 returns a time-ordered UUID, where the first
+// 48 bits are the unix timestamp in milliseconds
+func RawUUIDv7() ([16]byte, error) {
+	var uuid [16]byte
+	if _, err := io.ReadFull(random, uuid[6:]); err != nil {
+		return uuid, err
+	}
+
+	putMillis(uuid[:6], now())
+	uuid[6] = uuid[6]&0x0f | 0x70 // version 7
+	uuid[8] = uuid[8]&0x3f | 0x80 // RFC 4122 variant
+	return uuid, nil
+}
+
+// ULID returns a lexicographically sortable identifier, encoded in
+// 26 characters of Crockford's base32 (see https://github.com/ulid/spec)
+func ULID() (string, error) {
+	var id [16]byte
+	if _, err := io.ReadFull(random, id[6:]); err != nil {
+		return "", err
+	}
+	putMillis(id[:6], now())
+
+	const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	b := make([]byte, 26)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = alphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(b), nil
+}
+
+// ksuidEpoch is the KSUID epoch i.e. 2014-05-13T16:53:20Z
+const ksuidEpoch = 1400000000
+
+// KSUID returns a K-sortable unique identifier, encoded in 27
+// characters of base62 (see https://github.com/segmentio/ksuid)
+func KSUID() (string, error) {
+	var id [20]byte
+	if _, err := io.ReadFull(random, id[4:]); err != nil {
+		return "", err
+	}
+	binary.BigEndian.PutUint32(id[:4], uint32(now().Unix()-ksuidEpoch))
+
+	const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	n, base, mod := new(big.Int).SetBytes(id[:]), big.NewInt(62), new(big.Int)
+	b := make([]byte, 27)
+	for i := len(b) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		b[i] = alphabet[mod.Int64()]
+	}
+
+	return string(b), nil
+}
+
+// putMillis puts the 48-bit unix timestamp in milliseconds of t in b
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// formatUUID returns the canonical string form of the UUID
+func formatUUID(uuid [16]byte) string {
+	b := make([]byte, 36)
+	hex.Encode(b[0:8], uuid[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], uuid[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], uuid[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], uuid[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], uuid[10:])
+	return string(b)
+}
@@ -0,0 +1,61 @@
+package idgen
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerators(t *testing.T) {
+	defer func(n func() time.Time, r io.Reader) {
+		now, random = n, r
+	}(now, random)
+
+	// 2021-05-01T00:00:00Z with zero random bytes
+	now = func() time.Time { return time.Unix(1619827200, 0) }
+	random = bytes.NewReader(make([]byte, 1024))
+
+	uuid, err := UUIDv4()
+	require.NoError(t, err)
+	assert.Equal(t, "00000000-0000-4000-8000-000000000000", uuid)
+
+	uuid, err = UUIDv7()
+	require.NoError(t, err)
+	assert.Equal(t, "01792539-9000-7000-8000-000000000000", uuid)
+
+	ulid, err := ULID()
+	require.NoError(t, err)
+	assert.Equal(t, "01F4JKK4000000000000000000", ulid)
+
+	ksuid, err := KSUID()
+	require.NoError(t, err)
+	assert.Equal(t, "1ruXyASMpFRhNAMss2d2Y7iKem0", ksuid)
+
+	// random errors
+	random = iotest.ErrReader(errors.New("no entropy"))
+	_, err = UUIDv4()
+	assert.Error(t, err)
+	_, err = UUIDv7()
+	assert.Error(t, err)
+	_, err = ULID()
+	assert.Error(t, err)
+	_, err = KSUID()
+	assert.Error(t, err)
+}
+
+func TestUnique(t *testing.T) {
+	generators := []func() (string, error){UUIDv4, UUIDv7, ULID, KSUID}
+	for _, generate := range generators {
+		id1, err := generate()
+		require.NoError(t, err)
+		id2, err := generate()
+		require.NoError(t, err)
+		assert.NotEqual(t, id1, id2)
+	}
+}
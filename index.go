@@ -0,0 +1,42 @@
+package nero
+
+import "strings"
+
+// SchemaIndex is a unique index of a schema
+type SchemaIndex struct {
+	// columns are the names of the indexed fields
+	columns []string
+	// fields are the indexed fields, they're resolved when the schema is built
+	fields []*Field
+}
+
+// UniqueIndex takes the names of the indexed fields and returns a SchemaIndex.
+// The lookup and upsert methods are generated for the unique indexes e.g.
+// QueryByEmail and UpsertByEmail.
+func UniqueIndex(columns ...string) *SchemaIndex {
+	return &SchemaIndex{columns: columns}
+}
+
+// Columns returns the names of the indexed fields
+func (i *SchemaIndex) Columns() []string {
+	return i.columns[:]
+}
+
+// Fields returns the indexed fields. A field is nil if the schema
+// doesn't have a field with the same name.
+func (i *SchemaIndex) Fields() []*Field {
+	return i.fields[:]
+}
+
+// StructField returns the concatenated struct fields of the indexed
+// fields e.g. NameTeamID, it's used as the suffix of the generated methods
+func (i *SchemaIndex) StructField() string {
+	sb := strings.Builder{}
+	for _, field := range i.fields {
+		if field != nil {
+			sb.WriteString(field.StructField())
+		}
+	}
+
+	return sb.String()
+}
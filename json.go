@@ -0,0 +1,63 @@
+package nero
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// JSON wraps v in a ValueScanner that marshals it to JSON on write and
+// unmarshals it from JSON on scan. v must be a pointer when it's scanned into.
+func JSON(v interface{}) ValueScanner {
+	return &jsonValue{v}
+}
+
+// jsonValue is a JSON-encoded value
+type jsonValue struct {
+	v interface{}
+}
+
+// Value implements driver.Valuer
+func (j *jsonValue) Value() (driver.Value, error) {
+	if isNil(j.v) {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(j.v)
+	if err != nil {
+		return nil, err
+	}
+
+	// a string is sent as text instead of bytea
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (j *jsonValue) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(src, j.v)
+	case string:
+		return json.Unmarshal([]byte(src), j.v)
+	default:
+		return fmt.Errorf("nero: cannot scan %T into a JSON value", src)
+	}
+}
+
+// isNil returns true if v is nil or a nil pointer, map or slice
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package nero_test
+
+import (
+	"testing"
+
+	"github.com/sf9v/nero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+
+	t.Run("Value", func(t *testing.T) {
+		v, err := nero.JSON(item{Name: "x"}).Value()
+		require.NoError(t, err)
+		assert.Equal(t, `{"name":"x"}`, v)
+
+		v, err = nero.JSON(map[string]string{"a": "b"}).Value()
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":"b"}`, v)
+
+		var m map[string]string
+		v, err = nero.JSON(m).Value()
+		require.NoError(t, err)
+		assert.Nil(t, v)
+
+		var p *item
+		v, err = nero.JSON(p).Value()
+		require.NoError(t, err)
+		assert.Nil(t, v)
+
+		_, err = nero.JSON(make(chan int)).Value()
+		assert.Error(t, err)
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		var it item
+		require.NoError(t, nero.JSON(&it).Scan([]byte(`{"name":"x"}`)))
+		assert.Equal(t, "x", it.Name)
+
+		var m map[string]string
+		require.NoError(t, nero.JSON(&m).Scan(`{"a":"b"}`))
+		assert.Equal(t, map[string]string{"a": "b"}, m)
+
+		var p *item
+		require.NoError(t, nero.JSON(&p).Scan(nil))
+		assert.Nil(t, p)
+
+		assert.Error(t, nero.JSON(&it).Scan(1))
+		assert.Error(t, nero.JSON(&it).Scan("{"))
+	})
+}
@@ -38,6 +38,7 @@ package {{.PkgName}}
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"io"
@@ -56,30 +57,43 @@ import (
 	{{end -}}
 )
 
-{{ $fields := prependToFields .Identity .Fields }}
+{{ $fields := .AllFields }}
 
+{{- $keyType := rawType .Identity.TypeInfo.V -}}
+{{- $keyZero := zeroValue .Identity.TypeInfo.V -}}
+{{- if .HasCompositeKey -}}
+	{{- $keyType = "Key" -}}
+	{{- $keyZero = "Key{}" -}}
+{{- end }}
 // PostgresRepository is a repository that uses PostgreSQL as data store
 type PostgresRepository struct {
-	db  *sql.DB
+	db  nero.DB
 	logger nero.Logger
 	debug bool
+	txFromContext bool
+	replicas []nero.DB
+	balancer nero.Balancer
+	schema string
+	{{if .TenantField -}}
+		tenantExtractor nero.TenantExtractor
+	{{end -}}
 }
 
 var _ Repository = (*PostgresRepository)(nil)
 
-// NewPostgresRepository returns a PostgresRepository
-func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+// NewPostgresRepository returns a PostgresRepository where db
+// can be a *sql.DB, a *sql.Conn, a *sql.Tx or any nero.DB
+func NewPostgresRepository(db nero.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
 // Debug enables debug mode
 func (repo *PostgresRepository) Debug() *PostgresRepository {
 	l := log.New(os.Stdout, "[nero] ", log.LstdFlags | log.Lmicroseconds | log.Lmsgprefix)
-	return &PostgresRepository{
-		db:  repo.db,	
-		debug: true,
-		logger: l,
-	}
+	r := *repo
+	r.debug = true
+	r.logger = l
+	return &r
 }
 
 // WithLogger overrides the default logger
@@ -88,29 +102,274 @@ func (repo *PostgresRepository) WithLogger(logger nero.Logger) *PostgresReposito
 	return repo
 }
 
+// WithTxFromContext enables the tx-from-context mode where the non-Tx methods
+// run in the transaction carried by the context (see nero.ContextWithTx), if any
+func (repo *PostgresRepository) WithTxFromContext() *PostgresRepository {
+	repo.txFromContext = true
+	return repo
+}
+
+// WithReplicas sets the read-only replicas. Query, QueryOne and Aggregate are
+// sent to a replica chosen by the balancer (round-robin by default), while the
+// writes and the queries in a transaction are sent to the primary db.
+func (repo *PostgresRepository) WithReplicas(dbs ...nero.DB) *PostgresRepository {
+	repo.replicas = dbs
+	if repo.balancer == nil {
+		repo.balancer = nero.RoundRobinBalancer()
+	}
+	return repo
+}
+
+// WithBalancer overrides the balancer used for picking the replicas
+func (repo *PostgresRepository) WithBalancer(balancer nero.Balancer) *PostgresRepository {
+	repo.balancer = balancer
+	return repo
+}
+
+// WithSchema returns a copy of the repository that qualifies the tables with the
+// schema e.g. a per-tenant schema on PostgreSQL or an attached database on SQLite
+func (repo *PostgresRepository) WithSchema(schema string) *PostgresRepository {
+	r := *repo
+	r.schema = schema
+	return &r
+}
+
+// table returns the quoted name of the {{.Collection}} table
+func (repo *PostgresRepository) table() string {
+	return repo.qualify("{{.Namespace}}", "{{.Collection}}")
+}
+
+// qualify quotes the table name and qualifies it with the schema i.e. "schema"."table".
+// The schema that's set by WithSchema takes precedence over the namespace.
+func (repo *PostgresRepository) qualify(namespace, table string) string {
+	if repo.schema != "" {
+		namespace = repo.schema
+	}
+
+	if namespace == "" {
+		return nero.QuoteIdent(table)
+	}
+
+	return nero.QuoteIdent(namespace) + "." + nero.QuoteIdent(table)
+}
+
+{{if .TenantField -}}
+{{$tenant := .TenantField -}}
+// WithTenantExtractor overrides the function that extracts the tenant ID
+// from the context, which is nero.TenantFromContext by default
+func (repo *PostgresRepository) WithTenantExtractor(extractor nero.TenantExtractor) *PostgresRepository {
+	repo.tenantExtractor = extractor
+	return repo
+}
+
+// tenant returns the tenant ID that's carried by the context
+func (repo *PostgresRepository) tenant(ctx context.Context) ({{rawType $tenant.TypeInfo.V}}, error) {
+	extract := repo.tenantExtractor
+	if extract == nil {
+		extract = nero.TenantFromContext
+	}
+
+	v, err := extract(ctx)
+	if err != nil {
+		return {{zeroValue $tenant.TypeInfo.V}}, err
+	}
+
+	tenantID, ok := v.({{rawType $tenant.TypeInfo.V}})
+	if !ok {
+		return {{zeroValue $tenant.TypeInfo.V}}, errors.Errorf("expecting tenant ID to be a {{rawType $tenant.TypeInfo.V}}, got %T", v)
+	}
+
+	return tenantID, nil
+}
+
+// setTenant sets the {{$tenant.StructField}} field of the creator to the tenant ID
+func (repo *PostgresRepository) setTenant(ctx context.Context, c *Creator) error {
+	tenantID, err := repo.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.{{$tenant.Identifier}} = tenantID
+	return nil
+}
+
+{{end -}}
+{{if .Edges -}}
+// edgeTenant returns the tenant ID that scopes the edges to the tenant-scoped schemas
+func (repo *PostgresRepository) edgeTenant(ctx context.Context) (interface{}, error) {
+	{{if .TenantField -}}
+		return repo.tenant(ctx)
+	{{else -}}
+		return nero.TenantFromContext(ctx)
+	{{end -}}
+}
+
+// scopeEdges appends the predicate of the tenant to the Has<Edge>With
+// predicates of the tenant-scoped targets, including the nested ones
+func (repo *PostgresRepository) scopeEdges(ctx context.Context, preds []*comparison.Predicate) ([]*comparison.Predicate, error) {
+	scoped := make([]*comparison.Predicate, 0, len(preds))
+	for _, pred := range preds {
+		edge, ok := pred.Arg.(*comparison.Edge)
+		if pred.Op != comparison.Exists || !ok {
+			scoped = append(scoped, pred)
+			continue
+		}
+
+		edgePreds, err := repo.scopeEdges(ctx, edge.Preds)
+		if err != nil {
+			return nil, err
+		}
+
+		if edge.TenantField != "" {
+			tenantID, err := repo.edgeTenant(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			edgePreds = append(edgePreds, &comparison.Predicate{
+				Field: edge.TenantField, Op: comparison.Eq, Arg: tenantID,
+			})
+		}
+
+		scopedEdge := *edge
+		scopedEdge.Preds = edgePreds
+		scoped = append(scoped, &comparison.Predicate{Field: pred.Field, Op: pred.Op, Arg: &scopedEdge})
+	}
+
+	return scoped, nil
+}
+
+{{end -}}
+{{if or .TenantField .Edges -}}
+{{if .TenantField -}}
+// scope appends the predicate of the tenant to the predicates so the
+// statements never read or write the rows of the other tenants
+{{- if .Edges}}. The
+// Has<Edge>With predicates of the tenant-scoped edges are scoped too.
+{{- end}}
+{{else -}}
+// scope appends the predicate of the tenant to the Has<Edge>With predicates
+// of the tenant-scoped edges
+{{end -}}
+func (repo *PostgresRepository) scope(ctx context.Context, preds []*comparison.Predicate) ([]*comparison.Predicate, error) {
+	{{if .TenantField -}}
+		tenantID, err := repo.tenant(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		preds = append(preds, &comparison.Predicate{
+			Field: "{{.TenantField.Name}}", Op: comparison.Eq, Arg: tenantID,
+		})
+
+	{{end -}}
+	{{if .Edges -}}
+		return repo.scopeEdges(ctx, preds)
+	{{else -}}
+		return preds, nil
+	{{end -}}
+}
+
+{{end -}}
+
+// runner returns the transaction carried by the context when
+// the tx-from-context mode is enabled, otherwise it returns the db
+func (repo *PostgresRepository) runner(ctx context.Context) (nero.SQLRunner, error) {
+	if !repo.txFromContext {
+		return nero.AsSQLRunner(repo.db), nil
+	}
+
+	tx, ok := nero.TxFromContext(ctx)
+	if !ok {
+		return nero.AsSQLRunner(repo.db), nil
+	}
+
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return txx, nil
+}
+
+// readRunner returns a replica chosen by the balancer. It falls back to runner
+// when there are no replicas, when primary is true or when the context carries
+// a transaction in the tx-from-context mode.
+func (repo *PostgresRepository) readRunner(ctx context.Context, primary bool) (nero.SQLRunner, error) {
+	if primary || len(repo.replicas) == 0 || repo.balancer == nil {
+		return repo.runner(ctx)
+	}
+
+	if _, ok := nero.TxFromContext(ctx); ok && repo.txFromContext {
+		return repo.runner(ctx)
+	}
+
+	return nero.AsSQLRunner(repo.balancer(repo.replicas)), nil
+}
+
 // Tx begins a new transaction
 func (repo *PostgresRepository) Tx(ctx context.Context) (nero.Tx, error) {
-	return repo.db.BeginTx(ctx, nil)
+	return repo.TxWithOptions(ctx, nil)
+}
+
+// TxWithOptions begins a new transaction with the given options. If the
+// repository's db is already a transaction, a nested transaction is returned.
+func (repo *PostgresRepository) TxWithOptions(ctx context.Context, opts *sql.TxOptions) (nero.Tx, error) {
+	return nero.BeginTx(ctx, repo.db, opts)
+}
+
+// RunInTx runs fn in a transaction. The transaction is committed if fn returns nil
+// and rolled back if fn returns an error or panics. The transaction is retried
+// on serialization failures according to opts, which may be nil.
+func (repo *PostgresRepository) RunInTx(ctx context.Context, opts *nero.TxOptions, fn func(nero.Tx) error) error {
+	return runInTx(ctx, repo.TxWithOptions, repo.isRetryable, opts, fn)
+}
+
+// isRetryable returns true if err is a serialization failure or a deadlock
+func (repo *PostgresRepository) isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+
+	return false
 }
 
 // Create creates a {{.TypeName}}
-func (repo *PostgresRepository) Create(ctx context.Context, c *Creator) ({{rawType .Identity.TypeInfo.V}}, error) {
-	return repo.create(ctx, repo.db, c)
+func (repo *PostgresRepository) Create(ctx context.Context, c *Creator) ({{$keyType}}, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return {{$keyZero}}, err
+	}
+
+	return repo.create(ctx, runner, c)
 }
 
 // CreateTx creates a {{.TypeName}} in a transaction
-func (repo *PostgresRepository) CreateTx(ctx context.Context, tx nero.Tx, c *Creator) ({{rawType .Identity.TypeInfo.V}}, error) {
-	txx, ok := tx.(*sql.Tx)
+func (repo *PostgresRepository) CreateTx(ctx context.Context, tx nero.Tx, c *Creator) ({{$keyType}}, error) {
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return {{zeroValue .Identity.TypeInfo.V}}, errors.New("expecting tx to be *sql.Tx")
+		return {{$keyZero}}, errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.create(ctx, txx, c)
 }
 
-func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunner, c *Creator) ({{rawType .Identity.TypeInfo.V}}, error) {
+// create inserts a {{.TypeName}}. If conflict columns are given, the row that has the
+// same values for these columns is updated instead i.e. INSERT ... ON CONFLICT DO UPDATE.
+func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunner, c *Creator, conflict ...string) ({{$keyType}}, error) {
+	{{if .TenantField -}}
+		if err := repo.setTenant(ctx, c); err != nil {
+			return {{$keyZero}}, err
+		}
+
+	{{end -}}
+	if err := c.generate(); err != nil {
+		return {{$keyZero}}, err
+	}
+
 	if err := c.Validate(); err != nil {
-		return {{zeroValue .Identity.TypeInfo.V}}, err
+		return {{$keyZero}}, err
 	}
 
 	columns := []string{
@@ -124,7 +383,9 @@ func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunne
 	values := []interface{}{
 		{{range $field := $fields -}}
 			{{if and (ne $field.IsOptional true) (ne $field.IsAuto true) -}}
-				{{if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
+				{{if $field.IsJSON -}}
+					nero.JSON(c.{{$field.Identifier}}),
+				{{else if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
 					pq.Array(c.{{$field.Identifier}}),
 				{{else -}}
 					c.{{$field.Identifier}},
@@ -136,16 +397,56 @@ func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunne
 	{{range $field := $fields -}}
 		{{if and ($field.IsOptional) (ne $field.IsAuto true) -}}
 			if !isZero(c.{{$field.Identifier}}) {
-				columns = append(columns, "{{$field.Name}}")
-				values = append(values, c.{{$field.Identifier}})
+				columns = append(columns, "\"{{$field.Name}}\"")
+				{{if $field.IsJSON -}}
+					values = append(values, nero.JSON(c.{{$field.Identifier}}))
+				{{else if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
+					values = append(values, pq.Array(c.{{$field.Identifier}}))
+				{{else -}}
+					values = append(values, c.{{$field.Identifier}})
+				{{end -}}
 			}
 		{{end -}}
 	{{end}}
 
-	qb := squirrel.Insert("\"{{.Collection}}\"").
+	suffix := "RETURNING {{range $i, $field := .Identities}}{{if $i}}, {{end}}\"{{$field.Name}}\"{{end}}"
+	if len(conflict) > 0 {
+		{{if .TenantField -}}
+			// the unique indexes are per tenant, so the row of another
+			// tenant is never in conflict
+			if !contains(conflict, "\"{{.TenantField.Name}}\"") {
+				conflict = append([]string{"\"{{.TenantField.Name}}\""}, conflict...)
+			}
+
+		{{end -}}
+		// the key of the existing row is kept so the rows that reference it
+		// aren't broken and the returned key is the one of the existing row
+		keys := []string{
+			{{range $field := .Identities -}}
+				"\"{{$field.Name}}\"",
+			{{end -}}
+		}
+		sets := []string{}
+		for _, col := range columns {
+			if !contains(conflict, col) && !contains(keys, col) {
+				sets = append(sets, col+" = EXCLUDED."+col)
+			}
+		}
+
+		// the row is only returned if it's updated
+		if len(sets) == 0 {
+			sets = append(sets, conflict[0]+" = EXCLUDED."+conflict[0])
+		}
+
+		onConflict := fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s ", strings.Join(conflict, ", "),
+			strings.Join(sets, ", "))
+		suffix = onConflict + suffix
+	}
+
+	qb := squirrel.Insert(repo.table()).
 		Columns(columns...).
 		Values(values...).
-		Suffix("RETURNING \"{{.Identity.Name}}\"").
+		Suffix(suffix).
 		PlaceholderFormat(squirrel.Dollar).
 		RunWith(runner)
 	if repo.debug && repo.logger != nil {
@@ -153,25 +454,44 @@ func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunne
 		repo.logger.Printf("method: Create, stmt: %q, args: %v, error: %v", sql, args, err)
 	}
 
-	var {{.Identity.Identifier}} {{rawType .Identity.TypeInfo.V}}
-	err := qb.QueryRowContext(ctx).Scan(&{{.Identity.Identifier}})
-	if err != nil {
-		return {{zeroValue .Identity.TypeInfo.V}}, err
-	}
+	{{if .HasCompositeKey -}}
+		var key Key
+		err := qb.QueryRowContext(ctx).Scan(
+			{{range $field := .Identities -}}
+				&key.{{$field.StructField}},
+			{{end -}}
+		)
+		if err != nil {
+			return Key{}, err
+		}
+
+		return key, nil
+	{{else -}}
+		var {{.Identity.Identifier}} {{rawType .Identity.TypeInfo.V}}
+		err := qb.QueryRowContext(ctx).Scan(&{{.Identity.Identifier}})
+		if err != nil {
+			return {{$keyZero}}, err
+		}
 
-	return {{.Identity.Identifier}}, nil
+		return {{.Identity.Identifier}}, nil
+	{{end -}}
 }
 
 // CreateMany batch creates {{.TypeNamePlural}}
 func (repo *PostgresRepository) CreateMany(ctx context.Context, cs ...*Creator) error {
-	return repo.createMany(ctx, repo.db, cs...)
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return err
+	}
+
+	return repo.createMany(ctx, runner, cs...)
 }
 
 // CreateManyTx batch creates {{.TypeNamePlural}} in a transaction
 func (repo *PostgresRepository) CreateManyTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
-	txx, ok := tx.(*sql.Tx)
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return errors.New("expecting tx to be *sql.Tx")
+		return errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.createMany(ctx, txx, cs...)
@@ -190,16 +510,144 @@ func (repo *PostgresRepository) createMany(ctx context.Context, runner nero.SQLR
 		{{end -}}
 	}
 
-	qb := squirrel.Insert("\"{{.Collection}}\"").Columns(columns...)
+	// the creators are inserted in chunks so the statements don't exceed the bind parameter limit
+	chunks := chunkCreators(cs, nero.PostgresMaxParams/len(columns))
+	if _, ok := runner.(nero.TxRunner); !ok && len(chunks) > 1 {
+		// the chunks are inserted in a transaction so it's all or nothing
+		return repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+			return repo.CreateManyTx(ctx, tx, cs...)
+		})
+	}
+
+	for _, chunk := range chunks {
+		qb := squirrel.Insert(repo.table()).Columns(columns...)
+		for _, c := range chunk {
+			{{if .TenantField -}}
+				if err := repo.setTenant(ctx, c); err != nil {
+					return err
+				}
+
+			{{end -}}
+			if err := c.generate(); err != nil {
+				return err
+			}
+
+			if err := c.Validate(); err != nil {
+				return err
+			}
+
+			qb = qb.Values(
+				{{range $field := $fields -}}
+					{{if ne $field.IsAuto true -}}
+						{{if $field.IsJSON -}}
+							nero.JSON(c.{{$field.Identifier}}),
+						{{else if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
+							pq.Array(c.{{$field.Identifier}}),
+						{{else -}}
+							c.{{$field.Identifier}},
+						{{end -}}
+					{{end -}}
+				{{end -}}
+			)
+		}
+
+		qb = qb.Suffix("RETURNING {{range $i, $field := .Identities}}{{if $i}}, {{end}}\"{{$field.Name}}\"{{end}}").
+			PlaceholderFormat(squirrel.Dollar)
+		if repo.debug && repo.logger != nil {
+			sql, args, err := qb.ToSql()
+			repo.logger.Printf("method: CreateMany, stmt: %q, args: %v, error: %v", sql, args, err)
+		}
+
+		_, err := qb.RunWith(runner).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BulkInsert inserts {{.TypeNamePlural}} with COPY FROM STDIN, which is faster than CreateMany
+// for a large number of {{.TypeNamePlural}}. It runs in a transaction, which is the one that's
+// carried by the context in the tx-from-context mode.
+func (repo *PostgresRepository) BulkInsert(ctx context.Context, cs ...*Creator) error {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return err
+	}
+
+	if txx, ok := runner.(nero.TxRunner); ok {
+		return repo.bulkInsert(ctx, txx, cs...)
+	}
+
+	return repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+		return repo.BulkInsertTx(ctx, tx, cs...)
+	})
+}
+
+// BulkInsertTx inserts {{.TypeNamePlural}} with COPY FROM STDIN in a transaction
+func (repo *PostgresRepository) BulkInsertTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.bulkInsert(ctx, txx, cs...)
+}
+
+func (repo *PostgresRepository) bulkInsert(ctx context.Context, tx nero.TxRunner, cs ...*Creator) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	columns := []string{
+		{{range $field := $fields -}}
+			{{if ne $field.IsAuto true -}}
+				"{{$field.Name}}",
+			{{end -}}
+		{{end -}}
+	}
+
+	namespace := "{{.Namespace}}"
+	if repo.schema != "" {
+		namespace = repo.schema
+	}
+
+	query := pq.CopyIn("{{.Collection}}", columns...)
+	if namespace != "" {
+		query = pq.CopyInSchema(namespace, "{{.Collection}}", columns...)
+	}
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: BulkInsert, stmt: %q, rows: %d", query, len(cs))
+	}
+
+	stmt, err := tx.Unwrap().PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
 	for _, c := range cs {
+		{{if .TenantField -}}
+			if err := repo.setTenant(ctx, c); err != nil {
+				return err
+			}
+
+		{{end -}}
+		if err := c.generate(); err != nil {
+			return err
+		}
+
 		if err := c.Validate(); err != nil {
 			return err
 		}
 
-		qb = qb.Values(
+		_, err = stmt.ExecContext(ctx,
 			{{range $field := $fields -}}
 				{{if ne $field.IsAuto true -}}
-					{{if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
+					{{if $field.IsJSON -}}
+						nero.JSON(c.{{$field.Identifier}}),
+					{{else if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
 						pq.Array(c.{{$field.Identifier}}),
 					{{else -}}
 						c.{{$field.Identifier}},
@@ -207,43 +655,780 @@ func (repo *PostgresRepository) createMany(ctx context.Context, runner nero.SQLR
 				{{end -}}
 			{{end -}}
 		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// flush the buffered rows
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+// Query queries {{.TypeNamePlural}}
+func (repo *PostgresRepository) Query(ctx context.Context, q *Queryer) ([]{{rawType .TypeInfo.V}}, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.query(ctx, runner, q)
+}
+
+// QueryTx queries {{.TypeNamePlural}} in a transaction
+func (repo *PostgresRepository) QueryTx(ctx context.Context, tx nero.Tx, q *Queryer) ([]{{rawType .TypeInfo.V}}, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.query(ctx, txx, q)
+}
+
+func (repo *PostgresRepository) query(ctx context.Context, runner nero.SQLRunner, q *Queryer) ([]{{rawType .TypeInfo.V}}, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo.debug  && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Query, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	{{if .Edges -}}
+		{{.TypeIdentifierPlural}}, err := repo.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		err = repo.loadEdges(ctx, runner, q, {{.TypeIdentifierPlural}}...)
+		if err != nil {
+			return nil, err
+		}
+
+		return {{.TypeIdentifierPlural}}, nil
+	{{else -}}
+		return repo.scan(rows)
+	{{end -}}
+}
+
+// QueryOne queries a {{.TypeName}}
+func (repo *PostgresRepository) QueryOne(ctx context.Context, q *Queryer) ({{rawType .TypeInfo.V}}, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.queryOne(ctx, runner, q)
+}
+
+// QueryOneTx queries a {{.TypeName}} in a transaction
+func (repo *PostgresRepository) QueryOneTx(ctx context.Context, tx nero.Tx, q *Queryer) ({{rawType .TypeInfo.V}}, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.queryOne(ctx, txx, q)
+}
+
+func (repo *PostgresRepository) queryOne(ctx context.Context, runner nero.SQLRunner, q *Queryer) ({{rawType .TypeInfo.V}}, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return {{zeroValue .TypeInfo.V}}, err
+	}
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: QueryOne, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var {{.TypeIdentifier}} {{type .TypeInfo.V}}
+	err = qb.RunWith(runner).
+		QueryRowContext(ctx).
+		Scan(
+			{{range $field := $fields -}}
+				{{if $field.IsJSON -}}
+					nero.JSON(&{{$.TypeIdentifier}}.{{$field.StructField}}),
+				{{else if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
+					pq.Array(&{{$.TypeIdentifier}}.{{$field.StructField}}),
+				{{else -}}
+					&{{$.TypeIdentifier}}.{{$field.StructField}},
+				{{end -}}	
+			{{end -}}
+		)
+	if err != nil {
+		return {{zeroValue .TypeInfo.V}}, err
+	}
+
+	{{if .Edges -}}
+		err = repo.loadEdges(ctx, runner, q, &{{.TypeIdentifier}})
+		if err != nil {
+			return {{zeroValue .TypeInfo.V}}, err
+		}
+
+	{{end -}}
+	return &{{.TypeIdentifier}}, nil
+}
+
+// Exists checks if there's any {{.TypeName}} that matches the queryer
+func (repo *PostgresRepository) Exists(ctx context.Context, q *Queryer) (bool, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return false, err
+	}
+
+	return repo.exists(ctx, runner, q)
+}
+
+// ExistsTx checks if there's any {{.TypeName}} that matches the queryer in a transaction
+func (repo *PostgresRepository) ExistsTx(ctx context.Context, tx nero.Tx, q *Queryer) (bool, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return false, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.exists(ctx, txx, q)
+}
+
+func (repo *PostgresRepository) exists(ctx context.Context, runner nero.SQLRunner, q *Queryer) (bool, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return false, err
+	}
+
+	eb := squirrel.Select().
+		Column(squirrel.Expr("EXISTS (?)", qb.PlaceholderFormat(squirrel.Question))).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := eb.ToSql()
+		repo.logger.Printf("method: Exists, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var exists bool
+	err = eb.RunWith(runner).QueryRowContext(ctx).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Count counts the {{.TypeNamePlural}} that match the queryer
+func (repo *PostgresRepository) Count(ctx context.Context, q *Queryer) (int64, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.count(ctx, runner, q)
+}
+
+// CountTx counts the {{.TypeNamePlural}} that match the queryer in a transaction
+func (repo *PostgresRepository) CountTx(ctx context.Context, tx nero.Tx, q *Queryer) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.count(ctx, txx, q)
+}
+
+// count counts the rows of the select so the limit and
+// the offset of the queryer are taken into account
+func (repo *PostgresRepository) count(ctx context.Context, runner nero.SQLRunner, q *Queryer) (int64, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+
+	eb := squirrel.Select("COUNT(*)").
+		FromSelect(qb.PlaceholderFormat(squirrel.Question), "q").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := eb.ToSql()
+		repo.logger.Printf("method: Count, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var count int64
+	err = eb.RunWith(runner).QueryRowContext(ctx).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo *PostgresRepository) buildSelect(ctx context.Context, q *Queryer) (squirrel.SelectBuilder, error) {
+	qb := squirrel.Select(repo.columns()...).
+		From(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if len(q.distinctOn) > 0 {
+		on := []string{}
+		for _, field := range q.distinctOn {
+			on = append(on, fmt.Sprintf("%q", field.String()))
+		}
+		qb = qb.Options("DISTINCT ON (" + strings.Join(on, ", ") + ")")
+	} else if q.distinct {
+		qb = qb.Distinct()
+	}
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range q.predFuncs {
+		preds = predFunc(preds)
+	}
+	{{if or .TenantField .Edges -}}
+		preds, err := repo.scope(ctx, preds)
+		if err != nil {
+			return squirrel.SelectBuilder{}, err
+		}
+	{{end -}}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	sorts := []*sort.Sort{}
+	for _, sortFunc := range q.sortFuncs {
+		sorts = sortFunc(sorts)
+	}
+	qb = repo.buildSort(qb, sorts)
+
+	if q.limit > 0 {
+		qb = qb.Limit(uint64(q.limit))
+	}
+
+	if q.offset > 0 {
+		qb = qb.Offset(uint64(q.offset))
+	}
+
+	if q.forUpdate || q.forShare {
+		lock := "FOR UPDATE"
+		if q.forShare {
+			lock = "FOR SHARE"
+		}
+
+		if q.skipLocked {
+			lock += " SKIP LOCKED"
+		} else if q.noWait {
+			lock += " NOWAIT"
+		}
+
+		qb = qb.Suffix(lock)
+	}
+
+	return qb, nil
+}
+
+func (repo *PostgresRepository) columns() []string {
+	return []string{
+		{{range $field := $fields -}}
+			"\"{{$field.Name}}\"",
+		{{end -}}
+	}
+}
+
+func (repo *PostgresRepository) scan(rows *sql.Rows) ([]{{rawType .TypeInfo.V}}, error) {
+	{{.TypeIdentifierPlural}} := []{{rawType .TypeInfo.V}}{}
+	for rows.Next() {
+		var {{.TypeIdentifier}} {{type .TypeInfo.V}}
+		err := rows.Scan(
+			{{range $field := $fields -}}
+				{{if $field.IsJSON -}}
+					nero.JSON(&{{$.TypeIdentifier}}.{{$field.StructField}}),
+				{{else if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
+					pq.Array(&{{$.TypeIdentifier}}.{{$field.StructField}}),
+				{{else -}}
+					&{{$.TypeIdentifier}}.{{$field.StructField}},
+				{{end -}}
+			{{end -}}
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		{{.TypeIdentifierPlural}} = append({{.TypeIdentifierPlural}}, &{{.TypeIdentifier}})
+	}
+
+	return {{.TypeIdentifierPlural}}, rows.Err()
+}
+
+func (repo *PostgresRepository) buildPreds(sb squirrel.StatementBuilderType, table string, preds []*comparison.Predicate) squirrel.StatementBuilderType {
+	column := func(field string) string {
+		if table == "" {
+			return fmt.Sprintf("%q", field)
+		}
+		return fmt.Sprintf("%q.%q", table, field)
+	}
+
+	for _, pred := range preds {
+		ph := "?"
+		fieldX, arg := column(pred.Field), pred.Arg
+
+		args := []interface{}{}
+		if fieldY, ok := arg.(comparison.FieldArg); ok { // a field
+			ph = column(fieldY.Column())
+		} else if vals, ok := arg.([]interface{}); ok  {  // array of values 
+			args = append(args, vals...)
+		} else { // single value
+			args = append(args, pgArray(arg))
+		}
+		
+		switch pred.Op {
+		case comparison.Eq:
+			sb = sb.Where(fmt.Sprintf("%s = "+ph, fieldX), args...)
+		case comparison.NotEq:
+			sb = sb.Where(fmt.Sprintf("%s <> "+ph, fieldX), args...)
+		case comparison.Gt:
+			sb = sb.Where(fmt.Sprintf("%s > "+ph, fieldX), args...)
+		case comparison.GtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s >= "+ph, fieldX), args...)
+		case comparison.Lt:
+			sb = sb.Where(fmt.Sprintf("%s < "+ph, fieldX), args...)
+		case comparison.LtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s <= "+ph, fieldX), args...)
+		case comparison.IsNull, comparison.IsNotNull:
+			fmtStr := "%s IS NULL"
+			if pred.Op == comparison.IsNotNull {
+				fmtStr = "%s IS NOT NULL"
+			}
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX))
+		case comparison.In, comparison.NotIn:
+			fmtStr := "%s IN (%s)"
+			if pred.Op == comparison.NotIn {
+				fmtStr = "%s NOT IN (%s)"
+			}
+
+			phs := []string{}
+			for range args {
+				phs = append(phs, "?")
+			}
+
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX, strings.Join(phs, ",")), args...)
+		{{if .Edges -}}
+		case comparison.Exists:
+			edge, _ := arg.(*comparison.Edge)
+			sb = sb.Where(squirrel.Expr("EXISTS (?)", repo.buildExists(table, edge)))
+		{{end -}}
+		case comparison.PathEq:
+			// the predicates of Has<Edge>With may come from the target schema
+			pv, _ := arg.(*comparison.PathValue)
+			sb = sb.Where(fmt.Sprintf("%s #>> ? = ?", fieldX), pq.Array(strings.Split(pv.Path, ".")), pv.Value)
+		case comparison.Contains:
+			sb = sb.Where(fmt.Sprintf("%s @> ?", fieldX), args...)
+		case comparison.ContainedBy:
+			sb = sb.Where(fmt.Sprintf("%s <@ ?", fieldX), args...)
+		case comparison.Overlap:
+			sb = sb.Where(fmt.Sprintf("%s && ?", fieldX), args...)
+		case comparison.AnyEq:
+			sb = sb.Where(fmt.Sprintf("? = ANY(%s)", fieldX), args...)
+		}
+	}
+
+	return sb
+}
+
+func (repo *PostgresRepository) buildSort(qb squirrel.SelectBuilder, sorts []*sort.Sort) squirrel.SelectBuilder {
+	for _, s := range sorts {
+		field := fmt.Sprintf("%q", s.Field)
+		switch s.Direction {
+		case sort.Asc:
+			qb = qb.OrderBy(field + " ASC")
+		case sort.Desc:
+			qb = qb.OrderBy(field + " DESC")
+		}
+	}
+
+	return qb
+}
+
+{{if .Edges -}}
+// buildExists builds the subquery of the Has<Edge>With predicates. The target
+// table is aliased with the edge name, prefixed with the alias of the outer
+// table when the predicate is nested, so the columns are never ambiguous.
+func (repo *PostgresRepository) buildExists(outer string, edge *comparison.Edge) squirrel.SelectBuilder {
+	alias := edge.Name
+	if outer == "" {
+		outer = "{{.Collection}}"
+	} else {
+		alias = outer + "_" + edge.Name
+	}
+
+	qb := squirrel.Select("1").
+		From(repo.qualify(edge.Namespace, edge.Collection) + fmt.Sprintf(" AS %q", alias))
+	if edge.Through == "" {
+		qb = qb.Where(fmt.Sprintf("%q.%q = %q.%q", alias, edge.Column, outer, edge.Ref))
+	} else {
+		through := alias + "_" + edge.Through
+		qb = qb.Join(repo.qualify(edge.ThroughNamespace, edge.Through)+
+			fmt.Sprintf(" AS %q ON %q.%q = %q.%q", through, through, edge.ThroughTo, alias, edge.Column)).
+			Where(fmt.Sprintf("%q.%q = %q.%q", through, edge.ThroughFrom, outer, edge.Ref))
+	}
+
+	return squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), alias, edge.Preds))
+}
+
+// loadEdges eager loads the edges that are requested by the queryer
+func (repo *PostgresRepository) loadEdges(ctx context.Context, runner nero.SQLRunner, q *Queryer, {{.TypeIdentifierPlural}} ...{{rawType .TypeInfo.V}}) error {
+	if len({{.TypeIdentifierPlural}}) == 0 {
+		return nil
+	}
+
+	{{range $edge := .Edges -}}
+		if q.with{{$edge.StructField}} {
+			if err := repo.load{{$edge.StructField}}(ctx, runner, {{$.TypeIdentifierPlural}}); err != nil {
+				return errors.Wrap(err, "load {{$edge.Name}}")
+			}
+		}
+
+	{{end -}}
+	return nil
+}
+{{end -}}
+
+{{range $edge := .Edges -}}
+{{$target := $edge.Schema -}}
+{{$targetFields := $target.AllFields -}}
+// load{{$edge.StructField}} loads the {{$edge.Name}} edge of the {{$.TypeNamePlural}} with a single query
+func (repo *PostgresRepository) load{{$edge.StructField}}(ctx context.Context, runner nero.SQLRunner, {{$.TypeIdentifierPlural}} []{{rawType $.TypeInfo.V}}) error {
+	columns := []string{
+		{{if $edge.IsManyToMany -}}
+			"\"{{$edge.Through}}\".\"{{$edge.ThroughFrom}}\"",
+		{{end -}}
+		{{range $field := $targetFields -}}
+			"\"{{$target.Collection}}\".\"{{$field.Name}}\"",
+		{{end -}}
+	}
+
+	{{if $edge.IsManyToOne -}}
+		{{$fk := $.Field $edge.ForeignKey -}}
+		keys := []interface{}{}
+		seen := map[{{type $fk.TypeInfo.V}}]bool{}
+		for _, {{$.TypeIdentifier}} := range {{$.TypeIdentifierPlural}} {
+			{{if $fk.IsNillable -}}
+				if {{$.TypeIdentifier}}.{{$fk.StructField}} == nil {
+					continue
+				}
+				key := *{{$.TypeIdentifier}}.{{$fk.StructField}}
+			{{else -}}
+				key := {{$.TypeIdentifier}}.{{$fk.StructField}}
+			{{end}}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+
+		if len(keys) == 0 {
+			return nil
+		}
+
+		qb := squirrel.Select(columns...).
+			From(repo.qualify("{{$target.Namespace}}", "{{$target.Collection}}")).
+			Where(squirrel.Eq{"\"{{$target.Collection}}\".\"{{$target.Identity.Name}}\"": keys}).
+				PlaceholderFormat(squirrel.Dollar)
+	{{else -}}
+		keys := []interface{}{}
+		for _, {{$.TypeIdentifier}} := range {{$.TypeIdentifierPlural}} {
+			keys = append(keys, {{$.TypeIdentifier}}.{{$.Identity.StructField}})
+		}
+
+		{{if $edge.IsOneToMany -}}
+			qb := squirrel.Select(columns...).
+				From(repo.qualify("{{$target.Namespace}}", "{{$target.Collection}}")).
+				Where(squirrel.Eq{"\"{{$target.Collection}}\".\"{{$edge.ForeignKey}}\"": keys}).
+				PlaceholderFormat(squirrel.Dollar)
+		{{else -}}
+			qb := squirrel.Select(columns...).
+				From(repo.qualify("{{$target.Namespace}}", "{{$target.Collection}}")).
+				Join(repo.qualify("{{$.Namespace}}", "{{$edge.Through}}") + " ON \"{{$edge.Through}}\".\"{{$edge.ThroughTo}}\" = \"{{$target.Collection}}\".\"{{$target.Identity.Name}}\"").
+				Where(squirrel.Eq{"\"{{$edge.Through}}\".\"{{$edge.ThroughFrom}}\"": keys}).
+				PlaceholderFormat(squirrel.Dollar)
+		{{end -}}
+	{{end -}}
+	{{if $target.TenantField}}
+		// the rows of the other tenants are never loaded
+		tenantID, err := repo.edgeTenant(ctx)
+		if err != nil {
+			return err
+		}
+		qb = qb.Where("\"{{$target.Collection}}\".\"{{$target.TenantField.Name}}\" = ?", tenantID)
+
+	{{end -}}
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Query, edge: {{$edge.Name}}, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	{{if $edge.IsManyToOne -}}
+		related := map[{{type $target.Identity.TypeInfo.V}}]{{rawType $target.TypeInfo.V}}{}
+	{{else -}}
+		related := map[{{type $.Identity.TypeInfo.V}}][]{{rawType $target.TypeInfo.V}}{}
+	{{end -}}
+	for rows.Next() {
+		{{if $edge.IsManyToMany -}}
+			var key {{type $.Identity.TypeInfo.V}}
+		{{end -}}
+		var item {{type $target.TypeInfo.V}}
+		err = rows.Scan(
+			{{if $edge.IsManyToMany -}}
+				&key,
+			{{end -}}
+			{{range $field := $targetFields -}}
+					{{if $field.IsJSON -}}
+						nero.JSON(&item.{{$field.StructField}}),
+					{{else if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
+						pq.Array(&item.{{$field.StructField}}),
+					{{else -}}
+						&item.{{$field.StructField}},
+					{{end -}}
+			{{end -}}
+		)
+		if err != nil {
+			return err
+		}
+
+		{{if $edge.IsManyToOne -}}
+			related[item.{{$target.Identity.StructField}}] = &item
+		{{else if $edge.IsOneToMany -}}
+			{{$fk := $target.Field $edge.ForeignKey -}}
+			{{if $fk.IsNillable -}}
+				if item.{{$fk.StructField}} == nil {
+					continue
+				}
+				key := *item.{{$fk.StructField}}
+			{{else -}}
+				key := item.{{$fk.StructField}}
+			{{end -}}
+			related[key] = append(related[key], &item)
+		{{else -}}
+			related[key] = append(related[key], &item)
+		{{end -}}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, {{$.TypeIdentifier}} := range {{$.TypeIdentifierPlural}} {
+		{{if $edge.IsManyToOne -}}
+			{{$fk := $.Field $edge.ForeignKey -}}
+			{{if $fk.IsNillable -}}
+				if {{$.TypeIdentifier}}.{{$fk.StructField}} != nil {
+					{{$.TypeIdentifier}}.{{$edge.StructField}} = related[*{{$.TypeIdentifier}}.{{$fk.StructField}}]
+				}
+			{{else -}}
+				{{$.TypeIdentifier}}.{{$edge.StructField}} = related[{{$.TypeIdentifier}}.{{$fk.StructField}}]
+			{{end -}}
+		{{else -}}
+			{{$.TypeIdentifier}}.{{$edge.StructField}} = related[{{$.TypeIdentifier}}.{{$.Identity.StructField}}]
+		{{end -}}
+	}
+
+	return nil
+}
+
+{{end -}}
+
+{{range $index := .Indexes -}}
+	// QueryBy{{$index.StructField}} queries a {{$.TypeName}} by the unique {{$index.StructField}} index
+	func (repo *PostgresRepository) QueryBy{{$index.StructField}}(ctx context.Context, {{range $field := $index.Fields}}{{$field.Identifier}} {{rawType $field.TypeInfo.V}}, {{end}}) ({{rawType $.TypeInfo.V}}, error) {
+		return repo.QueryOne(ctx, NewQueryer().Where(
+			{{range $field := $index.Fields -}}
+				{{$field.StructField}}Eq({{$field.Identifier}}),
+			{{end -}}
+		))
+	}
+
+	// QueryBy{{$index.StructField}}Tx queries a {{$.TypeName}} by the unique {{$index.StructField}} index in a transaction
+	func (repo *PostgresRepository) QueryBy{{$index.StructField}}Tx(ctx context.Context, tx nero.Tx, {{range $field := $index.Fields}}{{$field.Identifier}} {{rawType $field.TypeInfo.V}}, {{end}}) ({{rawType $.TypeInfo.V}}, error) {
+		return repo.QueryOneTx(ctx, tx, NewQueryer().Where(
+			{{range $field := $index.Fields -}}
+				{{$field.StructField}}Eq({{$field.Identifier}}),
+			{{end -}}
+		))
+	}
+
+	// UpsertBy{{$index.StructField}} creates a {{$.TypeName}} or updates the one with the same {{$index.StructField}}
+	func (repo *PostgresRepository) UpsertBy{{$index.StructField}}(ctx context.Context, c *Creator) ({{$keyType}}, error) {
+		runner, err := repo.runner(ctx)
+		if err != nil {
+			return {{$keyZero}}, err
+		}
+
+		return repo.create(ctx, runner, c, {{range $field := $index.Fields}}"\"{{$field.Name}}\"", {{end}})
+	}
+
+	// UpsertBy{{$index.StructField}}Tx creates a {{$.TypeName}} or updates the one with the same {{$index.StructField}} in a transaction
+	func (repo *PostgresRepository) UpsertBy{{$index.StructField}}Tx(ctx context.Context, tx nero.Tx, c *Creator) ({{$keyType}}, error) {
+		txx, ok := tx.(nero.TxRunner)
+		if !ok {
+			return {{$keyZero}}, errors.New("expecting tx to implement nero.TxRunner")
+		}
+
+		return repo.create(ctx, txx, c, {{range $field := $index.Fields}}"\"{{$field.Name}}\"", {{end}})
+	}
+
+{{end -}}
+
+// Update updates a {{.TypeName}} or many {{.TypeNamePlural}}
+func (repo *PostgresRepository) Update(ctx context.Context, u *Updater) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.update(ctx, runner, u)
+}
+
+// UpdateTx updates a {{.TypeName}} many {{.TypeNamePlural}} in a transaction
+func (repo *PostgresRepository) UpdateTx(ctx context.Context, tx nero.Tx, u *Updater) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.update(ctx, txx, u)
+}
+
+func (repo *PostgresRepository) buildUpdate(ctx context.Context, u *Updater) (squirrel.UpdateBuilder, bool, error) {
+	qb := squirrel.Update(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	cnt := 0
+	{{range $field := .Fields }}
+		{{if and (ne $field.IsAuto true) (ne $field.IsTenant true)}}
+			if u.fields.has(Field{{$field.StructField}}) {
+				{{if $field.IsJSON -}}
+					qb = qb.Set("\"{{$field.Name}}\"", nero.JSON(u.{{$field.Identifier}}))
+				{{else if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
+					qb = qb.Set("\"{{$field.Name}}\"", pq.Array(u.{{$field.Identifier}}))
+				{{else -}}
+					qb = qb.Set("\"{{$field.Name}}\"", u.{{$field.Identifier}})
+				{{end -}}
+				cnt++
+			}
+		{{end}}
+	{{end}}
+
+	for _, e := range u.exprs {
+		{{if .TenantField -}}
+			if e.field == Field{{.TenantField.StructField}} {
+				return qb, false, errors.New("the tenant field can't be updated")
+			}
+
+		{{end -}}
+		col := fmt.Sprintf("%q", e.field)
+		if e.delta != nil {
+			qb = qb.Set(col, squirrel.Expr(col+" + ?", e.delta))
+		} else {
+			qb = qb.Set(col, squirrel.Expr(e.expr, e.args...))
+		}
+		cnt++
+	}
+
+	if cnt == 0 {
+		return qb, false, nil
+	}
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range u.predFuncs {
+		preds = predFunc(preds)
+	}
+	{{if or .TenantField .Edges -}}
+		preds, err := repo.scope(ctx, preds)
+		if err != nil {
+			return qb, false, err
+		}
+	{{end -}}
+	qb = squirrel.UpdateBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, true, nil
+}
+
+func (repo *PostgresRepository) update(ctx context.Context, runner nero.SQLRunner, u *Updater) (int64, error) {
+	if err := u.Validate(); err != nil {
+		return 0, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, nil
 	}
 
-	qb = qb.Suffix("RETURNING \"{{.Identity.Name}}\"").
-		PlaceholderFormat(squirrel.Dollar)
 	if repo.debug && repo.logger != nil {
 		sql, args, err := qb.ToSql()
-		repo.logger.Printf("method: CreateMany, stmt: %q, args: %v, error: %v", sql, args, err)
+		repo.logger.Printf("method: Update, stmt: %q, args: %v, error: %v", sql, args, err)
 	}
 
-	_, err := qb.RunWith(runner).ExecContext(ctx)
+	res, err := qb.RunWith(runner).ExecContext(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
 }
 
-// Query queries {{.TypeNamePlural}}
-func (repo *PostgresRepository) Query(ctx context.Context, q *Queryer) ([]{{rawType .TypeInfo.V}}, error) {
-	return repo.query(ctx, repo.db, q)
+// UpdateReturning updates a {{.TypeName}} or many {{.TypeNamePlural}} and returns the updated {{.TypeNamePlural}}
+func (repo *PostgresRepository) UpdateReturning(ctx context.Context, u *Updater) ([]{{rawType .TypeInfo.V}}, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.updateReturning(ctx, runner, u)
 }
 
-// QueryTx queries {{.TypeNamePlural}} in a transaction
-func (repo *PostgresRepository) QueryTx(ctx context.Context, tx nero.Tx, q *Queryer) ([]{{rawType .TypeInfo.V}}, error) {
-	txx, ok := tx.(*sql.Tx)
+// UpdateReturningTx updates a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction and returns the updated {{.TypeNamePlural}}
+func (repo *PostgresRepository) UpdateReturningTx(ctx context.Context, tx nero.Tx, u *Updater) ([]{{rawType .TypeInfo.V}}, error) {
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return nil, errors.New("expecting tx to be *sql.Tx")
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
 	}
 
-	return repo.query(ctx, txx, q)
+	return repo.updateReturning(ctx, txx, u)
 }
 
-func (repo *PostgresRepository) query(ctx context.Context, runner nero.SQLRunner, q *Queryer) ([]{{rawType .TypeInfo.V}}, error) {
-	qb := repo.buildSelect(q)	
-	if repo.debug  && repo.logger != nil {
+func (repo *PostgresRepository) updateReturning(ctx context.Context, runner nero.SQLRunner, u *Updater) ([]{{rawType .TypeInfo.V}}, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, nil
+	}
+	qb = qb.Suffix("RETURNING " + strings.Join(repo.columns(), ","))
+
+	if repo.debug && repo.logger != nil {
 		sql, args, err := qb.ToSql()
-		repo.logger.Printf("method: Query, stmt: %q, args: %v, error: %v", sql, args, err)
+		repo.logger.Printf("method: UpdateReturning, stmt: %q, args: %v, error: %v", sql, args, err)
 	}
 
 	rows, err := qb.RunWith(runner).QueryContext(ctx)
@@ -252,213 +1437,210 @@ func (repo *PostgresRepository) query(ctx context.Context, runner nero.SQLRunner
 	}
 	defer rows.Close()
 
-	{{.TypeIdentifierPlural}} := []{{rawType .TypeInfo.V}}{}
-	for rows.Next() {
-		var {{.TypeIdentifier}} {{type .TypeInfo.V}}
-		err = rows.Scan(
-			{{range $field := $fields -}}
-				{{if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
-					pq.Array(&{{$.TypeIdentifier}}.{{$field.StructField}}),
-				{{else -}}
-					&{{$.TypeIdentifier}}.{{$field.StructField}},
-				{{end -}}
-			{{end -}}
-		)
-		if err != nil {
-			return nil, err
-		}
+	return repo.scan(rows)
+}
 
-		{{.TypeIdentifierPlural}} = append({{.TypeIdentifierPlural}}, &{{.TypeIdentifier}})
+// UpdateMany updates many {{.TypeNamePlural}} with different values in a single statement
+func (repo *PostgresRepository) UpdateMany(ctx context.Context, rus []*RowUpdate) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
 	}
 
-	return {{.TypeIdentifierPlural}}, nil
-}
-
-// QueryOne queries a {{.TypeName}}
-func (repo *PostgresRepository) QueryOne(ctx context.Context, q *Queryer) ({{rawType .TypeInfo.V}}, error) {
-	return repo.queryOne(ctx, repo.db, q)
+	return repo.updateMany(ctx, runner, rus)
 }
 
-// QueryOneTx queries a {{.TypeName}} in a transaction
-func (repo *PostgresRepository) QueryOneTx(ctx context.Context, tx nero.Tx, q *Queryer) ({{rawType .TypeInfo.V}}, error) {
-	txx, ok := tx.(*sql.Tx)
+// UpdateManyTx updates many {{.TypeNamePlural}} with different values in a single statement in a transaction
+func (repo *PostgresRepository) UpdateManyTx(ctx context.Context, tx nero.Tx, rus []*RowUpdate) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return nil, errors.New("expecting tx to be *sql.Tx")
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
 	}
 
-	return repo.queryOne(ctx, txx, q)
+	return repo.updateMany(ctx, txx, rus)
 }
 
-func (repo *PostgresRepository) queryOne(ctx context.Context, runner nero.SQLRunner, q *Queryer) ({{rawType .TypeInfo.V}}, error) {
-	qb := repo.buildSelect(q)
-	if repo.debug && repo.logger != nil {
-		sql, args, err := qb.ToSql()
-		repo.logger.Printf("method: QueryOne, stmt: %q, args: %v, error: %v", sql, args, err)
+// updateMany updates the {{.TypeNamePlural}} with an UPDATE ... FROM (VALUES ...) statement. The
+// values are preceded by an empty select of the table so they get the types of the columns,
+// and the columns that aren't set by a row update are flagged so they keep their value.
+func (repo *PostgresRepository) updateMany(ctx context.Context, runner nero.SQLRunner, rus []*RowUpdate) (int64, error) {
+	if len(rus) == 0 {
+		return 0, nil
 	}
 
-	var {{.TypeIdentifier}} {{type .TypeInfo.V}}
-	err := qb.RunWith(runner).
-		QueryRowContext(ctx).
-		Scan(
-			{{range $field := $fields -}}
-				{{if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
-					pq.Array(&{{$.TypeIdentifier}}.{{$field.StructField}}),
-				{{else -}}
-					&{{$.TypeIdentifier}}.{{$field.StructField}},
-				{{end -}}	
-			{{end -}}
-		)
-	if err != nil {
-		return {{zeroValue .TypeInfo.V}}, err
+	for _, ru := range rus {
+		if err := ru.Validate(); err != nil {
+			return 0, err
+		}
 	}
 
-	return &{{.TypeIdentifier}}, nil
-}
-
-func (repo *PostgresRepository) buildSelect(q *Queryer) squirrel.SelectBuilder {
 	columns := []string{
-		{{range $field := $fields -}}
+		{{range $field := .Identities -}}
 			"\"{{$field.Name}}\"",
 		{{end -}}
 	}
-	qb := squirrel.Select(columns...).
-		From("\"{{.Collection}}\"").
-		PlaceholderFormat(squirrel.Dollar)
-
-	preds := []*comparison.Predicate{}
-	for _, predFunc := range q.predFuncs {
-		preds = predFunc(preds)
-	}
-	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), preds))
-
-	sorts := []*sort.Sort{}
-	for _, sortFunc := range q.sortFuncs {
-		sorts = sortFunc(sorts)
+	sets := []string{}
+	rows := [][]interface{}{}
+	for _, ru := range rus {
+		{{if .HasCompositeKey -}}
+			rows = append(rows, []interface{}{
+				{{range $field := .Identities -}}
+					ru.key.{{$field.StructField}},
+				{{end -}}
+			})
+		{{else -}}
+			rows = append(rows, []interface{}{ru.key})
+		{{end -}}
 	}
-	qb = repo.buildSort(qb, sorts)
 
-	if q.limit > 0 {
-		qb = qb.Limit(uint64(q.limit))
-	}
+	{{range $field := .Fields -}}
+		{{if and (ne $field.IsAuto true) (ne $field.IsTenant true) -}}
+			if anyHas(rus, Field{{$field.StructField}}) {
+				columns = append(columns, "\"{{$field.Name}}\"", "false AS \"set_{{$field.Name}}\"")
+				sets = append(sets, "\"{{$field.Name}}\" = CASE WHEN \"v\".\"set_{{$field.Name}}\" "+
+					"THEN \"v\".\"{{$field.Name}}\" ELSE \"{{$.Collection}}\".\"{{$field.Name}}\" END")
+				for i, ru := range rus {
+					{{if $field.IsJSON -}}
+						rows[i] = append(rows[i], nero.JSON(ru.{{$field.Identifier}}), ru.fields.has(Field{{$field.StructField}}))
+					{{else if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
+						rows[i] = append(rows[i], pq.Array(ru.{{$field.Identifier}}), ru.fields.has(Field{{$field.StructField}}))
+					{{else -}}
+						rows[i] = append(rows[i], ru.{{$field.Identifier}}, ru.fields.has(Field{{$field.StructField}}))
+					{{end -}}
+				}
+			}
 
-	if q.offset > 0 {
-		qb = qb.Offset(uint64(q.offset))
+		{{end -}}
+	{{end -}}
+	if len(sets) == 0 {
+		return 0, nil
 	}
 
-	return qb
-}
+	{{if .TenantField -}}
+		tenantID, err := repo.tenant(ctx)
+		if err != nil {
+			return 0, err
+		}
 
-func (repo *PostgresRepository) buildPreds(sb squirrel.StatementBuilderType, preds []*comparison.Predicate) squirrel.StatementBuilderType {
-	for _, pred := range preds {	
-		ph := "?"
-		fieldX, arg := pred.Field, pred.Arg
+	{{end -}}
+	// the rows are updated in chunks so the statements don't exceed the bind parameter limit
+	size := nero.PostgresMaxParams / (len(rows[0]) + 1)
+	if _, ok := runner.(nero.TxRunner); !ok && len(rows) > size {
+		// the chunks are updated in a transaction so it's all or nothing
+		var rowsAffected int64
+		err := repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+			var err error
+			rowsAffected, err = repo.UpdateManyTx(ctx, tx, rus)
+			return err
+		})
+		return rowsAffected, err
+	}
 
-		args := []interface{}{}
-		if fieldY, ok := arg.(Field); ok { // a field
-			ph = fmt.Sprintf("%q", fieldY)
-		} else if vals, ok := arg.([]interface{}); ok  {  // array of values 
-			args = append(args, vals...)
-		} else { // single value
-			args = append(args, arg)
+	var rowsAffected int64
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
 		}
-		
-		switch pred.Op {
-		case comparison.Eq:
-			sb = sb.Where(fmt.Sprintf("%q = "+ph, fieldX), args...)
-		case comparison.NotEq:
-			sb = sb.Where(fmt.Sprintf("%q <> "+ph, fieldX), args...)
-		case comparison.Gt:
-			sb = sb.Where(fmt.Sprintf("%q > "+ph, fieldX), args...)
-		case comparison.GtOrEq:
-			sb = sb.Where(fmt.Sprintf("%q >= "+ph, fieldX), args...)
-		case comparison.Lt:
-			sb = sb.Where(fmt.Sprintf("%q < "+ph, fieldX), args...)
-		case comparison.LtOrEq:
-			sb = sb.Where(fmt.Sprintf("%q <= "+ph, fieldX), args...)
-		case comparison.IsNull, comparison.IsNotNull:
-			fmtStr := "%q IS NULL"
-			if pred.Op == comparison.IsNotNull {
-				fmtStr = "%q IS NOT NULL"
-			}
-			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX))
-		case comparison.In, comparison.NotIn:
-			fmtStr := "%q IN (%s)"
-			if pred.Op == comparison.NotIn {
-				fmtStr = "%q NOT IN (%s)"
-			}
 
+		values := []string{}
+		args := []interface{}{}
+		for _, row := range rows[start:end] {
 			phs := []string{}
-			for range args {
+			for range row {
 				phs = append(phs, "?")
 			}
 
-			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX, strings.Join(phs, ",")), args...)
+			values = append(values, "("+strings.Join(phs, ", ")+")")
+			args = append(args, row...)
 		}
-	}
 
-	return sb
-}
+		where := []string{
+			{{range $field := .Identities -}}
+				"\"{{$.Collection}}\".\"{{$field.Name}}\" = \"v\".\"{{$field.Name}}\"",
+			{{end -}}
+		}
+		{{if .TenantField -}}
+			where = append(where, "\"{{.Collection}}\".\"{{.TenantField.Name}}\" = ?")
+			args = append(args, tenantID)
 
-func (repo *PostgresRepository) buildSort(qb squirrel.SelectBuilder, sorts []*sort.Sort) squirrel.SelectBuilder {
-	for _, s := range sorts {
-		field := fmt.Sprintf("%q", s.Field)
-		switch s.Direction {
-		case sort.Asc:
-			qb = qb.OrderBy(field + " ASC")
-		case sort.Desc:
-			qb = qb.OrderBy(field + " DESC")
+		{{end -}}
+		stmt, err := squirrel.Dollar.ReplacePlaceholders(fmt.Sprintf(
+			"UPDATE %s SET %s FROM (SELECT %s FROM %s WHERE false UNION ALL VALUES %s) AS \"v\" WHERE %s",
+			repo.table(), strings.Join(sets, ", "), strings.Join(columns, ", "), repo.table(),
+			strings.Join(values, ", "), strings.Join(where, " AND ")))
+		if repo.debug && repo.logger != nil {
+			repo.logger.Printf("method: UpdateMany, stmt: %q, args: %v, error: %v", stmt, args, err)
+		}
+
+		if err != nil {
+			return 0, err
 		}
+
+		res, err := runner.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected += n
 	}
 
-	return qb
+	return rowsAffected, nil
 }
 
-// Update updates a {{.TypeName}} or many {{.TypeNamePlural}}
-func (repo *PostgresRepository) Update(ctx context.Context, u *Updater) (int64, error) {
-	return repo.update(ctx, repo.db, u)
+// Delete deletes a {{.TypeName}} or many {{.TypeNamePlural}}
+func (repo *PostgresRepository) Delete(ctx context.Context, d *Deleter) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.delete(ctx, runner, d)
 }
 
-// UpdateTx updates a {{.TypeName}} many {{.TypeNamePlural}} in a transaction
-func (repo *PostgresRepository) UpdateTx(ctx context.Context, tx nero.Tx, u *Updater) (int64, error) {
-	txx, ok := tx.(*sql.Tx)
+// Delete deletes a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction
+func (repo *PostgresRepository) DeleteTx(ctx context.Context, tx nero.Tx, d *Deleter) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return 0, errors.New("expecting tx to be *sql.Tx")
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
 	}
 
-	return repo.update(ctx, txx, u)
+	return repo.delete(ctx, txx, d)
 }
 
-func (repo *PostgresRepository) update(ctx context.Context, runner nero.SQLRunner, u *Updater) (int64, error) {
-	qb := squirrel.Update("\"{{.Collection}}\"").
+func (repo *PostgresRepository) buildDelete(ctx context.Context, d *Deleter) (squirrel.DeleteBuilder, error) {
+	qb := squirrel.Delete(repo.table()).
 		PlaceholderFormat(squirrel.Dollar)
 
-	cnt := 0
-	{{range $field := .Fields }}
-		{{if ne $field.IsAuto true}}
-			if !isZero(u.{{$field.Identifier}}) {
-				{{if and ($field.IsArray) (ne $field.IsValueScanner true) -}}
-					qb = qb.Set("\"{{$field.Name}}\"", pq.Array(u.{{$field.Identifier}}))
-				{{else -}}
-					qb = qb.Set("\"{{$field.Name}}\"", u.{{$field.Identifier}})
-				{{end -}}
-				cnt++
-			}
-		{{end}}
-	{{end}}
-
-	if cnt == 0 {
-		return 0, nil
-	}
-
 	preds := []*comparison.Predicate{}
-	for _, predFunc := range u.predFuncs {
+	for _, predFunc := range d.predFuncs {
 		preds = predFunc(preds)
 	}
-	qb = squirrel.UpdateBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), preds))
+	{{if or .TenantField .Edges -}}
+		preds, err := repo.scope(ctx, preds)
+		if err != nil {
+			return qb, err
+		}
+	{{end -}}
+	qb = squirrel.DeleteBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, nil
+}
+
+func (repo *PostgresRepository) delete(ctx context.Context, runner nero.SQLRunner, d *Deleter) (int64, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return 0, err
+	}
 
 	if repo.debug && repo.logger != nil {
 		sql, args, err := qb.ToSql()
-		repo.logger.Printf("method: Update, stmt: %q, args: %v, error: %v", sql, args, err)
+		repo.logger.Printf("method: Delete, stmt: %q, args: %v, error: %v", sql, args, err)
 	}
 
 	res, err := qb.RunWith(runner).ExecContext(ctx)
@@ -474,59 +1656,62 @@ func (repo *PostgresRepository) update(ctx context.Context, runner nero.SQLRunne
 	return rowsAffected, nil
 }
 
-// Delete deletes a {{.TypeName}} or many {{.TypeNamePlural}}
-func (repo *PostgresRepository) Delete(ctx context.Context, d *Deleter) (int64, error) {
-	return repo.delete(ctx, repo.db, d)
+// DeleteReturning deletes a {{.TypeName}} or many {{.TypeNamePlural}} and returns the deleted {{.TypeNamePlural}}
+func (repo *PostgresRepository) DeleteReturning(ctx context.Context, d *Deleter) ([]{{rawType .TypeInfo.V}}, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.deleteReturning(ctx, runner, d)
 }
 
-// Delete deletes a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction
-func (repo *PostgresRepository) DeleteTx(ctx context.Context, tx nero.Tx, d *Deleter) (int64, error) {
-	txx, ok := tx.(*sql.Tx)
+// DeleteReturningTx deletes a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction and returns the deleted {{.TypeNamePlural}}
+func (repo *PostgresRepository) DeleteReturningTx(ctx context.Context, tx nero.Tx, d *Deleter) ([]{{rawType .TypeInfo.V}}, error) {
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return 0, errors.New("expecting tx to be *sql.Tx")
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
 	}
 
-	return repo.delete(ctx, txx, d)
+	return repo.deleteReturning(ctx, txx, d)
 }
 
-func (repo *PostgresRepository) delete(ctx context.Context, runner nero.SQLRunner, d *Deleter) (int64, error) {
-	qb := squirrel.Delete("\"{{.Collection}}\"").
-		PlaceholderFormat(squirrel.Dollar)
-
-	preds := []*comparison.Predicate{}
-	for _, predFunc := range d.predFuncs {
-		preds = predFunc(preds)
+func (repo *PostgresRepository) deleteReturning(ctx context.Context, runner nero.SQLRunner, d *Deleter) ([]{{rawType .TypeInfo.V}}, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return nil, err
 	}
-	qb = squirrel.DeleteBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), preds))
 
+	qb = qb.Suffix("RETURNING " + strings.Join(repo.columns(), ","))
 	if repo.debug && repo.logger != nil {
 		sql, args, err := qb.ToSql()
-		repo.logger.Printf("method: Delete, stmt: %q, args: %v, error: %v", sql, args, err)
-	}
-
-	res, err := qb.RunWith(runner).ExecContext(ctx)
-	if err != nil {
-		return 0, err
+		repo.logger.Printf("method: DeleteReturning, stmt: %q, args: %v, error: %v", sql, args, err)
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return rowsAffected, nil
+	return repo.scan(rows)
 }
 
 // Aggregate runs an aggregate query
 func (repo *PostgresRepository) Aggregate(ctx context.Context, a *Aggregator) error {
-	return repo.aggregate(ctx, repo.db, a)
+	runner, err := repo.readRunner(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	return repo.aggregate(ctx, runner, a)
 }
 
 // Aggregate runs an aggregate query in a transaction
 func (repo *PostgresRepository) AggregateTx(ctx context.Context, tx nero.Tx, a *Aggregator) error {
-	txx, ok := tx.(*sql.Tx)
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return errors.New("expecting tx to be *sql.Tx")
+		return errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.aggregate(ctx, txx, a)
@@ -557,7 +1742,7 @@ func (repo *PostgresRepository) aggregate(ctx context.Context, runner nero.SQLRu
 		}
 	}
 
-	qb := squirrel.Select(columns...).From("\"{{.Collection}}\"").
+	qb := squirrel.Select(columns...).From(repo.table()).
 		PlaceholderFormat(squirrel.Dollar)
 
 	groupBys := []string{}
@@ -570,7 +1755,13 @@ func (repo *PostgresRepository) aggregate(ctx context.Context, runner nero.SQLRu
 	for _, predFunc := range a.predFuncs {
 		preds = predFunc(preds)
 	}
-	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), preds))
+	{{if or .TenantField .Edges -}}
+		preds, err := repo.scope(ctx, preds)
+		if err != nil {
+			return err
+		}
+	{{end -}}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
 
 	sorts := []*sort.Sort{}
 	for _, sortFunc := range a.sortFuncs {
@@ -612,4 +1803,19 @@ func (repo *PostgresRepository) aggregate(ctx context.Context, runner nero.SQLRu
 
 	return nil
 }
+
+// pgArray wraps the slices with pq.Array so they're sent as arrays,
+// except the []byte and the driver.Valuer values that are sent as is
+func pgArray(v interface{}) interface{} {
+	if _, ok := v.(driver.Valuer); ok {
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		return pq.Array(v)
+	}
+
+	return v
+}
 `
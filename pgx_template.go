@@ -0,0 +1,1743 @@
+package nero
+
+// PgxTemplate is the template for generating a postgres repository that uses pgx
+type PgxTemplate struct {
+	filename string
+}
+
+var _ Template = (*PgxTemplate)(nil)
+
+// NewPgxTemplate returns a new PgxTemplate
+func NewPgxTemplate() *PgxTemplate {
+	return &PgxTemplate{
+		filename: "pgx.go",
+	}
+}
+
+// WithFilename overrides the default filename
+func (t *PgxTemplate) WithFilename(filename string) *PgxTemplate {
+	t.filename = filename
+	return t
+}
+
+// Filename returns the filename
+func (t *PgxTemplate) Filename() string {
+	return t.filename
+}
+
+// Content returns the template content
+func (t *PgxTemplate) Content() string {
+	return pgxTmpl
+}
+
+const pgxTmpl = `
+{{- fileHeaders -}}
+
+package {{.PkgName}}
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"log"
+	"os"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	pgx "github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/aggregate"
+	"github.com/sf9v/nero/comparison"
+	"github.com/sf9v/nero/pgxdb"
+	"github.com/sf9v/nero/sort"
+	{{range $import := .Imports -}}
+		"{{$import}}"
+	{{end -}}
+)
+
+{{ $fields := .AllFields }}
+
+{{- $keyType := rawType .Identity.TypeInfo.V -}}
+{{- $keyZero := zeroValue .Identity.TypeInfo.V -}}
+{{- if .HasCompositeKey -}}
+	{{- $keyType = "Key" -}}
+	{{- $keyZero = "Key{}" -}}
+{{- end }}
+// PgxRepository is a repository that uses PostgreSQL as data store through pgx
+type PgxRepository struct {
+	db  pgxdb.DB
+	logger nero.Logger
+	debug bool
+	txFromContext bool
+	replicas []pgxdb.DB
+	balancer pgxdb.Balancer
+	schema string
+	{{if .TenantField -}}
+		tenantExtractor nero.TenantExtractor
+	{{end -}}
+}
+
+var _ Repository = (*PgxRepository)(nil)
+
+// NewPgxRepository returns a PgxRepository where db can
+// be a *pgxpool.Pool, a *pgx.Conn, a pgx.Tx or any pgxdb.DB
+func NewPgxRepository(db pgxdb.DB) *PgxRepository {
+	return &PgxRepository{db: db}
+}
+
+// Debug enables debug mode
+func (repo *PgxRepository) Debug() *PgxRepository {
+	l := log.New(os.Stdout, "[nero] ", log.LstdFlags | log.Lmicroseconds | log.Lmsgprefix)
+	r := *repo
+	r.debug = true
+	r.logger = l
+	return &r
+}
+
+// WithLogger overrides the default logger
+func (repo *PgxRepository) WithLogger(logger nero.Logger) *PgxRepository {
+	repo.logger = logger
+	return repo
+}
+
+// WithTxFromContext enables the tx-from-context mode where the non-Tx methods
+// run in the transaction carried by the context (see nero.ContextWithTx), if any
+func (repo *PgxRepository) WithTxFromContext() *PgxRepository {
+	repo.txFromContext = true
+	return repo
+}
+
+// WithReplicas sets the read-only replicas. Query, QueryOne and Aggregate are
+// sent to a replica chosen by the balancer (round-robin by default), while the
+// writes and the queries in a transaction are sent to the primary db.
+func (repo *PgxRepository) WithReplicas(dbs ...pgxdb.DB) *PgxRepository {
+	repo.replicas = dbs
+	if repo.balancer == nil {
+		repo.balancer = pgxdb.RoundRobinBalancer()
+	}
+	return repo
+}
+
+// WithBalancer overrides the balancer used for picking the replicas
+func (repo *PgxRepository) WithBalancer(balancer pgxdb.Balancer) *PgxRepository {
+	repo.balancer = balancer
+	return repo
+}
+
+// WithSchema returns a copy of the repository that qualifies the tables
+// with the schema e.g. a per-tenant schema
+func (repo *PgxRepository) WithSchema(schema string) *PgxRepository {
+	r := *repo
+	r.schema = schema
+	return &r
+}
+
+// table returns the quoted name of the {{.Collection}} table
+func (repo *PgxRepository) table() string {
+	return repo.qualify("{{.Namespace}}", "{{.Collection}}")
+}
+
+// identifier returns the identifier of the {{.Collection}} table that's used by CopyFrom
+func (repo *PgxRepository) identifier() pgx.Identifier {
+	namespace := "{{.Namespace}}"
+	if repo.schema != "" {
+		namespace = repo.schema
+	}
+
+	if namespace == "" {
+		return pgx.Identifier{"{{.Collection}}"}
+	}
+
+	return pgx.Identifier{namespace, "{{.Collection}}"}
+}
+
+// qualify quotes the table name and qualifies it with the schema i.e. "schema"."table".
+// The schema that's set by WithSchema takes precedence over the namespace.
+func (repo *PgxRepository) qualify(namespace, table string) string {
+	if repo.schema != "" {
+		namespace = repo.schema
+	}
+
+	if namespace == "" {
+		return nero.QuoteIdent(table)
+	}
+
+	return nero.QuoteIdent(namespace) + "." + nero.QuoteIdent(table)
+}
+
+{{if .TenantField -}}
+{{$tenant := .TenantField -}}
+// WithTenantExtractor overrides the function that extracts the tenant ID
+// from the context, which is nero.TenantFromContext by default
+func (repo *PgxRepository) WithTenantExtractor(extractor nero.TenantExtractor) *PgxRepository {
+	repo.tenantExtractor = extractor
+	return repo
+}
+
+// tenant returns the tenant ID that's carried by the context
+func (repo *PgxRepository) tenant(ctx context.Context) ({{rawType $tenant.TypeInfo.V}}, error) {
+	extract := repo.tenantExtractor
+	if extract == nil {
+		extract = nero.TenantFromContext
+	}
+
+	v, err := extract(ctx)
+	if err != nil {
+		return {{zeroValue $tenant.TypeInfo.V}}, err
+	}
+
+	tenantID, ok := v.({{rawType $tenant.TypeInfo.V}})
+	if !ok {
+		return {{zeroValue $tenant.TypeInfo.V}}, errors.Errorf("expecting tenant ID to be a {{rawType $tenant.TypeInfo.V}}, got %T", v)
+	}
+
+	return tenantID, nil
+}
+
+// setTenant sets the {{$tenant.StructField}} field of the creator to the tenant ID
+func (repo *PgxRepository) setTenant(ctx context.Context, c *Creator) error {
+	tenantID, err := repo.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.{{$tenant.Identifier}} = tenantID
+	return nil
+}
+
+{{end -}}
+{{if .Edges -}}
+// edgeTenant returns the tenant ID that scopes the edges to the tenant-scoped schemas
+func (repo *PgxRepository) edgeTenant(ctx context.Context) (interface{}, error) {
+	{{if .TenantField -}}
+		return repo.tenant(ctx)
+	{{else -}}
+		return nero.TenantFromContext(ctx)
+	{{end -}}
+}
+
+// scopeEdges appends the predicate of the tenant to the Has<Edge>With
+// predicates of the tenant-scoped targets, including the nested ones
+func (repo *PgxRepository) scopeEdges(ctx context.Context, preds []*comparison.Predicate) ([]*comparison.Predicate, error) {
+	scoped := make([]*comparison.Predicate, 0, len(preds))
+	for _, pred := range preds {
+		edge, ok := pred.Arg.(*comparison.Edge)
+		if pred.Op != comparison.Exists || !ok {
+			scoped = append(scoped, pred)
+			continue
+		}
+
+		edgePreds, err := repo.scopeEdges(ctx, edge.Preds)
+		if err != nil {
+			return nil, err
+		}
+
+		if edge.TenantField != "" {
+			tenantID, err := repo.edgeTenant(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			edgePreds = append(edgePreds, &comparison.Predicate{
+				Field: edge.TenantField, Op: comparison.Eq, Arg: tenantID,
+			})
+		}
+
+		scopedEdge := *edge
+		scopedEdge.Preds = edgePreds
+		scoped = append(scoped, &comparison.Predicate{Field: pred.Field, Op: pred.Op, Arg: &scopedEdge})
+	}
+
+	return scoped, nil
+}
+
+{{end -}}
+{{if or .TenantField .Edges -}}
+{{if .TenantField -}}
+// scope appends the predicate of the tenant to the predicates so the
+// statements never read or write the rows of the other tenants
+{{- if .Edges}}. The
+// Has<Edge>With predicates of the tenant-scoped edges are scoped too.
+{{- end}}
+{{else -}}
+// scope appends the predicate of the tenant to the Has<Edge>With predicates
+// of the tenant-scoped edges
+{{end -}}
+func (repo *PgxRepository) scope(ctx context.Context, preds []*comparison.Predicate) ([]*comparison.Predicate, error) {
+	{{if .TenantField -}}
+		tenantID, err := repo.tenant(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		preds = append(preds, &comparison.Predicate{
+			Field: "{{.TenantField.Name}}", Op: comparison.Eq, Arg: tenantID,
+		})
+
+	{{end -}}
+	{{if .Edges -}}
+		return repo.scopeEdges(ctx, preds)
+	{{else -}}
+		return preds, nil
+	{{end -}}
+}
+
+{{end -}}
+
+// runner returns the transaction carried by the context when
+// the tx-from-context mode is enabled, otherwise it returns the db
+func (repo *PgxRepository) runner(ctx context.Context) (pgxdb.DB, error) {
+	if !repo.txFromContext {
+		return repo.db, nil
+	}
+
+	tx, ok := nero.TxFromContext(ctx)
+	if !ok {
+		return repo.db, nil
+	}
+
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return txx, nil
+}
+
+// readRunner returns a replica chosen by the balancer. It falls back to runner
+// when there are no replicas, when primary is true or when the context carries
+// a transaction in the tx-from-context mode.
+func (repo *PgxRepository) readRunner(ctx context.Context, primary bool) (pgxdb.DB, error) {
+	if primary || len(repo.replicas) == 0 || repo.balancer == nil {
+		return repo.runner(ctx)
+	}
+
+	if _, ok := nero.TxFromContext(ctx); ok && repo.txFromContext {
+		return repo.runner(ctx)
+	}
+
+	return repo.balancer(repo.replicas), nil
+}
+
+// Tx begins a new transaction
+func (repo *PgxRepository) Tx(ctx context.Context) (nero.Tx, error) {
+	return repo.TxWithOptions(ctx, nil)
+}
+
+// TxWithOptions begins a new transaction with the given options. If the
+// repository's db is already a transaction, a nested transaction is returned.
+func (repo *PgxRepository) TxWithOptions(ctx context.Context, opts *sql.TxOptions) (nero.Tx, error) {
+	tx, err := pgxdb.BeginTx(ctx, repo.db, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// RunInTx runs fn in a transaction. The transaction is committed if fn returns nil
+// and rolled back if fn returns an error or panics. The transaction is retried
+// on serialization failures according to opts, which may be nil.
+func (repo *PgxRepository) RunInTx(ctx context.Context, opts *nero.TxOptions, fn func(nero.Tx) error) error {
+	return runInTx(ctx, repo.TxWithOptions, repo.isRetryable, opts, fn)
+}
+
+// isRetryable returns true if err is a serialization failure or a deadlock
+func (repo *PgxRepository) isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+
+	return false
+}
+
+// Create creates a {{.TypeName}}
+func (repo *PgxRepository) Create(ctx context.Context, c *Creator) ({{$keyType}}, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return {{$keyZero}}, err
+	}
+
+	return repo.create(ctx, runner, c)
+}
+
+// CreateTx creates a {{.TypeName}} in a transaction
+func (repo *PgxRepository) CreateTx(ctx context.Context, tx nero.Tx, c *Creator) ({{$keyType}}, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return {{$keyZero}}, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.create(ctx, txx, c)
+}
+
+// create inserts a {{.TypeName}}. If conflict columns are given, the row that has the
+// same values for these columns is updated instead i.e. INSERT ... ON CONFLICT DO UPDATE.
+func (repo *PgxRepository) create(ctx context.Context, runner pgxdb.DB, c *Creator, conflict ...string) ({{$keyType}}, error) {
+	{{if .TenantField -}}
+		if err := repo.setTenant(ctx, c); err != nil {
+			return {{$keyZero}}, err
+		}
+
+	{{end -}}
+	if err := c.generate(); err != nil {
+		return {{$keyZero}}, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return {{$keyZero}}, err
+	}
+
+	columns := []string{
+		{{range $field := $fields -}}
+			{{if and (ne $field.IsOptional true) (ne $field.IsAuto true) -}}
+				"\"{{$field.Name}}\"",
+			{{end -}}
+		{{end -}}
+	}
+
+	values := []interface{}{
+		{{range $field := $fields -}}
+			{{if and (ne $field.IsOptional true) (ne $field.IsAuto true) -}}
+				c.{{$field.Identifier}},
+			{{end -}}
+		{{end -}}
+	}
+
+	{{range $field := $fields -}}
+		{{if and ($field.IsOptional) (ne $field.IsAuto true) -}}
+			if !isZero(c.{{$field.Identifier}}) {
+				columns = append(columns, "\"{{$field.Name}}\"")
+				values = append(values, c.{{$field.Identifier}})
+			}
+		{{end -}}
+	{{end}}
+
+	suffix := "RETURNING {{range $i, $field := .Identities}}{{if $i}}, {{end}}\"{{$field.Name}}\"{{end}}"
+	if len(conflict) > 0 {
+		{{if .TenantField -}}
+			// the unique indexes are per tenant, so the row of another
+			// tenant is never in conflict
+			if !contains(conflict, "\"{{.TenantField.Name}}\"") {
+				conflict = append([]string{"\"{{.TenantField.Name}}\""}, conflict...)
+			}
+
+		{{end -}}
+		// the key of the existing row is kept so the rows that reference it
+		// aren't broken and the returned key is the one of the existing row
+		keys := []string{
+			{{range $field := .Identities -}}
+				"\"{{$field.Name}}\"",
+			{{end -}}
+		}
+		sets := []string{}
+		for _, col := range columns {
+			if !contains(conflict, col) && !contains(keys, col) {
+				sets = append(sets, col+" = EXCLUDED."+col)
+			}
+		}
+
+		// the row is only returned if it's updated
+		if len(sets) == 0 {
+			sets = append(sets, conflict[0]+" = EXCLUDED."+conflict[0])
+		}
+
+		onConflict := fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s ", strings.Join(conflict, ", "),
+			strings.Join(sets, ", "))
+		suffix = onConflict + suffix
+	}
+
+	stmt, args, err := squirrel.Insert(repo.table()).
+		Columns(columns...).
+		Values(values...).
+		Suffix(suffix).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: Create, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return {{$keyZero}}, err
+	}
+
+	{{if .HasCompositeKey -}}
+		var key Key
+		err = runner.QueryRow(ctx, stmt, args...).Scan(
+			{{range $field := .Identities -}}
+				pgxdb.Key(&key.{{$field.StructField}}),
+			{{end -}}
+		)
+		if err != nil {
+			return Key{}, noRows(err)
+		}
+
+		return key, nil
+	{{else -}}
+		var {{.Identity.Identifier}} {{rawType .Identity.TypeInfo.V}}
+		err = runner.QueryRow(ctx, stmt, args...).Scan(pgxdb.Key(&{{.Identity.Identifier}}))
+		if err != nil {
+			return {{$keyZero}}, noRows(err)
+		}
+
+		return {{.Identity.Identifier}}, nil
+	{{end -}}
+}
+
+// CreateMany batch creates {{.TypeNamePlural}}
+func (repo *PgxRepository) CreateMany(ctx context.Context, cs ...*Creator) error {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return err
+	}
+
+	return repo.createMany(ctx, runner, cs...)
+}
+
+// CreateManyTx batch creates {{.TypeNamePlural}} in a transaction
+func (repo *PgxRepository) CreateManyTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.createMany(ctx, txx, cs...)
+}
+
+// createMany copies the {{.TypeNamePlural}} to the table with the COPY protocol
+func (repo *PgxRepository) createMany(ctx context.Context, runner pgxdb.DB, cs ...*Creator) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	columns := []string{
+		{{range $field := $fields -}}
+			{{if ne $field.IsAuto true -}}
+				"{{$field.Name}}",
+			{{end -}}
+		{{end -}}
+	}
+
+	rows := [][]interface{}{}
+	for _, c := range cs {
+		{{if .TenantField -}}
+			if err := repo.setTenant(ctx, c); err != nil {
+				return err
+			}
+
+		{{end -}}
+		if err := c.generate(); err != nil {
+			return err
+		}
+
+		if err := c.Validate(); err != nil {
+			return err
+		}
+
+		rows = append(rows, []interface{}{
+			{{range $field := $fields -}}
+				{{if ne $field.IsAuto true -}}
+					c.{{$field.Identifier}},
+				{{end -}}
+			{{end -}}
+		})
+	}
+
+	_, err := runner.CopyFrom(ctx, repo.identifier(), columns, pgx.CopyFromRows(rows))
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: CreateMany, table: %q, columns: %v, rows: %d, error: %v", repo.identifier().Sanitize(), columns, len(rows), err)
+	}
+
+	return err
+}
+
+// BulkInsert inserts {{.TypeNamePlural}} with the COPY protocol, same as CreateMany
+func (repo *PgxRepository) BulkInsert(ctx context.Context, cs ...*Creator) error {
+	return repo.CreateMany(ctx, cs...)
+}
+
+// BulkInsertTx inserts {{.TypeNamePlural}} with the COPY protocol in a transaction, same as CreateManyTx
+func (repo *PgxRepository) BulkInsertTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
+	return repo.CreateManyTx(ctx, tx, cs...)
+}
+
+// Query queries {{.TypeNamePlural}}
+func (repo *PgxRepository) Query(ctx context.Context, q *Queryer) ([]{{rawType .TypeInfo.V}}, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.query(ctx, runner, q)
+}
+
+// QueryTx queries {{.TypeNamePlural}} in a transaction
+func (repo *PgxRepository) QueryTx(ctx context.Context, tx nero.Tx, q *Queryer) ([]{{rawType .TypeInfo.V}}, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.query(ctx, txx, q)
+}
+
+func (repo *PgxRepository) query(ctx context.Context, runner pgxdb.DB, q *Queryer) ([]{{rawType .TypeInfo.V}}, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, args, err := qb.ToSql()
+	if repo.debug  && repo.logger != nil {
+		repo.logger.Printf("method: Query, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := runner.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	{{if .Edges -}}
+		{{.TypeIdentifierPlural}}, err := repo.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		err = repo.loadEdges(ctx, runner, q, {{.TypeIdentifierPlural}}...)
+		if err != nil {
+			return nil, err
+		}
+
+		return {{.TypeIdentifierPlural}}, nil
+	{{else -}}
+		return repo.scan(rows)
+	{{end -}}
+}
+
+// QueryOne queries a {{.TypeName}}
+func (repo *PgxRepository) QueryOne(ctx context.Context, q *Queryer) ({{rawType .TypeInfo.V}}, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.queryOne(ctx, runner, q)
+}
+
+// QueryOneTx queries a {{.TypeName}} in a transaction
+func (repo *PgxRepository) QueryOneTx(ctx context.Context, tx nero.Tx, q *Queryer) ({{rawType .TypeInfo.V}}, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.queryOne(ctx, txx, q)
+}
+
+func (repo *PgxRepository) queryOne(ctx context.Context, runner pgxdb.DB, q *Queryer) ({{rawType .TypeInfo.V}}, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return {{zeroValue .TypeInfo.V}}, err
+	}
+
+	stmt, args, err := qb.ToSql()
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: QueryOne, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return {{zeroValue .TypeInfo.V}}, err
+	}
+
+	var {{.TypeIdentifier}} {{type .TypeInfo.V}}
+	err = runner.QueryRow(ctx, stmt, args...).
+		Scan(
+			{{range $field := $fields -}}
+				{{if $.IsIdentity $field -}}
+					pgxdb.Key(&{{$.TypeIdentifier}}.{{$field.StructField}}),
+				{{else -}}
+					&{{$.TypeIdentifier}}.{{$field.StructField}},
+				{{end -}}
+			{{end -}}
+		)
+	if err != nil {
+		return {{zeroValue .TypeInfo.V}}, noRows(err)
+	}
+
+	{{if .Edges -}}
+		err = repo.loadEdges(ctx, runner, q, &{{.TypeIdentifier}})
+		if err != nil {
+			return {{zeroValue .TypeInfo.V}}, err
+		}
+
+	{{end -}}
+	return &{{.TypeIdentifier}}, nil
+}
+
+// Exists checks if there's any {{.TypeName}} that matches the queryer
+func (repo *PgxRepository) Exists(ctx context.Context, q *Queryer) (bool, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return false, err
+	}
+
+	return repo.exists(ctx, runner, q)
+}
+
+// ExistsTx checks if there's any {{.TypeName}} that matches the queryer in a transaction
+func (repo *PgxRepository) ExistsTx(ctx context.Context, tx nero.Tx, q *Queryer) (bool, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return false, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.exists(ctx, txx, q)
+}
+
+func (repo *PgxRepository) exists(ctx context.Context, runner pgxdb.DB, q *Queryer) (bool, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return false, err
+	}
+
+	eb := squirrel.Select().
+		Column(squirrel.Expr("EXISTS (?)", qb.PlaceholderFormat(squirrel.Question))).
+		PlaceholderFormat(squirrel.Dollar)
+
+	stmt, args, err := eb.ToSql()
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: Exists, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = runner.QueryRow(ctx, stmt, args...).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Count counts the {{.TypeNamePlural}} that match the queryer
+func (repo *PgxRepository) Count(ctx context.Context, q *Queryer) (int64, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.count(ctx, runner, q)
+}
+
+// CountTx counts the {{.TypeNamePlural}} that match the queryer in a transaction
+func (repo *PgxRepository) CountTx(ctx context.Context, tx nero.Tx, q *Queryer) (int64, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return 0, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.count(ctx, txx, q)
+}
+
+// count counts the rows of the select so the limit and
+// the offset of the queryer are taken into account
+func (repo *PgxRepository) count(ctx context.Context, runner pgxdb.DB, q *Queryer) (int64, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+
+	eb := squirrel.Select("COUNT(*)").
+		FromSelect(qb.PlaceholderFormat(squirrel.Question), "q").
+		PlaceholderFormat(squirrel.Dollar)
+
+	stmt, args, err := eb.ToSql()
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: Count, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = runner.QueryRow(ctx, stmt, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo *PgxRepository) buildSelect(ctx context.Context, q *Queryer) (squirrel.SelectBuilder, error) {
+	qb := squirrel.Select(repo.columns()...).
+		From(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if len(q.distinctOn) > 0 {
+		on := []string{}
+		for _, field := range q.distinctOn {
+			on = append(on, fmt.Sprintf("%q", field.String()))
+		}
+		qb = qb.Options("DISTINCT ON (" + strings.Join(on, ", ") + ")")
+	} else if q.distinct {
+		qb = qb.Distinct()
+	}
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range q.predFuncs {
+		preds = predFunc(preds)
+	}
+	{{if or .TenantField .Edges -}}
+		preds, err := repo.scope(ctx, preds)
+		if err != nil {
+			return squirrel.SelectBuilder{}, err
+		}
+	{{end -}}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	sorts := []*sort.Sort{}
+	for _, sortFunc := range q.sortFuncs {
+		sorts = sortFunc(sorts)
+	}
+	qb = repo.buildSort(qb, sorts)
+
+	if q.limit > 0 {
+		qb = qb.Limit(uint64(q.limit))
+	}
+
+	if q.offset > 0 {
+		qb = qb.Offset(uint64(q.offset))
+	}
+
+	if q.forUpdate || q.forShare {
+		lock := "FOR UPDATE"
+		if q.forShare {
+			lock = "FOR SHARE"
+		}
+
+		if q.skipLocked {
+			lock += " SKIP LOCKED"
+		} else if q.noWait {
+			lock += " NOWAIT"
+		}
+
+		qb = qb.Suffix(lock)
+	}
+
+	return qb, nil
+}
+
+func (repo *PgxRepository) columns() []string {
+	return []string{
+		{{range $field := $fields -}}
+			"\"{{$field.Name}}\"",
+		{{end -}}
+	}
+}
+
+func (repo *PgxRepository) scan(rows pgx.Rows) ([]{{rawType .TypeInfo.V}}, error) {
+	{{.TypeIdentifierPlural}} := []{{rawType .TypeInfo.V}}{}
+	for rows.Next() {
+		var {{.TypeIdentifier}} {{type .TypeInfo.V}}
+		err := rows.Scan(
+			{{range $field := $fields -}}
+				{{if $.IsIdentity $field -}}
+					pgxdb.Key(&{{$.TypeIdentifier}}.{{$field.StructField}}),
+				{{else -}}
+					&{{$.TypeIdentifier}}.{{$field.StructField}},
+				{{end -}}
+			{{end -}}
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		{{.TypeIdentifierPlural}} = append({{.TypeIdentifierPlural}}, &{{.TypeIdentifier}})
+	}
+
+	return {{.TypeIdentifierPlural}}, rows.Err()
+}
+
+func (repo *PgxRepository) buildPreds(sb squirrel.StatementBuilderType, table string, preds []*comparison.Predicate) squirrel.StatementBuilderType {
+	column := func(field string) string {
+		if table == "" {
+			return fmt.Sprintf("%q", field)
+		}
+		return fmt.Sprintf("%q.%q", table, field)
+	}
+
+	for _, pred := range preds {
+		ph := "?"
+		fieldX, arg := column(pred.Field), pred.Arg
+
+		args := []interface{}{}
+		if fieldY, ok := arg.(comparison.FieldArg); ok { // a field
+			ph = column(fieldY.Column())
+		} else if vals, ok := arg.([]interface{}); ok  {  // array of values
+			args = append(args, vals...)
+		} else { // single value
+			args = append(args, arg)
+		}
+
+		switch pred.Op {
+		case comparison.Eq:
+			sb = sb.Where(fmt.Sprintf("%s = "+ph, fieldX), args...)
+		case comparison.NotEq:
+			sb = sb.Where(fmt.Sprintf("%s <> "+ph, fieldX), args...)
+		case comparison.Gt:
+			sb = sb.Where(fmt.Sprintf("%s > "+ph, fieldX), args...)
+		case comparison.GtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s >= "+ph, fieldX), args...)
+		case comparison.Lt:
+			sb = sb.Where(fmt.Sprintf("%s < "+ph, fieldX), args...)
+		case comparison.LtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s <= "+ph, fieldX), args...)
+		case comparison.IsNull, comparison.IsNotNull:
+			fmtStr := "%s IS NULL"
+			if pred.Op == comparison.IsNotNull {
+				fmtStr = "%s IS NOT NULL"
+			}
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX))
+		case comparison.In, comparison.NotIn:
+			fmtStr := "%s IN (%s)"
+			if pred.Op == comparison.NotIn {
+				fmtStr = "%s NOT IN (%s)"
+			}
+
+			phs := []string{}
+			for range args {
+				phs = append(phs, "?")
+			}
+
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX, strings.Join(phs, ",")), args...)
+		{{if .Edges -}}
+		case comparison.Exists:
+			edge, _ := arg.(*comparison.Edge)
+			sb = sb.Where(squirrel.Expr("EXISTS (?)", repo.buildExists(table, edge)))
+		{{end -}}
+		case comparison.PathEq:
+			// the predicates of Has<Edge>With may come from the target schema
+			pv, _ := arg.(*comparison.PathValue)
+			sb = sb.Where(fmt.Sprintf("%s #>> ? = ?", fieldX), strings.Split(pv.Path, "."), pv.Value)
+		case comparison.Contains:
+			sb = sb.Where(fmt.Sprintf("%s @> ?", fieldX), args...)
+		case comparison.ContainedBy:
+			sb = sb.Where(fmt.Sprintf("%s <@ ?", fieldX), args...)
+		case comparison.Overlap:
+			sb = sb.Where(fmt.Sprintf("%s && ?", fieldX), args...)
+		case comparison.AnyEq:
+			sb = sb.Where(fmt.Sprintf("? = ANY(%s)", fieldX), args...)
+		}
+	}
+
+	return sb
+}
+
+func (repo *PgxRepository) buildSort(qb squirrel.SelectBuilder, sorts []*sort.Sort) squirrel.SelectBuilder {
+	for _, s := range sorts {
+		field := fmt.Sprintf("%q", s.Field)
+		switch s.Direction {
+		case sort.Asc:
+			qb = qb.OrderBy(field + " ASC")
+		case sort.Desc:
+			qb = qb.OrderBy(field + " DESC")
+		}
+	}
+
+	return qb
+}
+
+{{if .Edges -}}
+// buildExists builds the subquery of the Has<Edge>With predicates. The target
+// table is aliased with the edge name, prefixed with the alias of the outer
+// table when the predicate is nested, so the columns are never ambiguous.
+func (repo *PgxRepository) buildExists(outer string, edge *comparison.Edge) squirrel.SelectBuilder {
+	alias := edge.Name
+	if outer == "" {
+		outer = "{{.Collection}}"
+	} else {
+		alias = outer + "_" + edge.Name
+	}
+
+	qb := squirrel.Select("1").
+		From(repo.qualify(edge.Namespace, edge.Collection) + fmt.Sprintf(" AS %q", alias))
+	if edge.Through == "" {
+		qb = qb.Where(fmt.Sprintf("%q.%q = %q.%q", alias, edge.Column, outer, edge.Ref))
+	} else {
+		through := alias + "_" + edge.Through
+		qb = qb.Join(repo.qualify(edge.ThroughNamespace, edge.Through)+
+			fmt.Sprintf(" AS %q ON %q.%q = %q.%q", through, through, edge.ThroughTo, alias, edge.Column)).
+			Where(fmt.Sprintf("%q.%q = %q.%q", through, edge.ThroughFrom, outer, edge.Ref))
+	}
+
+	return squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), alias, edge.Preds))
+}
+
+// loadEdges eager loads the edges that are requested by the queryer. The
+// queries of the edges are sent to the database in a single batch.
+func (repo *PgxRepository) loadEdges(ctx context.Context, runner pgxdb.DB, q *Queryer, {{.TypeIdentifierPlural}} ...{{rawType .TypeInfo.V}}) error {
+	if len({{.TypeIdentifierPlural}}) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	loaders := []func(pgx.BatchResults) error{}
+	{{range $edge := .Edges -}}
+		if q.with{{$edge.StructField}} {
+			qb, ok := repo.select{{$edge.StructField}}({{$.TypeIdentifierPlural}})
+			if ok {
+				{{if $edge.Schema.TenantField -}}
+					// the rows of the other tenants are never loaded
+					tenantID, err := repo.edgeTenant(ctx)
+					if err != nil {
+						return errors.Wrap(err, "load {{$edge.Name}}")
+					}
+					qb = qb.Where("\"{{$edge.Schema.Collection}}\".\"{{$edge.Schema.TenantField.Name}}\" = ?", tenantID)
+
+				{{end -}}
+				stmt, args, err := qb.ToSql()
+				if repo.debug && repo.logger != nil {
+					repo.logger.Printf("method: Query, edge: {{$edge.Name}}, stmt: %q, args: %v, error: %v", stmt, args, err)
+				}
+
+				if err != nil {
+					return errors.Wrap(err, "load {{$edge.Name}}")
+				}
+
+				batch.Queue(stmt, args...)
+				loaders = append(loaders, func(br pgx.BatchResults) error {
+					rows, err := br.Query()
+					if err != nil {
+						return errors.Wrap(err, "load {{$edge.Name}}")
+					}
+					defer rows.Close()
+
+					return errors.Wrap(repo.load{{$edge.StructField}}(rows, {{$.TypeIdentifierPlural}}), "load {{$edge.Name}}")
+				})
+			}
+		}
+
+	{{end -}}
+	if len(loaders) == 0 {
+		return nil
+	}
+
+	br := runner.SendBatch(ctx, batch)
+	for _, load := range loaders {
+		if err := load(br); err != nil {
+			br.Close()
+			return err
+		}
+	}
+
+	return br.Close()
+}
+{{end -}}
+
+{{range $edge := .Edges -}}
+{{$target := $edge.Schema -}}
+{{$targetFields := $target.AllFields -}}
+// select{{$edge.StructField}} builds the query of the {{$edge.Name}} edge of the {{$.TypeNamePlural}}.
+// It returns false if there's nothing to load.
+func (repo *PgxRepository) select{{$edge.StructField}}({{$.TypeIdentifierPlural}} []{{rawType $.TypeInfo.V}}) (squirrel.SelectBuilder, bool) {
+	columns := []string{
+		{{if $edge.IsManyToMany -}}
+			"\"{{$edge.Through}}\".\"{{$edge.ThroughFrom}}\"",
+		{{end -}}
+		{{range $field := $targetFields -}}
+			"\"{{$target.Collection}}\".\"{{$field.Name}}\"",
+		{{end -}}
+	}
+
+	{{if $edge.IsManyToOne -}}
+		{{$fk := $.Field $edge.ForeignKey -}}
+		keys := []interface{}{}
+		seen := map[{{type $fk.TypeInfo.V}}]bool{}
+		for _, {{$.TypeIdentifier}} := range {{$.TypeIdentifierPlural}} {
+			{{if $fk.IsNillable -}}
+				if {{$.TypeIdentifier}}.{{$fk.StructField}} == nil {
+					continue
+				}
+				key := *{{$.TypeIdentifier}}.{{$fk.StructField}}
+			{{else -}}
+				key := {{$.TypeIdentifier}}.{{$fk.StructField}}
+			{{end}}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+
+		if len(keys) == 0 {
+			return squirrel.SelectBuilder{}, false
+		}
+
+		return squirrel.Select(columns...).
+			From(repo.qualify("{{$target.Namespace}}", "{{$target.Collection}}")).
+			Where(squirrel.Eq{"\"{{$target.Collection}}\".\"{{$target.Identity.Name}}\"": keys}).
+			PlaceholderFormat(squirrel.Dollar), true
+	{{else -}}
+		keys := []interface{}{}
+		for _, {{$.TypeIdentifier}} := range {{$.TypeIdentifierPlural}} {
+			keys = append(keys, {{$.TypeIdentifier}}.{{$.Identity.StructField}})
+		}
+
+		{{if $edge.IsOneToMany -}}
+			return squirrel.Select(columns...).
+				From(repo.qualify("{{$target.Namespace}}", "{{$target.Collection}}")).
+				Where(squirrel.Eq{"\"{{$target.Collection}}\".\"{{$edge.ForeignKey}}\"": keys}).
+				PlaceholderFormat(squirrel.Dollar), true
+		{{else -}}
+			return squirrel.Select(columns...).
+				From(repo.qualify("{{$target.Namespace}}", "{{$target.Collection}}")).
+				Join(repo.qualify("{{$.Namespace}}", "{{$edge.Through}}") + " ON \"{{$edge.Through}}\".\"{{$edge.ThroughTo}}\" = \"{{$target.Collection}}\".\"{{$target.Identity.Name}}\"").
+				Where(squirrel.Eq{"\"{{$edge.Through}}\".\"{{$edge.ThroughFrom}}\"": keys}).
+				PlaceholderFormat(squirrel.Dollar), true
+		{{end -}}
+	{{end -}}
+}
+
+// load{{$edge.StructField}} scans the rows of the {{$edge.Name}} edge and sets it to the {{$.TypeNamePlural}}
+func (repo *PgxRepository) load{{$edge.StructField}}(rows pgx.Rows, {{$.TypeIdentifierPlural}} []{{rawType $.TypeInfo.V}}) error {
+	{{if $edge.IsManyToOne -}}
+		related := map[{{type $target.Identity.TypeInfo.V}}]{{rawType $target.TypeInfo.V}}{}
+	{{else -}}
+		related := map[{{type $.Identity.TypeInfo.V}}][]{{rawType $target.TypeInfo.V}}{}
+	{{end -}}
+	for rows.Next() {
+		{{if $edge.IsManyToMany -}}
+			var key {{type $.Identity.TypeInfo.V}}
+		{{end -}}
+		var item {{type $target.TypeInfo.V}}
+		err := rows.Scan(
+			{{if $edge.IsManyToMany -}}
+				pgxdb.Key(&key),
+			{{end -}}
+			{{range $field := $targetFields -}}
+				{{if $target.IsIdentity $field -}}
+					pgxdb.Key(&item.{{$field.StructField}}),
+				{{else -}}
+					&item.{{$field.StructField}},
+				{{end -}}
+			{{end -}}
+		)
+		if err != nil {
+			return err
+		}
+
+		{{if $edge.IsManyToOne -}}
+			related[item.{{$target.Identity.StructField}}] = &item
+		{{else if $edge.IsOneToMany -}}
+			{{$fk := $target.Field $edge.ForeignKey -}}
+			{{if $fk.IsNillable -}}
+				if item.{{$fk.StructField}} == nil {
+					continue
+				}
+				key := *item.{{$fk.StructField}}
+			{{else -}}
+				key := item.{{$fk.StructField}}
+			{{end -}}
+			related[key] = append(related[key], &item)
+		{{else -}}
+			related[key] = append(related[key], &item)
+		{{end -}}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, {{$.TypeIdentifier}} := range {{$.TypeIdentifierPlural}} {
+		{{if $edge.IsManyToOne -}}
+			{{$fk := $.Field $edge.ForeignKey -}}
+			{{if $fk.IsNillable -}}
+				if {{$.TypeIdentifier}}.{{$fk.StructField}} != nil {
+					{{$.TypeIdentifier}}.{{$edge.StructField}} = related[*{{$.TypeIdentifier}}.{{$fk.StructField}}]
+				}
+			{{else -}}
+				{{$.TypeIdentifier}}.{{$edge.StructField}} = related[{{$.TypeIdentifier}}.{{$fk.StructField}}]
+			{{end -}}
+		{{else -}}
+			{{$.TypeIdentifier}}.{{$edge.StructField}} = related[{{$.TypeIdentifier}}.{{$.Identity.StructField}}]
+		{{end -}}
+	}
+
+	return nil
+}
+
+{{end -}}
+
+{{range $index := .Indexes -}}
+	// QueryBy{{$index.StructField}} queries a {{$.TypeName}} by the unique {{$index.StructField}} index
+	func (repo *PgxRepository) QueryBy{{$index.StructField}}(ctx context.Context, {{range $field := $index.Fields}}{{$field.Identifier}} {{rawType $field.TypeInfo.V}}, {{end}}) ({{rawType $.TypeInfo.V}}, error) {
+		return repo.QueryOne(ctx, NewQueryer().Where(
+			{{range $field := $index.Fields -}}
+				{{$field.StructField}}Eq({{$field.Identifier}}),
+			{{end -}}
+		))
+	}
+
+	// QueryBy{{$index.StructField}}Tx queries a {{$.TypeName}} by the unique {{$index.StructField}} index in a transaction
+	func (repo *PgxRepository) QueryBy{{$index.StructField}}Tx(ctx context.Context, tx nero.Tx, {{range $field := $index.Fields}}{{$field.Identifier}} {{rawType $field.TypeInfo.V}}, {{end}}) ({{rawType $.TypeInfo.V}}, error) {
+		return repo.QueryOneTx(ctx, tx, NewQueryer().Where(
+			{{range $field := $index.Fields -}}
+				{{$field.StructField}}Eq({{$field.Identifier}}),
+			{{end -}}
+		))
+	}
+
+	// UpsertBy{{$index.StructField}} creates a {{$.TypeName}} or updates the one with the same {{$index.StructField}}
+	func (repo *PgxRepository) UpsertBy{{$index.StructField}}(ctx context.Context, c *Creator) ({{$keyType}}, error) {
+		runner, err := repo.runner(ctx)
+		if err != nil {
+			return {{$keyZero}}, err
+		}
+
+		return repo.create(ctx, runner, c, {{range $field := $index.Fields}}"\"{{$field.Name}}\"", {{end}})
+	}
+
+	// UpsertBy{{$index.StructField}}Tx creates a {{$.TypeName}} or updates the one with the same {{$index.StructField}} in a transaction
+	func (repo *PgxRepository) UpsertBy{{$index.StructField}}Tx(ctx context.Context, tx nero.Tx, c *Creator) ({{$keyType}}, error) {
+		txx, ok := tx.(*pgxdb.Tx)
+		if !ok {
+			return {{$keyZero}}, errors.New("expecting tx to be a *pgxdb.Tx")
+		}
+
+		return repo.create(ctx, txx, c, {{range $field := $index.Fields}}"\"{{$field.Name}}\"", {{end}})
+	}
+
+{{end -}}
+
+// Update updates a {{.TypeName}} or many {{.TypeNamePlural}}
+func (repo *PgxRepository) Update(ctx context.Context, u *Updater) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.update(ctx, runner, u)
+}
+
+// UpdateTx updates a {{.TypeName}} many {{.TypeNamePlural}} in a transaction
+func (repo *PgxRepository) UpdateTx(ctx context.Context, tx nero.Tx, u *Updater) (int64, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return 0, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.update(ctx, txx, u)
+}
+
+func (repo *PgxRepository) buildUpdate(ctx context.Context, u *Updater) (squirrel.UpdateBuilder, bool, error) {
+	qb := squirrel.Update(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	cnt := 0
+	{{range $field := .Fields }}
+		{{if and (ne $field.IsAuto true) (ne $field.IsTenant true)}}
+			if u.fields.has(Field{{$field.StructField}}) {
+				qb = qb.Set("\"{{$field.Name}}\"", u.{{$field.Identifier}})
+				cnt++
+			}
+		{{end}}
+	{{end}}
+
+	for _, e := range u.exprs {
+		{{if .TenantField -}}
+			if e.field == Field{{.TenantField.StructField}} {
+				return qb, false, errors.New("the tenant field can't be updated")
+			}
+
+		{{end -}}
+		col := fmt.Sprintf("%q", e.field)
+		if e.delta != nil {
+			qb = qb.Set(col, squirrel.Expr(col+" + ?", e.delta))
+		} else {
+			qb = qb.Set(col, squirrel.Expr(e.expr, e.args...))
+		}
+		cnt++
+	}
+
+	if cnt == 0 {
+		return qb, false, nil
+	}
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range u.predFuncs {
+		preds = predFunc(preds)
+	}
+	{{if or .TenantField .Edges -}}
+		preds, err := repo.scope(ctx, preds)
+		if err != nil {
+			return qb, false, err
+		}
+	{{end -}}
+	qb = squirrel.UpdateBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, true, nil
+}
+
+func (repo *PgxRepository) update(ctx context.Context, runner pgxdb.DB, u *Updater) (int64, error) {
+	if err := u.Validate(); err != nil {
+		return 0, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, nil
+	}
+
+	stmt, args, err := qb.ToSql()
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: Update, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := runner.Exec(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// UpdateReturning updates a {{.TypeName}} or many {{.TypeNamePlural}} and returns the updated {{.TypeNamePlural}}
+func (repo *PgxRepository) UpdateReturning(ctx context.Context, u *Updater) ([]{{rawType .TypeInfo.V}}, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.updateReturning(ctx, runner, u)
+}
+
+// UpdateReturningTx updates a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction and returns the updated {{.TypeNamePlural}}
+func (repo *PgxRepository) UpdateReturningTx(ctx context.Context, tx nero.Tx, u *Updater) ([]{{rawType .TypeInfo.V}}, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.updateReturning(ctx, txx, u)
+}
+
+func (repo *PgxRepository) updateReturning(ctx context.Context, runner pgxdb.DB, u *Updater) ([]{{rawType .TypeInfo.V}}, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	stmt, args, err := qb.Suffix("RETURNING " + strings.Join(repo.columns(), ",")).ToSql()
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: UpdateReturning, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := runner.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repo.scan(rows)
+}
+
+// UpdateMany updates many {{.TypeNamePlural}} with different values in a single statement
+func (repo *PgxRepository) UpdateMany(ctx context.Context, rus []*RowUpdate) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.updateMany(ctx, runner, rus)
+}
+
+// UpdateManyTx updates many {{.TypeNamePlural}} with different values in a single statement in a transaction
+func (repo *PgxRepository) UpdateManyTx(ctx context.Context, tx nero.Tx, rus []*RowUpdate) (int64, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return 0, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.updateMany(ctx, txx, rus)
+}
+
+// updateMany updates the {{.TypeNamePlural}} with an UPDATE ... FROM (VALUES ...) statement. The
+// values are preceded by an empty select of the table so they get the types of the columns,
+// and the columns that aren't set by a row update are flagged so they keep their value.
+func (repo *PgxRepository) updateMany(ctx context.Context, runner pgxdb.DB, rus []*RowUpdate) (int64, error) {
+	if len(rus) == 0 {
+		return 0, nil
+	}
+
+	for _, ru := range rus {
+		if err := ru.Validate(); err != nil {
+			return 0, err
+		}
+	}
+
+	columns := []string{
+		{{range $field := .Identities -}}
+			"\"{{$field.Name}}\"",
+		{{end -}}
+	}
+	sets := []string{}
+	rows := [][]interface{}{}
+	for _, ru := range rus {
+		{{if .HasCompositeKey -}}
+			rows = append(rows, []interface{}{
+				{{range $field := .Identities -}}
+					ru.key.{{$field.StructField}},
+				{{end -}}
+			})
+		{{else -}}
+			rows = append(rows, []interface{}{ru.key})
+		{{end -}}
+	}
+
+	{{range $field := .Fields -}}
+		{{if and (ne $field.IsAuto true) (ne $field.IsTenant true) -}}
+			if anyHas(rus, Field{{$field.StructField}}) {
+				columns = append(columns, "\"{{$field.Name}}\"", "false AS \"set_{{$field.Name}}\"")
+				sets = append(sets, "\"{{$field.Name}}\" = CASE WHEN \"v\".\"set_{{$field.Name}}\" "+
+					"THEN \"v\".\"{{$field.Name}}\" ELSE \"{{$.Collection}}\".\"{{$field.Name}}\" END")
+				for i, ru := range rus {
+					rows[i] = append(rows[i], ru.{{$field.Identifier}}, ru.fields.has(Field{{$field.StructField}}))
+				}
+			}
+
+		{{end -}}
+	{{end -}}
+	if len(sets) == 0 {
+		return 0, nil
+	}
+
+	{{if .TenantField -}}
+		tenantID, err := repo.tenant(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+	{{end -}}
+	// the rows are updated in chunks so the statements don't exceed the bind parameter limit
+	size := nero.PostgresMaxParams / (len(rows[0]) + 1)
+	if _, ok := runner.(*pgxdb.Tx); !ok && len(rows) > size {
+		// the chunks are updated in a transaction so it's all or nothing
+		var rowsAffected int64
+		err := repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+			var err error
+			rowsAffected, err = repo.UpdateManyTx(ctx, tx, rus)
+			return err
+		})
+		return rowsAffected, err
+	}
+
+	var rowsAffected int64
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		values := []string{}
+		args := []interface{}{}
+		for _, row := range rows[start:end] {
+			phs := []string{}
+			for range row {
+				phs = append(phs, "?")
+			}
+
+			values = append(values, "("+strings.Join(phs, ", ")+")")
+			args = append(args, row...)
+		}
+
+		where := []string{
+			{{range $field := .Identities -}}
+				"\"{{$.Collection}}\".\"{{$field.Name}}\" = \"v\".\"{{$field.Name}}\"",
+			{{end -}}
+		}
+		{{if .TenantField -}}
+			where = append(where, "\"{{.Collection}}\".\"{{.TenantField.Name}}\" = ?")
+			args = append(args, tenantID)
+
+		{{end -}}
+		stmt, err := squirrel.Dollar.ReplacePlaceholders(fmt.Sprintf(
+			"UPDATE %s SET %s FROM (SELECT %s FROM %s WHERE false UNION ALL VALUES %s) AS \"v\" WHERE %s",
+			repo.table(), strings.Join(sets, ", "), strings.Join(columns, ", "), repo.table(),
+			strings.Join(values, ", "), strings.Join(where, " AND ")))
+		if repo.debug && repo.logger != nil {
+			repo.logger.Printf("method: UpdateMany, stmt: %q, args: %v, error: %v", stmt, args, err)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		tag, err := runner.Exec(ctx, stmt, args...)
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected += tag.RowsAffected()
+	}
+
+	return rowsAffected, nil
+}
+
+// Delete deletes a {{.TypeName}} or many {{.TypeNamePlural}}
+func (repo *PgxRepository) Delete(ctx context.Context, d *Deleter) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.delete(ctx, runner, d)
+}
+
+// Delete deletes a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction
+func (repo *PgxRepository) DeleteTx(ctx context.Context, tx nero.Tx, d *Deleter) (int64, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return 0, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.delete(ctx, txx, d)
+}
+
+func (repo *PgxRepository) buildDelete(ctx context.Context, d *Deleter) (squirrel.DeleteBuilder, error) {
+	qb := squirrel.Delete(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range d.predFuncs {
+		preds = predFunc(preds)
+	}
+	{{if or .TenantField .Edges -}}
+		preds, err := repo.scope(ctx, preds)
+		if err != nil {
+			return qb, err
+		}
+	{{end -}}
+	qb = squirrel.DeleteBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, nil
+}
+
+func (repo *PgxRepository) delete(ctx context.Context, runner pgxdb.DB, d *Deleter) (int64, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, args, err := qb.ToSql()
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: Delete, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := runner.Exec(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// DeleteReturning deletes a {{.TypeName}} or many {{.TypeNamePlural}} and returns the deleted {{.TypeNamePlural}}
+func (repo *PgxRepository) DeleteReturning(ctx context.Context, d *Deleter) ([]{{rawType .TypeInfo.V}}, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.deleteReturning(ctx, runner, d)
+}
+
+// DeleteReturningTx deletes a {{.TypeName}} or many {{.TypeNamePlural}} in a transaction and returns the deleted {{.TypeNamePlural}}
+func (repo *PgxRepository) DeleteReturningTx(ctx context.Context, tx nero.Tx, d *Deleter) ([]{{rawType .TypeInfo.V}}, error) {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.deleteReturning(ctx, txx, d)
+}
+
+func (repo *PgxRepository) deleteReturning(ctx context.Context, runner pgxdb.DB, d *Deleter) ([]{{rawType .TypeInfo.V}}, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, args, err := qb.Suffix("RETURNING " + strings.Join(repo.columns(), ",")).ToSql()
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: DeleteReturning, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := runner.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repo.scan(rows)
+}
+
+// Aggregate runs an aggregate query
+func (repo *PgxRepository) Aggregate(ctx context.Context, a *Aggregator) error {
+	runner, err := repo.readRunner(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	return repo.aggregate(ctx, runner, a)
+}
+
+// Aggregate runs an aggregate query in a transaction
+func (repo *PgxRepository) AggregateTx(ctx context.Context, tx nero.Tx, a *Aggregator) error {
+	txx, ok := tx.(*pgxdb.Tx)
+	if !ok {
+		return errors.New("expecting tx to be a *pgxdb.Tx")
+	}
+
+	return repo.aggregate(ctx, txx, a)
+}
+
+func (repo *PgxRepository) aggregate(ctx context.Context, runner pgxdb.DB, a *Aggregator) error {
+	aggs := []*aggregate.Aggregate{}
+	for _, aggFunc := range a.aggFuncs {
+		aggs = aggFunc(aggs)
+	}
+	columns := []string{}
+	for _, agg := range aggs {
+		field := agg.Field
+		qf := fmt.Sprintf("%q", field)
+		switch agg.Op {
+		case aggregate.Avg:
+			columns = append(columns, "AVG("+qf+") avg_"+field)
+		case aggregate.Count:
+			columns = append(columns, "COUNT("+qf+") count_"+field)
+		case aggregate.Max:
+			columns = append(columns, "MAX("+qf+") max_"+field)
+		case aggregate.Min:
+			columns = append(columns, "MIN("+qf+") min_"+field)
+		case aggregate.Sum:
+			columns = append(columns, "SUM("+qf+") sum_"+field)
+		case aggregate.None:
+			columns = append(columns, qf)
+		}
+	}
+
+	qb := squirrel.Select(columns...).From(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	groupBys := []string{}
+	for _, groupBy := range a.groupBys {
+		groupBys = append(groupBys, fmt.Sprintf("%q", groupBy.String()))
+	}
+	qb = qb.GroupBy(groupBys...)
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range a.predFuncs {
+		preds = predFunc(preds)
+	}
+	{{if or .TenantField .Edges -}}
+		preds, err := repo.scope(ctx, preds)
+		if err != nil {
+			return err
+		}
+	{{end -}}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	sorts := []*sort.Sort{}
+	for _, sortFunc := range a.sortFuncs {
+		sorts = sortFunc(sorts)
+	}
+	qb = repo.buildSort(qb, sorts)
+
+	stmt, args, err := qb.ToSql()
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: Aggregate, stmt: %q, args: %v, error: %v", stmt, args, err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(a.v).Elem()
+	t := reflect.TypeOf(v.Interface()).Elem()
+	if len(columns) != t.NumField() {
+		return errors.Errorf("column count (%v) and destination struct field count (%v) doesn't match",  len(columns), t.NumField(),)
+	}
+
+	rows, err := runner.Query(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		ve := reflect.New(t).Elem()
+		dest := make([]interface{}, ve.NumField())
+		for i := 0; i < ve.NumField(); i++ {
+			dest[i] = ve.Field(i).Addr().Interface()
+		}
+
+		err = rows.Scan(dest...)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.Append(v, ve))
+	}
+
+	return rows.Err()
+}
+
+// noRows replaces pgx.ErrNoRows with sql.ErrNoRows so the
+// error is the same as the one of the other repositories
+func noRows(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+
+	return err
+}
+`
@@ -0,0 +1,18 @@
+package nero_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sf9v/nero"
+)
+
+func TestPgxTemplate(t *testing.T) {
+	tmpl := nero.NewPgxTemplate().WithFilename("pgx.go")
+	assert.Equal(t, "pgx.go", tmpl.Filename())
+
+	_, err := nero.ParseTemplate(tmpl)
+	require.NoError(t, err)
+}
@@ -0,0 +1,27 @@
+package pgxdb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sf9v/nero/pgxdb"
+)
+
+func TestBalancer(t *testing.T) {
+	replicas := []pgxdb.DB{&pgxdb.Tx{}, &pgxdb.Tx{}, &pgxdb.Tx{}}
+
+	t.Run("RoundRobin", func(t *testing.T) {
+		balancer := pgxdb.RoundRobinBalancer()
+		for i := 0; i < 2*len(replicas); i++ {
+			assert.Same(t, replicas[i%len(replicas)], balancer(replicas))
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		balancer := pgxdb.RandomBalancer()
+		for i := 0; i < 10; i++ {
+			assert.Contains(t, replicas, balancer(replicas))
+		}
+	})
+}
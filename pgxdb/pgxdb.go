@@ -0,0 +1,140 @@
+// Package pgxdb contains the base handle and the transaction of the
+// repositories that are generated with nero.NewPgxTemplate
+package pgxdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/sf9v/nero"
+)
+
+// DB is the base handle of the pgx repositories. It's
+// satisfied by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type DB interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// TxBeginner is an interface that wraps the BeginTx method
+// of *pgxpool.Pool and *pgx.Conn
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// Tx is a nero.Tx that wraps pgx.Tx
+type Tx struct {
+	pgx.Tx
+}
+
+var (
+	_ nero.Tx = (*Tx)(nil)
+	_ DB      = (*Tx)(nil)
+)
+
+// NewTx returns a Tx
+func NewTx(tx pgx.Tx) *Tx {
+	return &Tx{Tx: tx}
+}
+
+// Unwrap returns the underlying pgx.Tx
+func (tx *Tx) Unwrap() pgx.Tx {
+	return tx.Tx
+}
+
+// Commit commits the transaction
+func (tx *Tx) Commit() error {
+	return tx.Tx.Commit(context.Background())
+}
+
+// Rollback rolls back the transaction
+func (tx *Tx) Rollback() error {
+	return tx.Tx.Rollback(context.Background())
+}
+
+// Savepoint creates a savepoint
+func (tx *Tx) Savepoint(name string) error {
+	return tx.exec("SAVEPOINT " + nero.QuoteIdent(name))
+}
+
+// RollbackTo rolls back to a savepoint
+func (tx *Tx) RollbackTo(name string) error {
+	return tx.exec("ROLLBACK TO SAVEPOINT " + nero.QuoteIdent(name))
+}
+
+// Release releases a savepoint
+func (tx *Tx) Release(name string) error {
+	return tx.exec("RELEASE SAVEPOINT " + nero.QuoteIdent(name))
+}
+
+func (tx *Tx) exec(stmt string) error {
+	_, err := tx.Tx.Exec(context.Background(), stmt)
+	return err
+}
+
+// BeginTx begins a transaction on db. If db is already a transaction, a nested
+// transaction is returned i.e. a savepoint, in which case opts must be nil or
+// the default options since the outer transaction's options can't be changed.
+func BeginTx(ctx context.Context, db DB, opts *sql.TxOptions) (*Tx, error) {
+	if beginner, ok := db.(TxBeginner); ok {
+		txOpts, err := TxOptions(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		tx, err := beginner.BeginTx(ctx, txOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewTx(tx), nil
+	}
+
+	if opts != nil && (opts.Isolation != sql.LevelDefault || opts.ReadOnly) {
+		return nil, errors.New("tx options are not supported in a nested transaction")
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTx(tx), nil
+}
+
+// TxOptions converts the database/sql transaction options to the pgx ones
+func TxOptions(opts *sql.TxOptions) (pgx.TxOptions, error) {
+	txOpts := pgx.TxOptions{}
+	if opts == nil {
+		return txOpts, nil
+	}
+
+	switch opts.Isolation {
+	case sql.LevelDefault:
+	case sql.LevelReadUncommitted:
+		txOpts.IsoLevel = pgx.ReadUncommitted
+	case sql.LevelReadCommitted:
+		txOpts.IsoLevel = pgx.ReadCommitted
+	case sql.LevelRepeatableRead:
+		txOpts.IsoLevel = pgx.RepeatableRead
+	case sql.LevelSerializable:
+		txOpts.IsoLevel = pgx.Serializable
+	default:
+		return txOpts, fmt.Errorf("unsupported isolation level: %v", opts.Isolation)
+	}
+
+	if opts.ReadOnly {
+		txOpts.AccessMode = pgx.ReadOnly
+	}
+
+	return txOpts, nil
+}
@@ -0,0 +1,33 @@
+package pgxdb_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sf9v/nero/pgxdb"
+)
+
+func TestTxOptions(t *testing.T) {
+	opts, err := pgxdb.TxOptions(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, pgx.TxOptions{}, opts)
+
+	opts, err = pgxdb.TxOptions(&sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+		ReadOnly:  true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, pgx.Serializable, opts.IsoLevel)
+	assert.Equal(t, pgx.ReadOnly, opts.AccessMode)
+
+	opts, err = pgxdb.TxOptions(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	assert.NoError(t, err)
+	assert.Equal(t, pgx.ReadCommitted, opts.IsoLevel)
+	assert.Empty(t, opts.AccessMode)
+
+	_, err = pgxdb.TxOptions(&sql.TxOptions{Isolation: sql.LevelLinearizable})
+	assert.Error(t, err)
+}
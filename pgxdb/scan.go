@@ -0,0 +1,80 @@
+package pgxdb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Key returns the scan destination of a key column. Unlike database/sql, pgx
+// doesn't scan an integer into a string, so a *string or **string is wrapped
+// in a scanner that also accepts the integer columns e.g. a bigint key of a
+// string identity. The other destinations are returned as is.
+func Key(dest interface{}) interface{} {
+	switch d := dest.(type) {
+	case *string:
+		return &stringKey{dest: d}
+	case **string:
+		return &nullStringKey{dest: d}
+	default:
+		return dest
+	}
+}
+
+// stringKey scans a text or integer key into a string
+type stringKey struct {
+	dest *string
+}
+
+// Scan implements sql.Scanner
+func (k *stringKey) Scan(src interface{}) error {
+	if src == nil {
+		return errors.New("cannot scan NULL into a string key")
+	}
+
+	s, err := keyText(src)
+	if err != nil {
+		return err
+	}
+
+	*k.dest = s
+	return nil
+}
+
+// nullStringKey scans a text or integer key into a *string, NULL is scanned as nil
+type nullStringKey struct {
+	dest **string
+}
+
+// Scan implements sql.Scanner
+func (k *nullStringKey) Scan(src interface{}) error {
+	if src == nil {
+		*k.dest = nil
+		return nil
+	}
+
+	s, err := keyText(src)
+	if err != nil {
+		return err
+	}
+
+	*k.dest = &s
+	return nil
+}
+
+func keyText(src interface{}) (string, error) {
+	switch v := src.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	default:
+		return "", fmt.Errorf("cannot scan %T into a string key", src)
+	}
+}
@@ -0,0 +1,49 @@
+package pgxdb_test
+
+import (
+	"testing"
+
+	"github.com/jackc/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sf9v/nero/pgxdb"
+)
+
+func TestKey(t *testing.T) {
+	ci := pgtype.NewConnInfo()
+	int8Src := []byte{0, 0, 0, 0, 0, 0, 0, 42}
+
+	var id string
+	var teamID *string
+	var age int
+	assert.Equal(t, &age, pgxdb.Key(&age))
+
+	// a bigint is scanned into a string
+	err := ci.Scan(pgtype.Int8OID, pgtype.BinaryFormatCode, int8Src, pgxdb.Key(&id))
+	require.NoError(t, err)
+	assert.Equal(t, "42", id)
+
+	err = ci.Scan(pgtype.Int8OID, pgtype.BinaryFormatCode, int8Src, pgxdb.Key(&teamID))
+	require.NoError(t, err)
+	require.NotNil(t, teamID)
+	assert.Equal(t, "42", *teamID)
+
+	// the text keys are kept as is
+	err = ci.Scan(pgtype.VarcharOID, pgtype.TextFormatCode, []byte("01F4JKK4"), pgxdb.Key(&id))
+	require.NoError(t, err)
+	assert.Equal(t, "01F4JKK4", id)
+
+	// null
+	err = ci.Scan(pgtype.VarcharOID, pgtype.TextFormatCode, nil, pgxdb.Key(&teamID))
+	require.NoError(t, err)
+	assert.Nil(t, teamID)
+
+	err = ci.Scan(pgtype.Int8OID, pgtype.BinaryFormatCode, nil, pgxdb.Key(&id))
+	assert.EqualError(t, err, "cannot scan NULL into a string key")
+
+	// the other types are not keys
+	float8Src := []byte{0x40, 0x09, 0x21, 0xfb, 0x54, 0x44, 0x2d, 0x18}
+	err = ci.Scan(pgtype.Float8OID, pgtype.BinaryFormatCode, float8Src, pgxdb.Key(&id))
+	assert.EqualError(t, err, "cannot scan float64 into a string key")
+}
@@ -6,18 +6,32 @@ import (
 	stringsx "github.com/sf9v/nero/x/strings"
 )
 
+// Schemaer is an interface that wraps the Schema method
+type Schemaer interface {
+	Schema() *Schema
+}
+
 // Schema is a schema used for generating the repository
 type Schema struct {
 	// pkgName is the package name of the generated files
 	pkgName string
 	// Collection is the name of the database collection
 	collection string
+	// namespace is the database schema of the collection
+	namespace string
 	// typeInfo is the type info of the schema model
 	typeInfo *mira.TypeInfo
-	// identity is the identity field
-	identity *Field
+	// identities are the identity fields, there's more than
+	// one identity field if the schema has a composite key
+	identities []*Field
 	// fields is the list of fields
 	fields []*Field
+	// edges is the list of edges
+	edges []*Edge
+	// indexes is the list of indexes
+	indexes []*SchemaIndex
+	// tenant is the tenant field
+	tenant *Field
 	// imports are list of package imports
 	imports []string
 	// Templates is the list of custom repository templates
@@ -34,9 +48,44 @@ func (s *Schema) Collection() string {
 	return s.collection
 }
 
-// Identity returns the identity field
+// Namespace returns the namespace
+func (s *Schema) Namespace() string {
+	return s.namespace
+}
+
+// Identity returns the (first) identity field
 func (s *Schema) Identity() *Field {
-	return s.identity
+	if len(s.identities) == 0 {
+		return nil
+	}
+
+	return s.identities[0]
+}
+
+// Identities returns the identity fields
+func (s *Schema) Identities() []*Field {
+	return s.identities[:]
+}
+
+// IsIdentity returns true if the field is one of the identity fields
+func (s *Schema) IsIdentity(field *Field) bool {
+	for _, identity := range s.identities {
+		if identity == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasCompositeKey returns true if the schema has more than one identity field
+func (s *Schema) HasCompositeKey() bool {
+	return len(s.identities) > 1
+}
+
+// AllFields returns the identity fields followed by the other fields
+func (s *Schema) AllFields() []*Field {
+	return append(s.identities[:len(s.identities):len(s.identities)], s.fields...)
 }
 
 // Fields returns the fields
@@ -44,6 +93,44 @@ func (s *Schema) Fields() []*Field {
 	return s.fields[:]
 }
 
+// Field returns the field (including the identity) with the given name or nil
+func (s *Schema) Field(name string) *Field {
+	for _, field := range s.AllFields() {
+		if field.name == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// TenantField returns the tenant field or nil
+func (s *Schema) TenantField() *Field {
+	return s.tenant
+}
+
+// Edges returns the edges
+func (s *Schema) Edges() []*Edge {
+	return s.edges[:]
+}
+
+// HasTenantEdges returns true if any of the edges targets a schema
+// that has a tenant field, the edges are then scoped to the tenant
+func (s *Schema) HasTenantEdges() bool {
+	for _, edge := range s.edges {
+		if target := edge.Schema(); target != nil && target.TenantField() != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Indexes returns the unique indexes, including the ones of the unique fields
+func (s *Schema) Indexes() []*SchemaIndex {
+	return s.indexes[:]
+}
+
 // Imports returns the pkg imports
 func (s *Schema) Imports() []string {
 	return s.imports[:]
@@ -2,6 +2,7 @@ package nero
 
 import (
 	"github.com/sf9v/mira"
+	"github.com/sf9v/nero/validation"
 )
 
 // SchemaBuilder is used for building a schema
@@ -14,6 +15,7 @@ func NewSchemaBuilder(v interface{}) *SchemaBuilder {
 	return &SchemaBuilder{sc: &Schema{
 		typeInfo:  mira.NewTypeInfo(v),
 		fields:    []*Field{},
+		edges:     []*Edge{},
 		templates: []Template{},
 	}}
 }
@@ -30,9 +32,17 @@ func (sb *SchemaBuilder) Collection(collection string) *SchemaBuilder {
 	return sb
 }
 
-// Identity sets the identity field
-func (sb *SchemaBuilder) Identity(field *Field) *SchemaBuilder {
-	sb.sc.identity = field
+// Namespace sets the namespace i.e. the database schema of the collection
+// e.g. "game" for the "game"."players" table
+func (sb *SchemaBuilder) Namespace(namespace string) *SchemaBuilder {
+	sb.sc.namespace = namespace
+	return sb
+}
+
+// Identity sets the identity field. More than one field
+// can be passed if the schema has a composite key.
+func (sb *SchemaBuilder) Identity(fields ...*Field) *SchemaBuilder {
+	sb.sc.identities = fields
 	return sb
 }
 
@@ -42,6 +52,27 @@ func (sb *SchemaBuilder) Fields(fields ...*Field) *SchemaBuilder {
 	return sb
 }
 
+// TenantField sets the tenant field and adds it to the fields. The repository
+// methods are then scoped to the tenant ID that's carried by the context.
+func (sb *SchemaBuilder) TenantField(field *Field) *SchemaBuilder {
+	field.tenant = true
+	sb.sc.tenant = field
+	sb.sc.fields = append(sb.sc.fields, field)
+	return sb
+}
+
+// Edges sets the edges
+func (sb *SchemaBuilder) Edges(edges ...*Edge) *SchemaBuilder {
+	sb.sc.edges = append(sb.sc.edges, edges...)
+	return sb
+}
+
+// Indexes sets the unique indexes e.g. nero.UniqueIndex("name", "team_id")
+func (sb *SchemaBuilder) Indexes(indexes ...*SchemaIndex) *SchemaBuilder {
+	sb.sc.indexes = append(sb.sc.indexes, indexes...)
+	return sb
+}
+
 // Templates sets the templates
 func (sb *SchemaBuilder) Templates(templates ...Template) *SchemaBuilder {
 	sb.sc.templates = append(sb.sc.templates, templates...)
@@ -62,10 +93,34 @@ func (sb *SchemaBuilder) Build() *Schema {
 
 	// get pkg imports
 	importMap := map[string]int{}
-	for _, fld := range append(sb.sc.fields, sb.sc.identity) {
+	for _, fld := range sb.sc.AllFields() {
 		if fld.typeInfo.PkgPath() != "" {
 			importMap[fld.typeInfo.PkgPath()] = 1
 		}
+
+		if fld.HasGenerator() && fld.GeneratorPkgPath() != "" {
+			importMap[fld.GeneratorPkgPath()] = 1
+		}
+
+		if pkgPath, _ := funcName(fld.defaultFunc); pkgPath != "" {
+			importMap[pkgPath] = 1
+		}
+
+		for _, rule := range fld.rules {
+			if rule.Name() != validation.RuleFunc {
+				continue
+			}
+
+			if pkgPath, _ := funcName(rule.Arg()); pkgPath != "" {
+				importMap[pkgPath] = 1
+			}
+		}
+	}
+
+	for _, edge := range sb.sc.edges {
+		if edge.typeInfo.PkgPath() != "" {
+			importMap[edge.typeInfo.PkgPath()] = 1
+		}
 	}
 
 	imports := []string{sb.sc.typeInfo.PkgPath()}
@@ -73,12 +128,34 @@ func (sb *SchemaBuilder) Build() *Schema {
 		imports = append(imports, imp)
 	}
 
+	// resolve the indexed fields
+	indexes := []*SchemaIndex{}
+	for _, fld := range sb.sc.AllFields() {
+		if fld.unique {
+			indexes = append(indexes, UniqueIndex(fld.name))
+		}
+	}
+
+	for _, index := range sb.sc.indexes {
+		indexes = append(indexes, &SchemaIndex{columns: index.Columns()})
+	}
+
+	for _, index := range indexes {
+		for _, column := range index.columns {
+			index.fields = append(index.fields, sb.sc.Field(column))
+		}
+	}
+
 	return &Schema{
 		typeInfo:   sb.sc.typeInfo,
 		pkgName:    sb.sc.pkgName,
 		collection: sb.sc.collection,
-		identity:   sb.sc.identity,
+		namespace:  sb.sc.namespace,
+		identities: sb.sc.identities,
 		fields:     sb.sc.fields,
+		edges:      sb.sc.edges,
+		indexes:    indexes,
+		tenant:     sb.sc.tenant,
 		imports:    imports,
 		templates:  templates,
 	}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/sf9v/nero"
 )
@@ -30,7 +31,12 @@ func TestSchemaBuilder(t *testing.T) {
 	assert.Equal(t, pkg, schema.PkgName())
 	assert.Equal(t, collection, schema.Collection())
 	assert.NotNil(t, schema.Identity())
+	assert.Len(t, schema.Identities(), 1)
+	assert.False(t, schema.HasCompositeKey())
+	assert.Len(t, schema.AllFields(), 2)
 	assert.Len(t, schema.Fields(), 1)
+	assert.True(t, schema.IsIdentity(schema.Identity()))
+	assert.False(t, schema.IsIdentity(schema.Fields()[0]))
 	assert.Len(t, schema.Imports(), 2)
 	assert.Len(t, schema.Templates(), 2)
 	assert.NotNil(t, schema.TypeInfo())
@@ -42,4 +48,48 @@ func TestSchemaBuilder(t *testing.T) {
 	tmpl := nero.NewPostgresTemplate()
 	schema = schemaBuilder.Templates(tmpl).Build()
 	assert.Len(t, schema.Templates(), 1)
+	assert.Empty(t, schema.Namespace())
+
+	schema = schemaBuilder.Namespace("myschema").Build()
+	assert.Equal(t, "myschema", schema.Namespace())
+
+	// composite key
+	schema = nero.NewSchemaBuilder(ms).
+		PkgName(pkg).Collection(collection).
+		Identity(
+			nero.NewFieldBuilder("id", ms.ID).StructField("ID").Build(),
+			nero.NewFieldBuilder("name", ms.Name).Build(),
+		).
+		Build()
+	assert.True(t, schema.HasCompositeKey())
+	assert.Equal(t, "id", schema.Identity().Name())
+	assert.Len(t, schema.Identities(), 2)
+	assert.Len(t, schema.AllFields(), 2)
+	assert.Empty(t, schema.Fields())
+	assert.NotNil(t, schema.Field("name"))
+	assert.True(t, schema.IsIdentity(schema.Field("name")))
+
+	// indexes
+	schema = nero.NewSchemaBuilder(ms).
+		PkgName(pkg).Collection(collection).
+		Identity(nero.NewFieldBuilder("id", ms.ID).StructField("ID").Build()).
+		Fields(nero.NewFieldBuilder("name", ms.Name).Unique().Build()).
+		Indexes(nero.UniqueIndex("id", "name"), nero.UniqueIndex("name", "unknown")).
+		Build()
+	require.Len(t, schema.Indexes(), 3)
+	assert.Equal(t, []string{"name"}, schema.Indexes()[0].Columns())
+	assert.Equal(t, "Name", schema.Indexes()[0].StructField())
+	assert.Equal(t, "IDName", schema.Indexes()[1].StructField())
+	assert.Nil(t, schema.Indexes()[2].Fields()[1])
+
+	// tenant field
+	schema = nero.NewSchemaBuilder(ms).
+		PkgName(pkg).Collection(collection).
+		Identity(nero.NewFieldBuilder("id", ms.ID).StructField("ID").Build()).
+		TenantField(nero.NewFieldBuilder("name", ms.Name).Build()).
+		Build()
+	require.NotNil(t, schema.TenantField())
+	assert.True(t, schema.TenantField().IsTenant())
+	assert.Equal(t, schema.TenantField(), schema.Field("name"))
+	assert.False(t, schema.Identity().IsTenant())
 }
@@ -4,8 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"strings"
 )
 
+// The maximum number of bind parameters in a statement. The SQLite limit is
+// the default of SQLITE_MAX_VARIABLE_NUMBER since SQLite 3.32.0.
+const (
+	PostgresMaxParams = 65535
+	SQLiteMaxParams   = 32766
+)
+
+// QuoteIdent quotes an sql identifier e.g. a table or a schema name
+func QuoteIdent(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
 // SQLRunner is an interface that wraps the standard sql methods
 type SQLRunner interface {
 	Query(string, ...interface{}) (*sql.Rows, error)
@@ -16,6 +29,57 @@ type SQLRunner interface {
 	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
 }
 
+// DB is an interface that wraps the context-aware sql methods. It is the base
+// handle of the generated repositories and is satisfied by *sql.DB, *sql.Conn,
+// *sql.Tx and most wrappers around them. Transactions are only supported if the
+// handle also implements TxBeginner, or if the handle is itself a transaction.
+type DB interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+// TxBeginner is an interface that wraps the BeginTx method
+type TxBeginner interface {
+	BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+}
+
+// AsSQLRunner returns db as a SQLRunner. If db doesn't implement the
+// non-context methods, they are run with a background context.
+func AsSQLRunner(db DB) SQLRunner {
+	if runner, ok := db.(SQLRunner); ok {
+		return runner
+	}
+
+	return &dbRunner{db}
+}
+
+// dbRunner adapts a DB to a SQLRunner
+type dbRunner struct {
+	DB
+}
+
+func (r *dbRunner) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return r.QueryContext(context.Background(), query, args...)
+}
+
+func (r *dbRunner) QueryRow(query string, args ...interface{}) *sql.Row {
+	return r.QueryRowContext(context.Background(), query, args...)
+}
+
+func (r *dbRunner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return r.ExecContext(context.Background(), query, args...)
+}
+
+// TxRunner is a SQLRunner that runs in a transaction.
+// Transaction wrappers (e.g. for tracing) can implement this
+// interface to be accepted by the generated repositories.
+type TxRunner interface {
+	SQLRunner
+	// Unwrap returns the underlying *sql.Tx
+	Unwrap() *sql.Tx
+}
+
 // ValueScanner is an interface that wraps the driver.Valuer and sql.Scanner interface
 type ValueScanner interface {
 	driver.Valuer
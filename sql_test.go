@@ -0,0 +1,44 @@
+package nero_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sf9v/nero"
+)
+
+func TestAsSQLRunner(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// *sql.DB is already a SQLRunner
+	assert.Equal(t, db, nero.AsSQLRunner(db))
+
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	runner := nero.AsSQLRunner(conn)
+	_, err = runner.Exec("CREATE TABLE items (name TEXT)")
+	require.NoError(t, err)
+
+	rows, err := runner.Query("SELECT name FROM items")
+	require.NoError(t, err)
+	assert.NoError(t, rows.Close())
+
+	var count int
+	err = runner.QueryRow("SELECT COUNT(*) FROM items").Scan(&count)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestQuoteIdent(t *testing.T) {
+	assert.Equal(t, `"players"`, nero.QuoteIdent("players"))
+	assert.Equal(t, `"a""b"`, nero.QuoteIdent(`a"b`))
+}
@@ -27,6 +27,8 @@ func NewFuncMap() template.FuncMap {
 	return template.FuncMap{
 		"type":            typeFunc,
 		"rawType":         rawTypeFunc,
+		"elemType":        elemTypeFunc,
+		"funcCall":        FuncCall,
 		"zeroValue":       zeroValueFunc,
 		"prependToFields": prependToFields,
 		"fileHeaders":     fileHeadersFunc,
@@ -49,6 +51,11 @@ func rawTypeFunc(v interface{}) string {
 	return fmt.Sprintf("%T", v)
 }
 
+// elemTypeFunc returns the element type of an array or a slice
+func elemTypeFunc(v interface{}) string {
+	return resolveType(reflect.TypeOf(v)).Elem().String()
+}
+
 // resolveType resolves the type of the value
 func resolveType(t reflect.Type) reflect.Type {
 	switch t.Kind() {
@@ -29,6 +29,16 @@ func TestFuncs(t *testing.T) {
 		assert.Equal(t, expect, got)
 	})
 
+	t.Run("elemTypeFunc", func(t *testing.T) {
+		got := elemTypeFunc([]string{})
+		expect := "string"
+		assert.Equal(t, expect, got)
+
+		got = elemTypeFunc([1]*myType{})
+		expect = "*nero.myType"
+		assert.Equal(t, expect, got)
+	})
+
 	t.Run("zeroFunc", func(t *testing.T) {
 		got := zeroValueFunc(0)
 		expect := "0"
@@ -0,0 +1,27 @@
+package nero
+
+import "context"
+
+// TenantExtractor returns the tenant ID that's carried by the context.
+// The repositories of the schemas that have a tenant field use
+// TenantFromContext by default.
+type TenantExtractor func(ctx context.Context) (interface{}, error)
+
+// tenantKey is the context key of the tenant ID
+type tenantKey struct{}
+
+// ContextWithTenant returns a copy of ctx that carries the tenant ID
+func ContextWithTenant(ctx context.Context, tenantID interface{}) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ID carried by ctx
+// or ErrNoTenant if there's none
+func TenantFromContext(ctx context.Context) (interface{}, error) {
+	tenantID := ctx.Value(tenantKey{})
+	if tenantID == nil {
+		return nil, ErrNoTenant
+	}
+
+	return tenantID, nil
+}
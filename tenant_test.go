@@ -0,0 +1,19 @@
+package nero_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sf9v/nero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWithTenant(t *testing.T) {
+	ctx := context.Background()
+	_, err := nero.TenantFromContext(ctx)
+	assert.Equal(t, nero.ErrNoTenant, err)
+
+	tenantID, err := nero.TenantFromContext(nero.ContextWithTenant(ctx, "acme"))
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", tenantID)
+}
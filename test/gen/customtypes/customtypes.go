@@ -1,7 +1,11 @@
 package customtypes
 
 import (
+	"errors"
+
 	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/idgen"
+	"github.com/sf9v/nero/validation"
 )
 
 // UUID is a uuid type
@@ -27,11 +31,26 @@ type Custom struct {
 
 // Item is an example struct embedded in Custom struct
 //
-// Note: Custom types like these must implement ValueScanner
+// Note: Custom types like these must implement ValueScanner or be
+// declared as JSON fields
 type Item struct {
 	Name string
 }
 
+// ValidateStr is an example custom validation function
+func ValidateStr(s string) error {
+	if s == "c" {
+		return errors.New("c is not allowed")
+	}
+
+	return nil
+}
+
+// NewMapStrStr is an example default function
+func NewMapStrStr() map[string]string {
+	return map[string]string{}
+}
+
 // Schema implements nero.Schemaer
 func (c Custom) Schema() *nero.Schema {
 	return nero.NewSchemaBuilder(&c).
@@ -41,18 +60,33 @@ func (c Custom) Schema() *nero.Schema {
 				StructField("ID").Auto().Build(),
 		).
 		Fields(
-			nero.NewFieldBuilder("uuid", c.UUID).StructField("UUID").Build(),
-			nero.NewFieldBuilder("str", c.Str).Build(),
-			nero.NewFieldBuilder("map_str_str", c.MapStrStr).Build(),
+			nero.NewFieldBuilder("uuid", c.UUID).StructField("UUID").
+				Generator(idgen.RawUUIDv4).Build(),
+			nero.NewFieldBuilder("str", c.Str).
+				Validate(
+					validation.MinLen(1),
+					validation.Match("^[a-z]+$"),
+					validation.OneOf("a", "b"),
+					validation.Func(ValidateStr),
+				).Build(),
+			nero.NewFieldBuilder("map_str_str", c.MapStrStr).JSON().
+				DefaultFunc(NewMapStrStr).Build(),
 			nero.NewFieldBuilder("map_str_ptr_str", c.MapStrPtrStr).Build(),
 			nero.NewFieldBuilder("map_int64_str", c.MapInt64Str).Build(),
 			nero.NewFieldBuilder("map_int64_ptr_str", c.MapInt64PtrStr).Build(),
 			nero.NewFieldBuilder("map_str_item", c.MapStrItem).Build(),
-			nero.NewFieldBuilder("map_str_ptr_item", c.MapStrPtrItem).Build(),
-			nero.NewFieldBuilder("item", c.Item).Build(),
-			nero.NewFieldBuilder("ptr_item", c.PtrItem).Build(),
+			nero.NewFieldBuilder("map_str_ptr_item", c.MapStrPtrItem).JSON().Build(),
+			nero.NewFieldBuilder("item", c.Item).JSON().Build(),
+			nero.NewFieldBuilder("ptr_item", c.PtrItem).JSON().Build(),
 			nero.NewFieldBuilder("items", c.Items).Build(),
 			nero.NewFieldBuilder("ptr_items", c.PtrItems).Build(),
-			nero.NewFieldBuilder("null_column", c.NullColumn).Build(),
-		).Build()
+			nero.NewFieldBuilder("null_column", c.NullColumn).Default("me@x.io").
+				Validate(validation.MaxLen(10), validation.Email()).Build(),
+		).
+		Templates(
+			nero.NewPostgresTemplate(),
+			nero.NewSQLiteTemplate(),
+			nero.NewPgxTemplate(),
+		).
+		Build()
 }
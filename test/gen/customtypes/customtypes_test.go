@@ -16,7 +16,7 @@ func TestCustomTypes(t *testing.T) {
 	c := customtypes.Custom{}
 	files, err := gen.Generate(c.Schema())
 	require.NoError(t, err)
-	assert.Len(t, files, 7, "should have 7 generated files")
+	assert.Len(t, files, 8, "should have 8 generated files")
 
 	// create base directory
 	basePath := path.Join("customrepo")
@@ -0,0 +1,28 @@
+// Code generated by nero, DO NOT EDIT.
+package friendshiprepo
+
+// Collection is the name of the database collection
+const Collection = "friendships"
+
+// Field is a Friendship field
+type Field int
+
+// String returns the string representation of the field
+func (f Field) String() string {
+	return [...]string{
+		"player_id",
+		"friend_id",
+		"created_at",
+	}[f]
+}
+
+// Column implements comparison.FieldArg
+func (f Field) Column() string {
+	return f.String()
+}
+
+const (
+	FieldPlayerID Field = iota
+	FieldFriendID
+	FieldCreatedAt
+)
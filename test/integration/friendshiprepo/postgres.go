@@ -0,0 +1,1210 @@
+// Code generated by nero, DO NOT EDIT.
+package friendshiprepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/aggregate"
+	"github.com/sf9v/nero/comparison"
+	"github.com/sf9v/nero/sort"
+	"github.com/sf9v/nero/test/integration/player"
+)
+
+// PostgresRepository is a repository that uses PostgreSQL as data store
+type PostgresRepository struct {
+	db            nero.DB
+	logger        nero.Logger
+	debug         bool
+	txFromContext bool
+	replicas      []nero.DB
+	balancer      nero.Balancer
+	schema        string
+}
+
+var _ Repository = (*PostgresRepository)(nil)
+
+// NewPostgresRepository returns a PostgresRepository where db
+// can be a *sql.DB, a *sql.Conn, a *sql.Tx or any nero.DB
+func NewPostgresRepository(db nero.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+// Debug enables debug mode
+func (repo *PostgresRepository) Debug() *PostgresRepository {
+	l := log.New(os.Stdout, "[nero] ", log.LstdFlags|log.Lmicroseconds|log.Lmsgprefix)
+	r := *repo
+	r.debug = true
+	r.logger = l
+	return &r
+}
+
+// WithLogger overrides the default logger
+func (repo *PostgresRepository) WithLogger(logger nero.Logger) *PostgresRepository {
+	repo.logger = logger
+	return repo
+}
+
+// WithTxFromContext enables the tx-from-context mode where the non-Tx methods
+// run in the transaction carried by the context (see nero.ContextWithTx), if any
+func (repo *PostgresRepository) WithTxFromContext() *PostgresRepository {
+	repo.txFromContext = true
+	return repo
+}
+
+// WithReplicas sets the read-only replicas. Query, QueryOne and Aggregate are
+// sent to a replica chosen by the balancer (round-robin by default), while the
+// writes and the queries in a transaction are sent to the primary db.
+func (repo *PostgresRepository) WithReplicas(dbs ...nero.DB) *PostgresRepository {
+	repo.replicas = dbs
+	if repo.balancer == nil {
+		repo.balancer = nero.RoundRobinBalancer()
+	}
+	return repo
+}
+
+// WithBalancer overrides the balancer used for picking the replicas
+func (repo *PostgresRepository) WithBalancer(balancer nero.Balancer) *PostgresRepository {
+	repo.balancer = balancer
+	return repo
+}
+
+// WithSchema returns a copy of the repository that qualifies the tables with the
+// schema e.g. a per-tenant schema on PostgreSQL or an attached database on SQLite
+func (repo *PostgresRepository) WithSchema(schema string) *PostgresRepository {
+	r := *repo
+	r.schema = schema
+	return &r
+}
+
+// table returns the quoted name of the friendships table
+func (repo *PostgresRepository) table() string {
+	return repo.qualify("", "friendships")
+}
+
+// qualify quotes the table name and qualifies it with the schema i.e. "schema"."table".
+// The schema that's set by WithSchema takes precedence over the namespace.
+func (repo *PostgresRepository) qualify(namespace, table string) string {
+	if repo.schema != "" {
+		namespace = repo.schema
+	}
+
+	if namespace == "" {
+		return nero.QuoteIdent(table)
+	}
+
+	return nero.QuoteIdent(namespace) + "." + nero.QuoteIdent(table)
+}
+
+// runner returns the transaction carried by the context when
+// the tx-from-context mode is enabled, otherwise it returns the db
+func (repo *PostgresRepository) runner(ctx context.Context) (nero.SQLRunner, error) {
+	if !repo.txFromContext {
+		return nero.AsSQLRunner(repo.db), nil
+	}
+
+	tx, ok := nero.TxFromContext(ctx)
+	if !ok {
+		return nero.AsSQLRunner(repo.db), nil
+	}
+
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return txx, nil
+}
+
+// readRunner returns a replica chosen by the balancer. It falls back to runner
+// when there are no replicas, when primary is true or when the context carries
+// a transaction in the tx-from-context mode.
+func (repo *PostgresRepository) readRunner(ctx context.Context, primary bool) (nero.SQLRunner, error) {
+	if primary || len(repo.replicas) == 0 || repo.balancer == nil {
+		return repo.runner(ctx)
+	}
+
+	if _, ok := nero.TxFromContext(ctx); ok && repo.txFromContext {
+		return repo.runner(ctx)
+	}
+
+	return nero.AsSQLRunner(repo.balancer(repo.replicas)), nil
+}
+
+// Tx begins a new transaction
+func (repo *PostgresRepository) Tx(ctx context.Context) (nero.Tx, error) {
+	return repo.TxWithOptions(ctx, nil)
+}
+
+// TxWithOptions begins a new transaction with the given options. If the
+// repository's db is already a transaction, a nested transaction is returned.
+func (repo *PostgresRepository) TxWithOptions(ctx context.Context, opts *sql.TxOptions) (nero.Tx, error) {
+	return nero.BeginTx(ctx, repo.db, opts)
+}
+
+// RunInTx runs fn in a transaction. The transaction is committed if fn returns nil
+// and rolled back if fn returns an error or panics. The transaction is retried
+// on serialization failures according to opts, which may be nil.
+func (repo *PostgresRepository) RunInTx(ctx context.Context, opts *nero.TxOptions, fn func(nero.Tx) error) error {
+	return runInTx(ctx, repo.TxWithOptions, repo.isRetryable, opts, fn)
+}
+
+// isRetryable returns true if err is a serialization failure or a deadlock
+func (repo *PostgresRepository) isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+
+	return false
+}
+
+// Create creates a Friendship
+func (repo *PostgresRepository) Create(ctx context.Context, c *Creator) (Key, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return repo.create(ctx, runner, c)
+}
+
+// CreateTx creates a Friendship in a transaction
+func (repo *PostgresRepository) CreateTx(ctx context.Context, tx nero.Tx, c *Creator) (Key, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return Key{}, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.create(ctx, txx, c)
+}
+
+// create inserts a Friendship. If conflict columns are given, the row that has the
+// same values for these columns is updated instead i.e. INSERT ... ON CONFLICT DO UPDATE.
+func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunner, c *Creator, conflict ...string) (Key, error) {
+	if err := c.generate(); err != nil {
+		return Key{}, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return Key{}, err
+	}
+
+	columns := []string{
+		"\"player_id\"",
+		"\"friend_id\"",
+	}
+
+	values := []interface{}{
+		c.playerID,
+		c.friendID,
+	}
+
+	suffix := "RETURNING \"player_id\", \"friend_id\""
+	if len(conflict) > 0 {
+		// the key of the existing row is kept so the rows that reference it
+		// aren't broken and the returned key is the one of the existing row
+		keys := []string{
+			"\"player_id\"",
+			"\"friend_id\"",
+		}
+		sets := []string{}
+		for _, col := range columns {
+			if !contains(conflict, col) && !contains(keys, col) {
+				sets = append(sets, col+" = EXCLUDED."+col)
+			}
+		}
+
+		// the row is only returned if it's updated
+		if len(sets) == 0 {
+			sets = append(sets, conflict[0]+" = EXCLUDED."+conflict[0])
+		}
+
+		onConflict := fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s ", strings.Join(conflict, ", "),
+			strings.Join(sets, ", "))
+		suffix = onConflict + suffix
+	}
+
+	qb := squirrel.Insert(repo.table()).
+		Columns(columns...).
+		Values(values...).
+		Suffix(suffix).
+		PlaceholderFormat(squirrel.Dollar).
+		RunWith(runner)
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Create, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var key Key
+	err := qb.QueryRowContext(ctx).Scan(
+		&key.PlayerID,
+		&key.FriendID,
+	)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return key, nil
+}
+
+// CreateMany batch creates Friendships
+func (repo *PostgresRepository) CreateMany(ctx context.Context, cs ...*Creator) error {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return err
+	}
+
+	return repo.createMany(ctx, runner, cs...)
+}
+
+// CreateManyTx batch creates Friendships in a transaction
+func (repo *PostgresRepository) CreateManyTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.createMany(ctx, txx, cs...)
+}
+
+func (repo *PostgresRepository) createMany(ctx context.Context, runner nero.SQLRunner, cs ...*Creator) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	columns := []string{
+		"\"player_id\"",
+		"\"friend_id\"",
+	}
+
+	// the creators are inserted in chunks so the statements don't exceed the bind parameter limit
+	chunks := chunkCreators(cs, nero.PostgresMaxParams/len(columns))
+	if _, ok := runner.(nero.TxRunner); !ok && len(chunks) > 1 {
+		// the chunks are inserted in a transaction so it's all or nothing
+		return repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+			return repo.CreateManyTx(ctx, tx, cs...)
+		})
+	}
+
+	for _, chunk := range chunks {
+		qb := squirrel.Insert(repo.table()).Columns(columns...)
+		for _, c := range chunk {
+			if err := c.generate(); err != nil {
+				return err
+			}
+
+			if err := c.Validate(); err != nil {
+				return err
+			}
+
+			qb = qb.Values(
+				c.playerID,
+				c.friendID,
+			)
+		}
+
+		qb = qb.Suffix("RETURNING \"player_id\", \"friend_id\"").
+			PlaceholderFormat(squirrel.Dollar)
+		if repo.debug && repo.logger != nil {
+			sql, args, err := qb.ToSql()
+			repo.logger.Printf("method: CreateMany, stmt: %q, args: %v, error: %v", sql, args, err)
+		}
+
+		_, err := qb.RunWith(runner).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BulkInsert inserts Friendships with COPY FROM STDIN, which is faster than CreateMany
+// for a large number of Friendships. It runs in a transaction, which is the one that's
+// carried by the context in the tx-from-context mode.
+func (repo *PostgresRepository) BulkInsert(ctx context.Context, cs ...*Creator) error {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return err
+	}
+
+	if txx, ok := runner.(nero.TxRunner); ok {
+		return repo.bulkInsert(ctx, txx, cs...)
+	}
+
+	return repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+		return repo.BulkInsertTx(ctx, tx, cs...)
+	})
+}
+
+// BulkInsertTx inserts Friendships with COPY FROM STDIN in a transaction
+func (repo *PostgresRepository) BulkInsertTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.bulkInsert(ctx, txx, cs...)
+}
+
+func (repo *PostgresRepository) bulkInsert(ctx context.Context, tx nero.TxRunner, cs ...*Creator) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	columns := []string{
+		"player_id",
+		"friend_id",
+	}
+
+	namespace := ""
+	if repo.schema != "" {
+		namespace = repo.schema
+	}
+
+	query := pq.CopyIn("friendships", columns...)
+	if namespace != "" {
+		query = pq.CopyInSchema(namespace, "friendships", columns...)
+	}
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: BulkInsert, stmt: %q, rows: %d", query, len(cs))
+	}
+
+	stmt, err := tx.Unwrap().PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, c := range cs {
+		if err := c.generate(); err != nil {
+			return err
+		}
+
+		if err := c.Validate(); err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			c.playerID,
+			c.friendID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// flush the buffered rows
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+// Query queries Friendships
+func (repo *PostgresRepository) Query(ctx context.Context, q *Queryer) ([]*player.Friendship, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.query(ctx, runner, q)
+}
+
+// QueryTx queries Friendships in a transaction
+func (repo *PostgresRepository) QueryTx(ctx context.Context, tx nero.Tx, q *Queryer) ([]*player.Friendship, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.query(ctx, txx, q)
+}
+
+func (repo *PostgresRepository) query(ctx context.Context, runner nero.SQLRunner, q *Queryer) ([]*player.Friendship, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Query, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repo.scan(rows)
+}
+
+// QueryOne queries a Friendship
+func (repo *PostgresRepository) QueryOne(ctx context.Context, q *Queryer) (*player.Friendship, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.queryOne(ctx, runner, q)
+}
+
+// QueryOneTx queries a Friendship in a transaction
+func (repo *PostgresRepository) QueryOneTx(ctx context.Context, tx nero.Tx, q *Queryer) (*player.Friendship, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.queryOne(ctx, txx, q)
+}
+
+func (repo *PostgresRepository) queryOne(ctx context.Context, runner nero.SQLRunner, q *Queryer) (*player.Friendship, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: QueryOne, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var friendship player.Friendship
+	err = qb.RunWith(runner).
+		QueryRowContext(ctx).
+		Scan(
+			&friendship.PlayerID,
+			&friendship.FriendID,
+			&friendship.CreatedAt,
+		)
+	if err != nil {
+		return nil, err
+	}
+
+	return &friendship, nil
+}
+
+// Exists checks if there's any Friendship that matches the queryer
+func (repo *PostgresRepository) Exists(ctx context.Context, q *Queryer) (bool, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return false, err
+	}
+
+	return repo.exists(ctx, runner, q)
+}
+
+// ExistsTx checks if there's any Friendship that matches the queryer in a transaction
+func (repo *PostgresRepository) ExistsTx(ctx context.Context, tx nero.Tx, q *Queryer) (bool, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return false, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.exists(ctx, txx, q)
+}
+
+func (repo *PostgresRepository) exists(ctx context.Context, runner nero.SQLRunner, q *Queryer) (bool, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return false, err
+	}
+
+	eb := squirrel.Select().
+		Column(squirrel.Expr("EXISTS (?)", qb.PlaceholderFormat(squirrel.Question))).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := eb.ToSql()
+		repo.logger.Printf("method: Exists, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var exists bool
+	err = eb.RunWith(runner).QueryRowContext(ctx).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Count counts the Friendships that match the queryer
+func (repo *PostgresRepository) Count(ctx context.Context, q *Queryer) (int64, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.count(ctx, runner, q)
+}
+
+// CountTx counts the Friendships that match the queryer in a transaction
+func (repo *PostgresRepository) CountTx(ctx context.Context, tx nero.Tx, q *Queryer) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.count(ctx, txx, q)
+}
+
+// count counts the rows of the select so the limit and
+// the offset of the queryer are taken into account
+func (repo *PostgresRepository) count(ctx context.Context, runner nero.SQLRunner, q *Queryer) (int64, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+
+	eb := squirrel.Select("COUNT(*)").
+		FromSelect(qb.PlaceholderFormat(squirrel.Question), "q").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := eb.ToSql()
+		repo.logger.Printf("method: Count, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var count int64
+	err = eb.RunWith(runner).QueryRowContext(ctx).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo *PostgresRepository) buildSelect(ctx context.Context, q *Queryer) (squirrel.SelectBuilder, error) {
+	qb := squirrel.Select(repo.columns()...).
+		From(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if len(q.distinctOn) > 0 {
+		on := []string{}
+		for _, field := range q.distinctOn {
+			on = append(on, fmt.Sprintf("%q", field.String()))
+		}
+		qb = qb.Options("DISTINCT ON (" + strings.Join(on, ", ") + ")")
+	} else if q.distinct {
+		qb = qb.Distinct()
+	}
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range q.predFuncs {
+		preds = predFunc(preds)
+	}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	sorts := []*sort.Sort{}
+	for _, sortFunc := range q.sortFuncs {
+		sorts = sortFunc(sorts)
+	}
+	qb = repo.buildSort(qb, sorts)
+
+	if q.limit > 0 {
+		qb = qb.Limit(uint64(q.limit))
+	}
+
+	if q.offset > 0 {
+		qb = qb.Offset(uint64(q.offset))
+	}
+
+	if q.forUpdate || q.forShare {
+		lock := "FOR UPDATE"
+		if q.forShare {
+			lock = "FOR SHARE"
+		}
+
+		if q.skipLocked {
+			lock += " SKIP LOCKED"
+		} else if q.noWait {
+			lock += " NOWAIT"
+		}
+
+		qb = qb.Suffix(lock)
+	}
+
+	return qb, nil
+}
+
+func (repo *PostgresRepository) columns() []string {
+	return []string{
+		"\"player_id\"",
+		"\"friend_id\"",
+		"\"created_at\"",
+	}
+}
+
+func (repo *PostgresRepository) scan(rows *sql.Rows) ([]*player.Friendship, error) {
+	friendships := []*player.Friendship{}
+	for rows.Next() {
+		var friendship player.Friendship
+		err := rows.Scan(
+			&friendship.PlayerID,
+			&friendship.FriendID,
+			&friendship.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		friendships = append(friendships, &friendship)
+	}
+
+	return friendships, rows.Err()
+}
+
+func (repo *PostgresRepository) buildPreds(sb squirrel.StatementBuilderType, table string, preds []*comparison.Predicate) squirrel.StatementBuilderType {
+	column := func(field string) string {
+		if table == "" {
+			return fmt.Sprintf("%q", field)
+		}
+		return fmt.Sprintf("%q.%q", table, field)
+	}
+
+	for _, pred := range preds {
+		ph := "?"
+		fieldX, arg := column(pred.Field), pred.Arg
+
+		args := []interface{}{}
+		if fieldY, ok := arg.(comparison.FieldArg); ok { // a field
+			ph = column(fieldY.Column())
+		} else if vals, ok := arg.([]interface{}); ok { // array of values
+			args = append(args, vals...)
+		} else { // single value
+			args = append(args, pgArray(arg))
+		}
+
+		switch pred.Op {
+		case comparison.Eq:
+			sb = sb.Where(fmt.Sprintf("%s = "+ph, fieldX), args...)
+		case comparison.NotEq:
+			sb = sb.Where(fmt.Sprintf("%s <> "+ph, fieldX), args...)
+		case comparison.Gt:
+			sb = sb.Where(fmt.Sprintf("%s > "+ph, fieldX), args...)
+		case comparison.GtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s >= "+ph, fieldX), args...)
+		case comparison.Lt:
+			sb = sb.Where(fmt.Sprintf("%s < "+ph, fieldX), args...)
+		case comparison.LtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s <= "+ph, fieldX), args...)
+		case comparison.IsNull, comparison.IsNotNull:
+			fmtStr := "%s IS NULL"
+			if pred.Op == comparison.IsNotNull {
+				fmtStr = "%s IS NOT NULL"
+			}
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX))
+		case comparison.In, comparison.NotIn:
+			fmtStr := "%s IN (%s)"
+			if pred.Op == comparison.NotIn {
+				fmtStr = "%s NOT IN (%s)"
+			}
+
+			phs := []string{}
+			for range args {
+				phs = append(phs, "?")
+			}
+
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX, strings.Join(phs, ",")), args...)
+		case comparison.PathEq:
+			// the predicates of Has<Edge>With may come from the target schema
+			pv, _ := arg.(*comparison.PathValue)
+			sb = sb.Where(fmt.Sprintf("%s #>> ? = ?", fieldX), pq.Array(strings.Split(pv.Path, ".")), pv.Value)
+		case comparison.Contains:
+			sb = sb.Where(fmt.Sprintf("%s @> ?", fieldX), args...)
+		case comparison.ContainedBy:
+			sb = sb.Where(fmt.Sprintf("%s <@ ?", fieldX), args...)
+		case comparison.Overlap:
+			sb = sb.Where(fmt.Sprintf("%s && ?", fieldX), args...)
+		case comparison.AnyEq:
+			sb = sb.Where(fmt.Sprintf("? = ANY(%s)", fieldX), args...)
+		}
+	}
+
+	return sb
+}
+
+func (repo *PostgresRepository) buildSort(qb squirrel.SelectBuilder, sorts []*sort.Sort) squirrel.SelectBuilder {
+	for _, s := range sorts {
+		field := fmt.Sprintf("%q", s.Field)
+		switch s.Direction {
+		case sort.Asc:
+			qb = qb.OrderBy(field + " ASC")
+		case sort.Desc:
+			qb = qb.OrderBy(field + " DESC")
+		}
+	}
+
+	return qb
+}
+
+// Update updates a Friendship or many Friendships
+func (repo *PostgresRepository) Update(ctx context.Context, u *Updater) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.update(ctx, runner, u)
+}
+
+// UpdateTx updates a Friendship many Friendships in a transaction
+func (repo *PostgresRepository) UpdateTx(ctx context.Context, tx nero.Tx, u *Updater) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.update(ctx, txx, u)
+}
+
+func (repo *PostgresRepository) buildUpdate(ctx context.Context, u *Updater) (squirrel.UpdateBuilder, bool, error) {
+	qb := squirrel.Update(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	cnt := 0
+
+	for _, e := range u.exprs {
+		col := fmt.Sprintf("%q", e.field)
+		if e.delta != nil {
+			qb = qb.Set(col, squirrel.Expr(col+" + ?", e.delta))
+		} else {
+			qb = qb.Set(col, squirrel.Expr(e.expr, e.args...))
+		}
+		cnt++
+	}
+
+	if cnt == 0 {
+		return qb, false, nil
+	}
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range u.predFuncs {
+		preds = predFunc(preds)
+	}
+	qb = squirrel.UpdateBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, true, nil
+}
+
+func (repo *PostgresRepository) update(ctx context.Context, runner nero.SQLRunner, u *Updater) (int64, error) {
+	if err := u.Validate(); err != nil {
+		return 0, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, nil
+	}
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Update, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	res, err := qb.RunWith(runner).ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// UpdateReturning updates a Friendship or many Friendships and returns the updated Friendships
+func (repo *PostgresRepository) UpdateReturning(ctx context.Context, u *Updater) ([]*player.Friendship, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.updateReturning(ctx, runner, u)
+}
+
+// UpdateReturningTx updates a Friendship or many Friendships in a transaction and returns the updated Friendships
+func (repo *PostgresRepository) UpdateReturningTx(ctx context.Context, tx nero.Tx, u *Updater) ([]*player.Friendship, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.updateReturning(ctx, txx, u)
+}
+
+func (repo *PostgresRepository) updateReturning(ctx context.Context, runner nero.SQLRunner, u *Updater) ([]*player.Friendship, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, nil
+	}
+	qb = qb.Suffix("RETURNING " + strings.Join(repo.columns(), ","))
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: UpdateReturning, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repo.scan(rows)
+}
+
+// UpdateMany updates many Friendships with different values in a single statement
+func (repo *PostgresRepository) UpdateMany(ctx context.Context, rus []*RowUpdate) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.updateMany(ctx, runner, rus)
+}
+
+// UpdateManyTx updates many Friendships with different values in a single statement in a transaction
+func (repo *PostgresRepository) UpdateManyTx(ctx context.Context, tx nero.Tx, rus []*RowUpdate) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.updateMany(ctx, txx, rus)
+}
+
+// updateMany updates the Friendships with an UPDATE ... FROM (VALUES ...) statement. The
+// values are preceded by an empty select of the table so they get the types of the columns,
+// and the columns that aren't set by a row update are flagged so they keep their value.
+func (repo *PostgresRepository) updateMany(ctx context.Context, runner nero.SQLRunner, rus []*RowUpdate) (int64, error) {
+	if len(rus) == 0 {
+		return 0, nil
+	}
+
+	for _, ru := range rus {
+		if err := ru.Validate(); err != nil {
+			return 0, err
+		}
+	}
+
+	columns := []string{
+		"\"player_id\"",
+		"\"friend_id\"",
+	}
+	sets := []string{}
+	rows := [][]interface{}{}
+	for _, ru := range rus {
+		rows = append(rows, []interface{}{
+			ru.key.PlayerID,
+			ru.key.FriendID,
+		})
+	}
+
+	if len(sets) == 0 {
+		return 0, nil
+	}
+
+	// the rows are updated in chunks so the statements don't exceed the bind parameter limit
+	size := nero.PostgresMaxParams / (len(rows[0]) + 1)
+	if _, ok := runner.(nero.TxRunner); !ok && len(rows) > size {
+		// the chunks are updated in a transaction so it's all or nothing
+		var rowsAffected int64
+		err := repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+			var err error
+			rowsAffected, err = repo.UpdateManyTx(ctx, tx, rus)
+			return err
+		})
+		return rowsAffected, err
+	}
+
+	var rowsAffected int64
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		values := []string{}
+		args := []interface{}{}
+		for _, row := range rows[start:end] {
+			phs := []string{}
+			for range row {
+				phs = append(phs, "?")
+			}
+
+			values = append(values, "("+strings.Join(phs, ", ")+")")
+			args = append(args, row...)
+		}
+
+		where := []string{
+			"\"friendships\".\"player_id\" = \"v\".\"player_id\"",
+			"\"friendships\".\"friend_id\" = \"v\".\"friend_id\"",
+		}
+		stmt, err := squirrel.Dollar.ReplacePlaceholders(fmt.Sprintf(
+			"UPDATE %s SET %s FROM (SELECT %s FROM %s WHERE false UNION ALL VALUES %s) AS \"v\" WHERE %s",
+			repo.table(), strings.Join(sets, ", "), strings.Join(columns, ", "), repo.table(),
+			strings.Join(values, ", "), strings.Join(where, " AND ")))
+		if repo.debug && repo.logger != nil {
+			repo.logger.Printf("method: UpdateMany, stmt: %q, args: %v, error: %v", stmt, args, err)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		res, err := runner.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected += n
+	}
+
+	return rowsAffected, nil
+}
+
+// Delete deletes a Friendship or many Friendships
+func (repo *PostgresRepository) Delete(ctx context.Context, d *Deleter) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.delete(ctx, runner, d)
+}
+
+// Delete deletes a Friendship or many Friendships in a transaction
+func (repo *PostgresRepository) DeleteTx(ctx context.Context, tx nero.Tx, d *Deleter) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.delete(ctx, txx, d)
+}
+
+func (repo *PostgresRepository) buildDelete(ctx context.Context, d *Deleter) (squirrel.DeleteBuilder, error) {
+	qb := squirrel.Delete(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range d.predFuncs {
+		preds = predFunc(preds)
+	}
+	qb = squirrel.DeleteBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, nil
+}
+
+func (repo *PostgresRepository) delete(ctx context.Context, runner nero.SQLRunner, d *Deleter) (int64, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return 0, err
+	}
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Delete, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	res, err := qb.RunWith(runner).ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// DeleteReturning deletes a Friendship or many Friendships and returns the deleted Friendships
+func (repo *PostgresRepository) DeleteReturning(ctx context.Context, d *Deleter) ([]*player.Friendship, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.deleteReturning(ctx, runner, d)
+}
+
+// DeleteReturningTx deletes a Friendship or many Friendships in a transaction and returns the deleted Friendships
+func (repo *PostgresRepository) DeleteReturningTx(ctx context.Context, tx nero.Tx, d *Deleter) ([]*player.Friendship, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.deleteReturning(ctx, txx, d)
+}
+
+func (repo *PostgresRepository) deleteReturning(ctx context.Context, runner nero.SQLRunner, d *Deleter) ([]*player.Friendship, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	qb = qb.Suffix("RETURNING " + strings.Join(repo.columns(), ","))
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: DeleteReturning, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repo.scan(rows)
+}
+
+// Aggregate runs an aggregate query
+func (repo *PostgresRepository) Aggregate(ctx context.Context, a *Aggregator) error {
+	runner, err := repo.readRunner(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	return repo.aggregate(ctx, runner, a)
+}
+
+// Aggregate runs an aggregate query in a transaction
+func (repo *PostgresRepository) AggregateTx(ctx context.Context, tx nero.Tx, a *Aggregator) error {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.aggregate(ctx, txx, a)
+}
+
+func (repo *PostgresRepository) aggregate(ctx context.Context, runner nero.SQLRunner, a *Aggregator) error {
+	aggs := []*aggregate.Aggregate{}
+	for _, aggFunc := range a.aggFuncs {
+		aggs = aggFunc(aggs)
+	}
+	columns := []string{}
+	for _, agg := range aggs {
+		field := agg.Field
+		qf := fmt.Sprintf("%q", field)
+		switch agg.Op {
+		case aggregate.Avg:
+			columns = append(columns, "AVG("+qf+") avg_"+field)
+		case aggregate.Count:
+			columns = append(columns, "COUNT("+qf+") count_"+field)
+		case aggregate.Max:
+			columns = append(columns, "MAX("+qf+") max_"+field)
+		case aggregate.Min:
+			columns = append(columns, "MIN("+qf+") min_"+field)
+		case aggregate.Sum:
+			columns = append(columns, "SUM("+qf+") sum_"+field)
+		case aggregate.None:
+			columns = append(columns, qf)
+		}
+	}
+
+	qb := squirrel.Select(columns...).From(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	groupBys := []string{}
+	for _, groupBy := range a.groupBys {
+		groupBys = append(groupBys, fmt.Sprintf("%q", groupBy.String()))
+	}
+	qb = qb.GroupBy(groupBys...)
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range a.predFuncs {
+		preds = predFunc(preds)
+	}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	sorts := []*sort.Sort{}
+	for _, sortFunc := range a.sortFuncs {
+		sorts = sortFunc(sorts)
+	}
+	qb = repo.buildSort(qb, sorts)
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Aggregate, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	v := reflect.ValueOf(a.v).Elem()
+	t := reflect.TypeOf(v.Interface()).Elem()
+	if len(columns) != t.NumField() {
+		return errors.Errorf("column count (%v) and destination struct field count (%v) doesn't match", len(columns), t.NumField())
+	}
+
+	for rows.Next() {
+		ve := reflect.New(t).Elem()
+		dest := make([]interface{}, ve.NumField())
+		for i := 0; i < ve.NumField(); i++ {
+			dest[i] = ve.Field(i).Addr().Interface()
+		}
+
+		err = rows.Scan(dest...)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.Append(v, ve))
+	}
+
+	return nil
+}
+
+// pgArray wraps the slices with pq.Array so they're sent as arrays,
+// except the []byte and the driver.Valuer values that are sent as is
+func pgArray(v interface{}) interface{} {
+	if _, ok := v.(driver.Valuer); ok {
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		return pq.Array(v)
+	}
+
+	return v
+}
@@ -5,24 +5,32 @@ import (
 	"os"
 	"path"
 
+	"github.com/sf9v/nero"
 	"github.com/sf9v/nero/gen"
 	"github.com/sf9v/nero/test/integration/player"
 )
 
 func main() {
-	// generate
-	p := player.Player{}
-	files, err := gen.Generate(p.Schema())
-	checkErr(err)
-
-	// create base directory
-	basePath := path.Join("playerrepo")
-	err = os.MkdirAll(basePath, os.ModePerm)
-	checkErr(err)
+	schemaers := []nero.Schemaer{
+		player.Player{},
+		player.Team{},
+		player.Friendship{},
+	}
+	for _, schemaer := range schemaers {
+		// generate
+		schema := schemaer.Schema()
+		files, err := gen.Generate(schema)
+		checkErr(err)
 
-	for _, file := range files {
-		err = file.Render(basePath)
+		// create base directory
+		basePath := path.Join(schema.PkgName())
+		err = os.MkdirAll(basePath, os.ModePerm)
 		checkErr(err)
+
+		for _, file := range files {
+			err = file.Render(basePath)
+			checkErr(err)
+		}
 	}
 }
 
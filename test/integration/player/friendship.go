@@ -0,0 +1,37 @@
+package player
+
+import (
+	"time"
+
+	"github.com/sf9v/nero"
+)
+
+// Friendship is a friendship between two players
+type Friendship struct {
+	PlayerID  string
+	FriendID  string
+	CreatedAt *time.Time
+}
+
+// Schema implements nero.Schemaer
+func (f Friendship) Schema() *nero.Schema {
+	return nero.NewSchemaBuilder(&f).
+		PkgName("friendshiprepo").
+		Collection("friendships").
+		Identity(
+			nero.NewFieldBuilder("player_id", f.PlayerID).
+				StructField("PlayerID").Build(),
+			nero.NewFieldBuilder("friend_id", f.FriendID).
+				StructField("FriendID").Build(),
+		).
+		Fields(
+			nero.NewFieldBuilder("created_at", f.CreatedAt).
+				Auto().Build(),
+		).
+		Templates(
+			nero.NewPostgresTemplate(),
+			nero.NewSQLiteTemplate(),
+			nero.NewPgxTemplate(),
+		).
+		Build()
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/validation"
 )
 
 // Player is a plaer
@@ -13,8 +14,12 @@ type Player struct {
 	Name      string
 	Age       int
 	Race      Race
+	TeamID    *string
 	UpdatedAt *time.Time
 	CreatedAt *time.Time
+
+	Team    *Team
+	Friends []*Player
 }
 
 // Race is the player race
@@ -37,18 +42,31 @@ func (p Player) Schema() *nero.Schema {
 		Identity(nero.NewFieldBuilder("id", p.ID).
 			StructField("ID").Auto().Build()).
 		Fields(
-			nero.NewFieldBuilder("email", p.Email).Build(),
-			nero.NewFieldBuilder("name", p.Name).Build(),
-			nero.NewFieldBuilder("age", p.Age).Build(),
-			nero.NewFieldBuilder("race", p.Race).Build(),
+			nero.NewFieldBuilder("email", p.Email).Unique().
+				Validate(validation.Email()).Build(),
+			nero.NewFieldBuilder("name", p.Name).
+				Validate(validation.MaxLen(50)).Build(),
+			nero.NewFieldBuilder("age", p.Age).Default(18).
+				Validate(validation.Min(0)).Build(),
+			nero.NewFieldBuilder("race", p.Race).
+				Enum(RaceHuman, RaceCharr, RaceNorn, RaceSylvari, RaceTitan).Build(),
+			nero.NewFieldBuilder("team_id", p.TeamID).
+				StructField("TeamID").Optional().Build(),
 			nero.NewFieldBuilder("updated_at", p.UpdatedAt).
 				Optional().Build(),
 			nero.NewFieldBuilder("created_at", p.CreatedAt).
 				Auto().Build(),
 		).
+		Edges(
+			nero.NewEdgeBuilder("team", p.Team).
+				ManyToOne(Team{}, "team_id").Build(),
+			nero.NewEdgeBuilder("friends", p.Friends).
+				ManyToMany(Player{}, "friendships", "player_id", "friend_id").Build(),
+		).
 		Templates(
 			nero.NewPostgresTemplate(),
 			nero.NewSQLiteTemplate(),
+			nero.NewPgxTemplate(),
 		).
 		Build()
 }
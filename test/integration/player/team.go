@@ -0,0 +1,48 @@
+package player
+
+import (
+	"time"
+
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/idgen"
+)
+
+// Team is a team of players
+type Team struct {
+	ID        string
+	Name      string
+	Meta      map[string]string
+	Tags      []string
+	TenantID  string
+	CreatedAt *time.Time
+
+	Players []*Player
+}
+
+// Schema implements nero.Schemaer
+func (t Team) Schema() *nero.Schema {
+	return nero.NewSchemaBuilder(&t).
+		PkgName("teamrepo").
+		Collection("teams").
+		Identity(nero.NewFieldBuilder("id", t.ID).
+			StructField("ID").Generator(idgen.ULID).Build()).
+		Fields(
+			nero.NewFieldBuilder("name", t.Name).Unique().Build(),
+			nero.NewFieldBuilder("meta", t.Meta).JSON().Optional().Build(),
+			nero.NewFieldBuilder("tags", t.Tags).Optional().Build(),
+			nero.NewFieldBuilder("created_at", t.CreatedAt).
+				Auto().Build(),
+		).
+		TenantField(nero.NewFieldBuilder("tenant_id", t.TenantID).
+			StructField("TenantID").Build()).
+		Edges(
+			nero.NewEdgeBuilder("players", t.Players).
+				OneToMany(Player{}, "team_id").Build(),
+		).
+		Templates(
+			nero.NewPostgresTemplate(),
+			nero.NewSQLiteTemplate(),
+			nero.NewPgxTemplate(),
+		).
+		Build()
+}
@@ -5,6 +5,7 @@ package playerrepo_test
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -15,8 +16,10 @@ import (
 
 	"github.com/sf9v/nero"
 	"github.com/sf9v/nero/comparison"
+	"github.com/sf9v/nero/test/integration/friendshiprepo"
 	"github.com/sf9v/nero/test/integration/player"
 	"github.com/sf9v/nero/test/integration/playerrepo"
+	"github.com/sf9v/nero/test/integration/teamrepo"
 )
 
 // test runners
@@ -52,6 +55,12 @@ func newRepoTestRunner(repo playerrepo.Repository) func(t *testing.T) {
 				_, err := repo.Create(ctx, playerrepo.NewCreator())
 				assert.Error(t, err)
 
+				// a required field that's not set
+				_, err = repo.Create(ctx, playerrepo.NewCreator().Email("zero@gg.io").
+					Race(player.RaceHuman))
+				var errRequired *nero.ErrRequiredField
+				assert.True(t, errors.As(err, &errRequired))
+
 				cctx, cancel := context.WithCancel(ctx)
 				cancel()
 				_, err = repo.Create(cctx, playerrepo.NewCreator())
@@ -193,6 +202,61 @@ func newRepoTestRunner(repo playerrepo.Repository) func(t *testing.T) {
 			})
 		})
 
+		t.Run("Distinct", func(t *testing.T) {
+			players, err := repo.Query(ctx, playerrepo.NewQueryer())
+			require.NoError(t, err)
+
+			// the rows are distinct since all the columns are selected
+			distinct, err := repo.Query(ctx, playerrepo.NewQueryer().Distinct())
+			assert.NoError(t, err)
+			assert.Len(t, distinct, len(players))
+
+			count, err := repo.Count(ctx, playerrepo.NewQueryer().Distinct())
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(players)), count)
+		})
+
+		t.Run("ExistsAndCount", func(t *testing.T) {
+			t.Run("Ok", func(t *testing.T) {
+				exists, err := repo.Exists(ctx, playerrepo.NewQueryer().
+					Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.True(t, exists)
+
+				exists, err = repo.Exists(ctx, playerrepo.NewQueryer().
+					Where(playerrepo.IDEq("9999")))
+				assert.NoError(t, err)
+				assert.False(t, exists)
+
+				players, err := repo.Query(ctx, playerrepo.NewQueryer().
+					Where(playerrepo.RaceEq(player.RaceHuman)))
+				require.NoError(t, err)
+				count, err := repo.Count(ctx, playerrepo.NewQueryer().
+					Where(playerrepo.RaceEq(player.RaceHuman)))
+				assert.NoError(t, err)
+				assert.Equal(t, int64(len(players)), count)
+
+				// the limit is taken into account
+				count, err = repo.Count(ctx, playerrepo.NewQueryer().Limit(1))
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), count)
+
+				count, err = repo.Count(ctx, playerrepo.NewQueryer().
+					Where(playerrepo.IDEq("9999")))
+				assert.NoError(t, err)
+				assert.Equal(t, int64(0), count)
+			})
+
+			t.Run("Error", func(t *testing.T) {
+				cctx, cancel := context.WithCancel(ctx)
+				cancel()
+				_, err = repo.Exists(cctx, playerrepo.NewQueryer())
+				assert.Error(t, err)
+				_, err = repo.Count(cctx, playerrepo.NewQueryer())
+				assert.Error(t, err)
+			})
+		})
+
 		t.Run("Aggregate", func(t *testing.T) {
 			t.Run("Ok", func(t *testing.T) {
 				type aggt struct {
@@ -270,6 +334,78 @@ func newRepoTestRunner(repo playerrepo.Repository) func(t *testing.T) {
 				assert.NotNil(t, playr.UpdatedAt)
 			})
 
+			t.Run("Zero and null values", func(t *testing.T) {
+				rowsAffected, err := repo.Update(ctx, playerrepo.NewUpdater().
+					Age(0).ClearUpdatedAt().Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), rowsAffected)
+
+				playr, err := repo.QueryOne(ctx, playerrepo.NewQueryer().
+					Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.Equal(t, 0, playr.Age)
+				assert.Nil(t, playr.UpdatedAt)
+				assert.Equal(t, "titan", playr.Name)
+			})
+
+			t.Run("Expressions", func(t *testing.T) {
+				rowsAffected, err := repo.Update(ctx, playerrepo.NewUpdater().
+					AddAge(5).Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), rowsAffected)
+
+				rowsAffected, err = repo.Update(ctx, playerrepo.NewUpdater().
+					SetExpr(playerrepo.FieldAge, "age * ?", 3).
+					Name("titan").Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), rowsAffected)
+
+				playr, err := repo.QueryOne(ctx, playerrepo.NewQueryer().
+					Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.Equal(t, 15, playr.Age)
+
+				rowsAffected, err = repo.Update(ctx, playerrepo.NewUpdater().
+					AddAge(-15).Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), rowsAffected)
+
+				// the last assignment of a field wins
+				_, err = repo.Update(ctx, playerrepo.NewUpdater().
+					AddAge(5).Age(30).Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+
+				playr, err = repo.QueryOne(ctx, playerrepo.NewQueryer().
+					Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.Equal(t, 30, playr.Age)
+
+				_, err = repo.Update(ctx, playerrepo.NewUpdater().
+					Age(10).SetExpr(playerrepo.FieldAge, "age + ?", 2).
+					AddAge(-30).Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+
+				playr, err = repo.QueryOne(ctx, playerrepo.NewQueryer().
+					Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.Equal(t, 0, playr.Age)
+			})
+
+			t.Run("Returning", func(t *testing.T) {
+				players, err := repo.UpdateReturning(ctx, playerrepo.NewUpdater().
+					Name("titan").Where(playerrepo.IDIn("1", "2")))
+				assert.NoError(t, err)
+				require.Len(t, players, 2)
+				for _, playr := range players {
+					assert.Equal(t, "titan", playr.Name)
+					assert.NotNil(t, playr.CreatedAt)
+				}
+
+				players, err = repo.UpdateReturning(ctx, playerrepo.NewUpdater())
+				assert.NoError(t, err)
+				assert.Empty(t, players)
+			})
+
 			_, err = repo.Update(ctx, playerrepo.NewUpdater())
 			assert.NoError(t, err)
 
@@ -297,10 +433,17 @@ func newRepoTestRunner(repo playerrepo.Repository) func(t *testing.T) {
 				assert.Error(t, err, sql.ErrNoRows)
 				assert.Nil(t, usr)
 
+				// delete returning
+				players, err := repo.DeleteReturning(ctx,
+					playerrepo.NewDeleter().Where(playerrepo.IDEq("2")))
+				assert.NoError(t, err)
+				require.Len(t, players, 1)
+				assert.Equal(t, "2", players[0].ID)
+
 				// delete all
 				rowsAffected, err = repo.Delete(ctx, playerrepo.NewDeleter())
 				assert.NoError(t, err)
-				assert.Equal(t, int64(99), rowsAffected)
+				assert.Equal(t, int64(98), rowsAffected)
 			})
 
 			t.Run("Error", func(t *testing.T) {
@@ -310,6 +453,30 @@ func newRepoTestRunner(repo playerrepo.Repository) func(t *testing.T) {
 				assert.Error(t, err)
 			})
 		})
+
+		t.Run("Upsert", func(t *testing.T) {
+			id, err := repo.UpsertByEmail(ctx, playerrepo.NewCreator().
+				Email("upsert@gg.io").Name("upsert").Age(20).Race(player.RaceHuman))
+			require.NoError(t, err)
+
+			upsertedID, err := repo.UpsertByEmail(ctx, playerrepo.NewCreator().
+				Email("upsert@gg.io").Name("upserted").Age(21).Race(player.RaceNorn))
+			require.NoError(t, err)
+			assert.Equal(t, id, upsertedID)
+
+			playr, err := repo.QueryByEmail(ctx, "upsert@gg.io")
+			require.NoError(t, err)
+			assert.Equal(t, id, playr.ID)
+			assert.Equal(t, "upserted", playr.Name)
+			assert.Equal(t, 21, playr.Age)
+			assert.Equal(t, player.RaceNorn, playr.Race)
+
+			_, err = repo.QueryByEmail(ctx, "none@gg.io")
+			assert.Error(t, err)
+
+			_, err = repo.Delete(ctx, playerrepo.NewDeleter().Where(playerrepo.IDEq(id)))
+			require.NoError(t, err)
+		})
 	}
 }
 
@@ -542,6 +709,34 @@ func newRepoTestRunnerTx(repo playerrepo.Repository) func(t *testing.T) {
 			})
 		})
 
+		t.Run("ExistsAndCountTx", func(t *testing.T) {
+			t.Run("Ok", func(t *testing.T) {
+				tx := newTx(ctx, t)
+				exists, err := repo.ExistsTx(ctx, tx, playerrepo.NewQueryer().
+					Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				assert.True(t, exists)
+
+				players, err := repo.QueryTx(ctx, tx, playerrepo.NewQueryer())
+				require.NoError(t, err)
+				count, err := repo.CountTx(ctx, tx, playerrepo.NewQueryer())
+				assert.NoError(t, err)
+				assert.Equal(t, int64(len(players)), count)
+				assert.NoError(t, tx.Commit())
+			})
+
+			t.Run("Error", func(t *testing.T) {
+				cctx, cancel := context.WithCancel(ctx)
+				tx := newTx(cctx, t)
+				cancel()
+				_, err = repo.ExistsTx(cctx, tx, playerrepo.NewQueryer())
+				assert.Error(t, err)
+				_, err = repo.CountTx(cctx, tx, playerrepo.NewQueryer())
+				assert.Error(t, err)
+				assert.Error(t, tx.Commit())
+			})
+		})
+
 		t.Run("AggregateTx", func(t *testing.T) {
 			t.Run("Ok", func(t *testing.T) {
 				type aggt struct {
@@ -619,6 +814,16 @@ func newRepoTestRunnerTx(repo playerrepo.Repository) func(t *testing.T) {
 				assert.NotNil(t, usr.UpdatedAt)
 			})
 
+			t.Run("Returning", func(t *testing.T) {
+				tx := newTx(ctx, t)
+				players, err := repo.UpdateReturningTx(ctx, tx, playerrepo.NewUpdater().
+					Age(301).Where(playerrepo.IDEq("1")))
+				assert.NoError(t, err)
+				require.Len(t, players, 1)
+				assert.Equal(t, 301, players[0].Age)
+				assert.NoError(t, tx.Rollback())
+			})
+
 			tx := newTx(ctx, t)
 			_, err = repo.UpdateTx(ctx, tx, playerrepo.NewUpdater())
 			assert.NoError(t, err)
@@ -633,6 +838,75 @@ func newRepoTestRunnerTx(repo playerrepo.Repository) func(t *testing.T) {
 			})
 		})
 
+		t.Run("RunInTx", func(t *testing.T) {
+			err := repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+				_, err := repo.UpdateTx(ctx, tx, playerrepo.NewUpdater().
+					Name("committed").Where(playerrepo.IDEq("3")))
+				return err
+			})
+			assert.NoError(t, err)
+
+			err = repo.RunInTx(ctx, &nero.TxOptions{MaxRetries: 3}, func(tx nero.Tx) error {
+				_, err := repo.UpdateTx(ctx, tx, playerrepo.NewUpdater().
+					Name("rolled back").Where(playerrepo.IDEq("3")))
+				if err != nil {
+					return err
+				}
+				return errors.New("an error")
+			})
+			assert.EqualError(t, err, "an error")
+
+			playr, err := repo.QueryOne(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.IDEq("3")))
+			assert.NoError(t, err)
+			assert.Equal(t, "committed", playr.Name)
+		})
+
+		t.Run("Savepoint", func(t *testing.T) {
+			tx, err := repo.TxWithOptions(ctx, &sql.TxOptions{})
+			require.NoError(t, err)
+
+			_, err = repo.UpdateTx(ctx, tx, playerrepo.NewUpdater().
+				Name("saved").Where(playerrepo.IDEq("3")))
+			assert.NoError(t, err)
+			assert.NoError(t, tx.Savepoint("sp1"))
+
+			_, err = repo.UpdateTx(ctx, tx, playerrepo.NewUpdater().
+				Name("discarded").Where(playerrepo.IDEq("3")))
+			assert.NoError(t, err)
+			assert.NoError(t, tx.RollbackTo("sp1"))
+			assert.NoError(t, tx.Release("sp1"))
+			assert.Error(t, tx.RollbackTo("sp1"))
+			assert.NoError(t, tx.Commit())
+
+			playr, err := repo.QueryOne(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.IDEq("3")))
+			assert.NoError(t, err)
+			assert.Equal(t, "saved", playr.Name)
+		})
+
+		t.Run("TxRunner", func(t *testing.T) {
+			// a wrapped transaction is accepted
+			tx, err := repo.Tx(ctx)
+			require.NoError(t, err)
+			sqlTx, ok := tx.(*nero.SQLTx)
+			if !ok {
+				require.NoError(t, tx.Rollback())
+				t.Skip("not a database/sql transaction")
+			}
+
+			wtx := &wrappedTx{SQLTx: sqlTx}
+			players, err := repo.QueryTx(ctx, wtx, playerrepo.NewQueryer().
+				Where(playerrepo.IDEq("3")))
+			assert.NoError(t, err)
+			assert.Len(t, players, 1)
+			assert.NoError(t, wtx.Commit())
+
+			// a transaction that doesn't implement nero.TxRunner is rejected
+			_, err = repo.QueryTx(ctx, &struct{ nero.Tx }{wtx}, playerrepo.NewQueryer())
+			assert.Error(t, err)
+		})
+
 		t.Run("DeleteTx", func(t *testing.T) {
 			t.Run("Ok", func(t *testing.T) {
 				preds := []comparison.PredFunc{
@@ -653,11 +927,20 @@ func newRepoTestRunnerTx(repo playerrepo.Repository) func(t *testing.T) {
 				assert.Nil(t, usr)
 				assert.NoError(t, tx.Commit())
 
+				// delete returning
+				tx = newTx(ctx, t)
+				players, err := repo.DeleteReturningTx(ctx, tx,
+					playerrepo.NewDeleter().Where(playerrepo.IDEq("2")))
+				assert.NoError(t, err)
+				require.Len(t, players, 1)
+				assert.Equal(t, "2", players[0].ID)
+				assert.NoError(t, tx.Commit())
+
 				// delete all
 				tx = newTx(ctx, t)
 				rowsAffected, err = repo.Delete(ctx, playerrepo.NewDeleter())
 				assert.NoError(t, err)
-				assert.Equal(t, int64(99), rowsAffected)
+				assert.Equal(t, int64(98), rowsAffected)
 				assert.NoError(t, tx.Commit())
 			})
 
@@ -672,6 +955,638 @@ func newRepoTestRunnerTx(repo playerrepo.Repository) func(t *testing.T) {
 	}
 }
 
+// wrappedTx is a transaction wrapper e.g. from a middleware
+type wrappedTx struct {
+	*nero.SQLTx
+}
+
+func newDistinctOnTestRunner(repo playerrepo.Repository) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		players, err := repo.Query(ctx, playerrepo.NewQueryer())
+		require.NoError(t, err)
+		races := map[player.Race]bool{}
+		for _, p := range players {
+			races[p.Race] = true
+		}
+
+		// the latest player of each race
+		latest, err := repo.Query(ctx, playerrepo.NewQueryer().
+			DistinctOn(playerrepo.FieldRace).
+			Sort(playerrepo.Asc(playerrepo.FieldRace), playerrepo.Desc(playerrepo.FieldID)))
+		require.NoError(t, err)
+		assert.Len(t, latest, len(races))
+
+		for _, p := range latest {
+			want, err := repo.QueryOne(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.RaceEq(p.Race)).
+				Sort(playerrepo.Desc(playerrepo.FieldID)).Limit(1))
+			require.NoError(t, err)
+			assert.Equal(t, want.ID, p.ID)
+		}
+
+		count, err := repo.Count(ctx, playerrepo.NewQueryer().
+			DistinctOn(playerrepo.FieldRace))
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(races)), count)
+	}
+}
+
+func newBulkTestRunner(repo playerrepo.Repository) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		newCreators := func(prefix string, n int) []*playerrepo.Creator {
+			crs := []*playerrepo.Creator{}
+			for i := 0; i < n; i++ {
+				crs = append(crs, playerrepo.NewCreator().
+					Email(fmt.Sprintf("%s%d@gg.io", prefix, i)).
+					Name(fmt.Sprintf("%s%d", prefix, i)).
+					Age(randomAge()).Race(player.RaceSylvari))
+			}
+			return crs
+		}
+
+		t.Run("CreateManyChunks", func(t *testing.T) {
+			// more than the bind parameter limit of both back-ends allows in a single
+			// statement, 12000 creators of 6 columns exceed nero.PostgresMaxParams
+			err := repo.CreateMany(ctx, newCreators("chunk", 12000)...)
+			require.NoError(t, err)
+
+			players, err := repo.Query(ctx, playerrepo.NewQueryer())
+			require.NoError(t, err)
+			assert.Len(t, players, 12000)
+
+			// an invalid creator in the last chunk rolls back the other chunks
+			crs := append(newCreators("invalid", 12000), playerrepo.NewCreator())
+			err = repo.CreateMany(ctx, crs...)
+			assert.Error(t, err)
+
+			players, err = repo.Query(ctx, playerrepo.NewQueryer())
+			require.NoError(t, err)
+			assert.Len(t, players, 12000)
+
+			_, err = repo.Delete(ctx, playerrepo.NewDeleter())
+			require.NoError(t, err)
+		})
+
+		t.Run("BulkInsert", func(t *testing.T) {
+			err := repo.BulkInsert(ctx, newCreators("bulk", 1000)...)
+			require.NoError(t, err)
+
+			players, err := repo.Query(ctx, playerrepo.NewQueryer())
+			require.NoError(t, err)
+			assert.Len(t, players, 1000)
+
+			err = repo.BulkInsert(ctx)
+			assert.NoError(t, err)
+
+			// an invalid creator rolls back the others
+			crs := append(newCreators("invalid", 10), playerrepo.NewCreator())
+			err = repo.BulkInsert(ctx, crs...)
+			assert.Error(t, err)
+
+			players, err = repo.Query(ctx, playerrepo.NewQueryer())
+			require.NoError(t, err)
+			assert.Len(t, players, 1000)
+
+			_, err = repo.Delete(ctx, playerrepo.NewDeleter())
+			require.NoError(t, err)
+		})
+
+		t.Run("BulkInsertTx", func(t *testing.T) {
+			tx, err := repo.Tx(ctx)
+			require.NoError(t, err)
+			err = repo.BulkInsertTx(ctx, tx, newCreators("bulktx", 10)...)
+			require.NoError(t, err)
+
+			players, err := repo.QueryTx(ctx, tx, playerrepo.NewQueryer())
+			require.NoError(t, err)
+			assert.Len(t, players, 10)
+			require.NoError(t, tx.Rollback())
+
+			players, err = repo.Query(ctx, playerrepo.NewQueryer())
+			require.NoError(t, err)
+			assert.Len(t, players, 0)
+		})
+
+		t.Run("UpdateMany", func(t *testing.T) {
+			err := repo.CreateMany(ctx, newCreators("many", 3)...)
+			require.NoError(t, err)
+
+			players, err := repo.Query(ctx, playerrepo.NewQueryer().
+				Sort(playerrepo.Asc(playerrepo.FieldID)))
+			require.NoError(t, err)
+			require.Len(t, players, 3)
+
+			// each player gets its own values and the fields
+			// that aren't set by a row update are left as is
+			teamID := "01F4JKK4000000000000000000"
+			rowsAffected, err := repo.UpdateMany(ctx, []*playerrepo.RowUpdate{
+				playerrepo.NewRowUpdate(players[0].ID).Name("many0 updated").TeamID(&teamID),
+				playerrepo.NewRowUpdate(players[1].ID).Age(99),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), rowsAffected)
+
+			updated, err := repo.Query(ctx, playerrepo.NewQueryer().
+				Sort(playerrepo.Asc(playerrepo.FieldID)))
+			require.NoError(t, err)
+			require.Len(t, updated, 3)
+			assert.Equal(t, "many0 updated", updated[0].Name)
+			assert.Equal(t, players[0].Age, updated[0].Age)
+			require.NotNil(t, updated[0].TeamID)
+			assert.Equal(t, teamID, *updated[0].TeamID)
+			assert.Equal(t, players[1].Name, updated[1].Name)
+			assert.Equal(t, 99, updated[1].Age)
+			assert.Nil(t, updated[1].TeamID)
+			assert.Equal(t, players[2], updated[2])
+
+			rowsAffected, err = repo.UpdateMany(ctx, []*playerrepo.RowUpdate{
+				playerrepo.NewRowUpdate(players[0].ID).ClearTeamID(),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), rowsAffected)
+
+			rowsAffected, err = repo.UpdateMany(ctx, nil)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), rowsAffected)
+
+			// the updates are rolled back with the transaction
+			tx, err := repo.Tx(ctx)
+			require.NoError(t, err)
+			_, err = repo.UpdateManyTx(ctx, tx, []*playerrepo.RowUpdate{
+				playerrepo.NewRowUpdate(players[2].ID).Name("many2 updated"),
+			})
+			require.NoError(t, err)
+			require.NoError(t, tx.Rollback())
+
+			updated, err = repo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.IDEq(players[2].ID)))
+			require.NoError(t, err)
+			require.Len(t, updated, 1)
+			assert.Equal(t, players[2].Name, updated[0].Name)
+
+			_, err = repo.Delete(ctx, playerrepo.NewDeleter())
+			require.NoError(t, err)
+		})
+	}
+}
+
+func newEdgeTestRunner(db *sql.DB, playerRepo playerrepo.Repository, teamRepo teamrepo.Repository) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := nero.ContextWithTenant(context.Background(), "acme")
+
+		// the team id is generated
+		teamID, err := teamRepo.Create(ctx, teamrepo.NewCreator().Name("Edge"))
+		require.NoError(t, err)
+		assert.Len(t, teamID, 26)
+
+		// or supplied by the client
+		otherID, err := teamRepo.Create(ctx, teamrepo.NewCreator().
+			ID("01F4JKK4000000000000000000").Name("Other"))
+		require.NoError(t, err)
+		assert.Equal(t, "01F4JKK4000000000000000000", otherID)
+
+		// edge0 and edge1 are in the team and are friends of edge2
+		ids := []string{}
+		for i := 0; i < 3; i++ {
+			c := playerrepo.NewCreator().Email(fmt.Sprintf("edge%d@gg.io", i)).
+				Name(fmt.Sprintf("edge%d", i)).Age(randomAge()).Race(player.RaceNorn)
+			if i < 2 {
+				c = c.TeamID(&teamID)
+			}
+
+			id, err := playerRepo.Create(ctx, c)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+
+		for _, id := range ids[:2] {
+			_, err = db.Exec(fmt.Sprintf("INSERT INTO friendships (player_id, friend_id) VALUES (%s, %s)", ids[2], id))
+			require.NoError(t, err)
+		}
+
+		t.Run("ManyToOne", func(t *testing.T) {
+			players, err := playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Sort(playerrepo.Asc(playerrepo.FieldID)).WithTeam())
+			require.NoError(t, err)
+			require.Len(t, players, 3)
+			require.NotNil(t, players[0].Team)
+			assert.Equal(t, teamID, players[0].Team.ID)
+			assert.Equal(t, "Edge", players[1].Team.Name)
+			assert.Nil(t, players[2].Team)
+
+			// not loaded
+			players, err = playerRepo.Query(ctx, playerrepo.NewQueryer())
+			require.NoError(t, err)
+			assert.Nil(t, players[0].Team)
+		})
+
+		t.Run("OneToMany", func(t *testing.T) {
+			team, err := teamRepo.QueryOne(ctx, teamrepo.NewQueryer().
+				Where(teamrepo.IDEq(teamID)).WithPlayers())
+			require.NoError(t, err)
+			require.Len(t, team.Players, 2)
+			assert.Equal(t, teamID, *team.Players[0].TeamID)
+		})
+
+		t.Run("ManyToMany", func(t *testing.T) {
+			players, err := playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Sort(playerrepo.Asc(playerrepo.FieldID)).WithFriends().WithTeam())
+			require.NoError(t, err)
+			require.Len(t, players, 3)
+			assert.Len(t, players[0].Friends, 0)
+			require.Len(t, players[2].Friends, 2)
+			assert.Equal(t, "edge0", players[2].Friends[0].Name)
+			assert.Equal(t, "edge1", players[2].Friends[1].Name)
+		})
+
+		t.Run("Has", func(t *testing.T) {
+			players, err := playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasTeam()))
+			require.NoError(t, err)
+			assert.Len(t, players, 2)
+
+			players, err = playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasTeamWith(teamrepo.NameEq("Edge"))))
+			require.NoError(t, err)
+			assert.Len(t, players, 2)
+
+			players, err = playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasTeamWith(teamrepo.NameEq("Other"))))
+			require.NoError(t, err)
+			assert.Len(t, players, 0)
+
+			players, err = playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasFriendsWith(playerrepo.NameEq("edge0"))))
+			require.NoError(t, err)
+			require.Len(t, players, 1)
+			assert.Equal(t, ids[2], players[0].ID)
+
+			teams, err := teamRepo.Query(ctx, teamrepo.NewQueryer().
+				Where(teamrepo.HasPlayersWith(playerrepo.NameEq("edge1"))))
+			require.NoError(t, err)
+			assert.Len(t, teams, 1)
+
+			// the columns are qualified with the edge, created_at is in both
+			// players and friendships
+			players, err = playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasFriendsWith(playerrepo.CreatedAtIsNotNull())))
+			require.NoError(t, err)
+			require.Len(t, players, 1)
+			assert.Equal(t, ids[2], players[0].ID)
+
+			// the fields of the target
+			players, err = playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasTeamWith(teamrepo.FieldXEqFieldY(teamrepo.FieldName, teamrepo.FieldName))))
+			require.NoError(t, err)
+			assert.Len(t, players, 2)
+
+			players, err = playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasTeamWith(teamrepo.FieldXNotEqFieldY(teamrepo.FieldName, teamrepo.FieldName))))
+			require.NoError(t, err)
+			assert.Len(t, players, 0)
+
+			// nested
+			players, err = playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasFriendsWith(playerrepo.HasTeamWith(teamrepo.NameEq("Edge")))))
+			require.NoError(t, err)
+			require.Len(t, players, 1)
+			assert.Equal(t, ids[2], players[0].ID)
+
+			players, err = playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasFriendsWith(playerrepo.HasFriends())))
+			require.NoError(t, err)
+			assert.Len(t, players, 0)
+
+			teams, err = teamRepo.Query(ctx, teamrepo.NewQueryer().
+				Where(teamrepo.HasPlayersWith(playerrepo.HasTeamWith(teamrepo.NameEq("Edge")))))
+			require.NoError(t, err)
+			require.Len(t, teams, 1)
+			assert.Equal(t, teamID, teams[0].ID)
+
+			// in an update
+			rowsAffected, err := playerRepo.Update(ctx, playerrepo.NewUpdater().
+				Age(99).Where(playerrepo.HasTeamWith(teamrepo.NameEq("Edge"))))
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), rowsAffected)
+		})
+
+		t.Run("CrossTenant", func(t *testing.T) {
+			// a player that references the team of another tenant
+			rival := nero.ContextWithTenant(context.Background(), "rival")
+			rivalID, err := teamRepo.Create(rival, teamrepo.NewCreator().Name("Rival"))
+			require.NoError(t, err)
+			id, err := playerRepo.Create(ctx, playerrepo.NewCreator().Email("edge3@gg.io").
+				Name("edge3").Age(randomAge()).Race(player.RaceNorn).TeamID(&rivalID))
+			require.NoError(t, err)
+
+			playr, err := playerRepo.QueryOne(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.IDEq(id)).WithTeam())
+			require.NoError(t, err)
+			assert.Nil(t, playr.Team)
+
+			players, err := playerRepo.Query(ctx, playerrepo.NewQueryer().
+				Where(playerrepo.HasTeamWith(teamrepo.NameEq("Rival"))))
+			require.NoError(t, err)
+			assert.Len(t, players, 0)
+
+			// in the tenant of the team
+			playr, err = playerRepo.QueryOne(rival, playerrepo.NewQueryer().
+				Where(playerrepo.IDEq(id)).WithTeam())
+			require.NoError(t, err)
+			require.NotNil(t, playr.Team)
+			assert.Equal(t, rivalID, playr.Team.ID)
+
+			players, err = playerRepo.Query(rival, playerrepo.NewQueryer().
+				Where(playerrepo.HasTeamWith(teamrepo.NameEq("Rival"))))
+			require.NoError(t, err)
+			assert.Len(t, players, 1)
+
+			// the nested edges are scoped too
+			players, err = playerRepo.Query(rival, playerrepo.NewQueryer().
+				Where(playerrepo.HasFriendsWith(playerrepo.HasTeamWith(teamrepo.NameEq("Edge")))))
+			require.NoError(t, err)
+			assert.Len(t, players, 0)
+
+			// the tenant is required by the edges to the tenant-scoped teams
+			_, err = playerRepo.Query(context.Background(), playerrepo.NewQueryer().WithTeam())
+			assert.True(t, errors.Is(err, nero.ErrNoTenant))
+			_, err = playerRepo.Query(context.Background(), playerrepo.NewQueryer().
+				Where(playerrepo.HasTeam()))
+			assert.True(t, errors.Is(err, nero.ErrNoTenant))
+			_, err = playerRepo.Query(context.Background(), playerrepo.NewQueryer().
+				Where(playerrepo.HasFriendsWith(playerrepo.HasTeam())))
+			assert.True(t, errors.Is(err, nero.ErrNoTenant))
+		})
+	}
+}
+
+func newCompositeKeyTestRunner(repo friendshiprepo.Repository) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+
+		key, err := repo.Create(ctx, friendshiprepo.NewCreator().
+			PlayerID("100").FriendID("200"))
+		require.NoError(t, err)
+		assert.Equal(t, friendshiprepo.Key{PlayerID: "100", FriendID: "200"}, key)
+
+		// duplicate key
+		_, err = repo.Create(ctx, friendshiprepo.NewCreator().
+			PlayerID("100").FriendID("200"))
+		assert.Error(t, err)
+
+		// all key fields are required
+		_, err = repo.Create(ctx, friendshiprepo.NewCreator().PlayerID("100"))
+		assert.Error(t, err)
+
+		friendship, err := repo.QueryOne(ctx, friendshiprepo.NewQueryer().
+			Where(friendshiprepo.KeyEq(key)))
+		require.NoError(t, err)
+		assert.Equal(t, "100", friendship.PlayerID)
+		assert.Equal(t, "200", friendship.FriendID)
+		assert.NotNil(t, friendship.CreatedAt)
+
+		rowsAffected, err := repo.Delete(ctx, friendshiprepo.NewDeleter().
+			Where(friendshiprepo.KeyEq(key)))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rowsAffected)
+	}
+}
+
+func newJSONTestRunner(repo teamrepo.Repository) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := nero.ContextWithTenant(context.Background(), "acme")
+
+		id, err := repo.Create(ctx, teamrepo.NewCreator().Name("JSON").
+			Meta(map[string]string{"region": "eu"}))
+		require.NoError(t, err)
+
+		team, err := repo.QueryOne(ctx, teamrepo.NewQueryer().
+			Where(teamrepo.IDEq(id)))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"region": "eu"}, team.Meta)
+
+		_, err = repo.Update(ctx, teamrepo.NewUpdater().
+			Meta(map[string]string{"region": "na"}).Where(teamrepo.IDEq(id)))
+		require.NoError(t, err)
+
+		team, err = repo.QueryOne(ctx, teamrepo.NewQueryer().
+			Where(teamrepo.IDEq(id)))
+		require.NoError(t, err)
+		assert.Equal(t, "na", team.Meta["region"])
+
+		// null meta
+		id, err = repo.Create(ctx, teamrepo.NewCreator().Name("No meta"))
+		require.NoError(t, err)
+
+		team, err = repo.QueryOne(ctx, teamrepo.NewQueryer().
+			Where(teamrepo.IDEq(id)))
+		require.NoError(t, err)
+		assert.Nil(t, team.Meta)
+	}
+}
+
+func newArrayTestRunner(repo teamrepo.Repository) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := nero.ContextWithTenant(context.Background(), "acme")
+
+		id, err := repo.Create(ctx, teamrepo.NewCreator().Name("Array").
+			Tags([]string{"eu", "pro"}))
+		require.NoError(t, err)
+
+		team, err := repo.QueryOne(ctx, teamrepo.NewQueryer().
+			Where(teamrepo.IDEq(id)))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"eu", "pro"}, team.Tags)
+
+		_, err = repo.Update(ctx, teamrepo.NewUpdater().
+			Tags([]string{"na"}).Where(teamrepo.IDEq(id)))
+		require.NoError(t, err)
+
+		team, err = repo.QueryOne(ctx, teamrepo.NewQueryer().
+			Where(teamrepo.IDEq(id)))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"na"}, team.Tags)
+
+		// bulk insert
+		err = repo.CreateMany(ctx, teamrepo.NewCreator().Name("Array many").
+			Tags([]string{"asia"}))
+		require.NoError(t, err)
+
+		team, err = repo.QueryOne(ctx, teamrepo.NewQueryer().
+			Where(teamrepo.NameEq("Array many")))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"asia"}, team.Tags)
+
+		// null tags
+		id, err = repo.Create(ctx, teamrepo.NewCreator().Name("No tags"))
+		require.NoError(t, err)
+
+		team, err = repo.QueryOne(ctx, teamrepo.NewQueryer().
+			Where(teamrepo.IDEq(id)))
+		require.NoError(t, err)
+		assert.Nil(t, team.Tags)
+	}
+}
+
+func newArrayPredTestRunner(repo teamrepo.Repository) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := nero.ContextWithTenant(context.Background(), "acme")
+
+		euID, err := repo.Create(ctx, teamrepo.NewCreator().Name("Tagged EU").
+			Tags([]string{"eu", "pro"}))
+		require.NoError(t, err)
+		naID, err := repo.Create(ctx, teamrepo.NewCreator().Name("Tagged NA").
+			Tags([]string{"na"}))
+		require.NoError(t, err)
+
+		queryIDs := func(predFuncs ...comparison.PredFunc) []string {
+			// only the teams of this runner
+			predFuncs = append(predFuncs, teamrepo.IDIn(euID, naID))
+			teams, err := repo.Query(ctx, teamrepo.NewQueryer().
+				Where(predFuncs...).Sort(teamrepo.Asc(teamrepo.FieldName)))
+			require.NoError(t, err)
+
+			ids := []string{}
+			for _, team := range teams {
+				ids = append(ids, team.ID)
+			}
+			return ids
+		}
+
+		// @>
+		assert.Equal(t, []string{euID}, queryIDs(teamrepo.TagsContains([]string{"pro", "eu"})))
+		assert.Empty(t, queryIDs(teamrepo.TagsContains([]string{"eu", "na"})))
+
+		// <@
+		assert.Equal(t, []string{naID}, queryIDs(teamrepo.TagsContainedBy([]string{"na", "asia"})))
+		assert.Equal(t, []string{euID, naID}, queryIDs(teamrepo.TagsContainedBy([]string{"eu", "na", "pro"})))
+
+		// &&
+		assert.Equal(t, []string{euID, naID}, queryIDs(teamrepo.TagsOverlap([]string{"pro", "na"})))
+		assert.Empty(t, queryIDs(teamrepo.TagsOverlap([]string{"asia"})))
+
+		// = ANY
+		assert.Equal(t, []string{euID}, queryIDs(teamrepo.TagsAnyEq("pro")))
+		assert.Equal(t, []string{naID}, queryIDs(teamrepo.TagsAnyEq("na"),
+			teamrepo.TagsContainedBy([]string{"na"})))
+
+		_, err = repo.Delete(ctx, teamrepo.NewDeleter().
+			Where(teamrepo.IDIn(euID, naID)))
+		require.NoError(t, err)
+	}
+}
+
+func newTeamUpsertTestRunner(repo teamrepo.Repository) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := nero.ContextWithTenant(context.Background(), "acme")
+
+		// the id of the existing team is kept on conflict
+		// even if a new one is generated for the creator
+		id, err := repo.UpsertByName(ctx, teamrepo.NewCreator().Name("Upsert"))
+		require.NoError(t, err)
+
+		upsertedID, err := repo.UpsertByName(ctx, teamrepo.NewCreator().
+			Name("Upsert").Meta(map[string]string{"region": "eu"}))
+		require.NoError(t, err)
+		assert.Equal(t, id, upsertedID)
+
+		// or supplied by the client
+		upsertedID, err = repo.UpsertByName(ctx, teamrepo.NewCreator().
+			ID("01F4JKK4000000000000000001").Name("Upsert"))
+		require.NoError(t, err)
+		assert.Equal(t, id, upsertedID)
+
+		team, err := repo.QueryByName(ctx, "Upsert")
+		require.NoError(t, err)
+		assert.Equal(t, id, team.ID)
+		assert.Equal(t, "eu", team.Meta["region"])
+
+		_, err = repo.Delete(ctx, teamrepo.NewDeleter().Where(teamrepo.IDEq(id)))
+		require.NoError(t, err)
+	}
+}
+
+func newTenantTestRunner(repo teamrepo.Repository) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		red := nero.ContextWithTenant(ctx, "red")
+		blue := nero.ContextWithTenant(ctx, "blue")
+
+		// the tenant is required
+		_, err := repo.Create(ctx, teamrepo.NewCreator().Name("None"))
+		assert.True(t, errors.Is(err, nero.ErrNoTenant))
+		_, err = repo.Query(ctx, teamrepo.NewQueryer())
+		assert.True(t, errors.Is(err, nero.ErrNoTenant))
+
+		redID, err := repo.Create(red, teamrepo.NewCreator().Name("Red"))
+		require.NoError(t, err)
+		err = repo.CreateMany(blue, teamrepo.NewCreator().Name("Blue"))
+		require.NoError(t, err)
+
+		teams, err := repo.Query(red, teamrepo.NewQueryer())
+		require.NoError(t, err)
+		require.Len(t, teams, 1)
+		assert.Equal(t, "Red", teams[0].Name)
+		assert.Equal(t, "red", teams[0].TenantID)
+
+		// the rows of the other tenants are left as is
+		rowsAffected, err := repo.Update(blue, teamrepo.NewUpdater().
+			Name("Stolen").Where(teamrepo.IDEq(redID)))
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), rowsAffected)
+
+		rowsAffected, err = repo.Delete(blue, teamrepo.NewDeleter().
+			Where(teamrepo.IDEq(redID)))
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), rowsAffected)
+
+		exists, err := repo.Exists(blue, teamrepo.NewQueryer().
+			Where(teamrepo.IDEq(redID)))
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		count, err := repo.Count(blue, teamrepo.NewQueryer())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		type aggt struct {
+			CountID int
+		}
+		agg := []aggt{}
+		err = repo.Aggregate(blue, teamrepo.NewAggregator(&agg).
+			Aggregate(teamrepo.Count(teamrepo.FieldID)))
+		require.NoError(t, err)
+		require.Len(t, agg, 1)
+		assert.Equal(t, 1, agg[0].CountID)
+
+		// the unique names are per tenant
+		blueID, err := repo.UpsertByName(blue, teamrepo.NewCreator().Name("Red"))
+		require.NoError(t, err)
+		assert.NotEqual(t, redID, blueID)
+
+		upsertedID, err := repo.UpsertByName(red, teamrepo.NewCreator().
+			Name("Red").Meta(map[string]string{"region": "eu"}))
+		require.NoError(t, err)
+		assert.Equal(t, redID, upsertedID)
+
+		team, err := repo.QueryByName(blue, "Red")
+		require.NoError(t, err)
+		assert.Equal(t, blueID, team.ID)
+		assert.Empty(t, team.Meta)
+
+		// the tenant can't be changed
+		_, err = repo.Update(red, teamrepo.NewUpdater().
+			SetExpr(teamrepo.FieldTenantID, "?", "blue"))
+		assert.Error(t, err)
+
+		rowsAffected, err = repo.Delete(red, teamrepo.NewDeleter())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rowsAffected)
+	}
+}
+
 func randomAge() int {
 	return rand.Intn(30-18) + 18
 }
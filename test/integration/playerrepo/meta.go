@@ -15,17 +15,24 @@ func (f Field) String() string {
 		"name",
 		"age",
 		"race",
+		"team_id",
 		"updated_at",
 		"created_at",
 	}[f]
 }
 
+// Column implements comparison.FieldArg
+func (f Field) Column() string {
+	return f.String()
+}
+
 const (
 	FieldID Field = iota
 	FieldEmail
 	FieldName
 	FieldAge
 	FieldRace
+	FieldTeamID
 	FieldUpdatedAt
 	FieldCreatedAt
 )
@@ -4,6 +4,7 @@ package playerrepo
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"log"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/sf9v/nero"
 	"github.com/sf9v/nero/aggregate"
@@ -21,26 +23,30 @@ import (
 
 // PostgresRepository is a repository that uses PostgreSQL as data store
 type PostgresRepository struct {
-	db     *sql.DB
-	logger nero.Logger
-	debug  bool
+	db            nero.DB
+	logger        nero.Logger
+	debug         bool
+	txFromContext bool
+	replicas      []nero.DB
+	balancer      nero.Balancer
+	schema        string
 }
 
 var _ Repository = (*PostgresRepository)(nil)
 
-// NewPostgresRepository returns a PostgresRepository
-func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+// NewPostgresRepository returns a PostgresRepository where db
+// can be a *sql.DB, a *sql.Conn, a *sql.Tx or any nero.DB
+func NewPostgresRepository(db nero.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
 // Debug enables debug mode
 func (repo *PostgresRepository) Debug() *PostgresRepository {
 	l := log.New(os.Stdout, "[nero] ", log.LstdFlags|log.Lmicroseconds|log.Lmsgprefix)
-	return &PostgresRepository{
-		db:     repo.db,
-		debug:  true,
-		logger: l,
-	}
+	r := *repo
+	r.debug = true
+	r.logger = l
+	return &r
 }
 
 // WithLogger overrides the default logger
@@ -49,27 +55,193 @@ func (repo *PostgresRepository) WithLogger(logger nero.Logger) *PostgresReposito
 	return repo
 }
 
+// WithTxFromContext enables the tx-from-context mode where the non-Tx methods
+// run in the transaction carried by the context (see nero.ContextWithTx), if any
+func (repo *PostgresRepository) WithTxFromContext() *PostgresRepository {
+	repo.txFromContext = true
+	return repo
+}
+
+// WithReplicas sets the read-only replicas. Query, QueryOne and Aggregate are
+// sent to a replica chosen by the balancer (round-robin by default), while the
+// writes and the queries in a transaction are sent to the primary db.
+func (repo *PostgresRepository) WithReplicas(dbs ...nero.DB) *PostgresRepository {
+	repo.replicas = dbs
+	if repo.balancer == nil {
+		repo.balancer = nero.RoundRobinBalancer()
+	}
+	return repo
+}
+
+// WithBalancer overrides the balancer used for picking the replicas
+func (repo *PostgresRepository) WithBalancer(balancer nero.Balancer) *PostgresRepository {
+	repo.balancer = balancer
+	return repo
+}
+
+// WithSchema returns a copy of the repository that qualifies the tables with the
+// schema e.g. a per-tenant schema on PostgreSQL or an attached database on SQLite
+func (repo *PostgresRepository) WithSchema(schema string) *PostgresRepository {
+	r := *repo
+	r.schema = schema
+	return &r
+}
+
+// table returns the quoted name of the players table
+func (repo *PostgresRepository) table() string {
+	return repo.qualify("", "players")
+}
+
+// qualify quotes the table name and qualifies it with the schema i.e. "schema"."table".
+// The schema that's set by WithSchema takes precedence over the namespace.
+func (repo *PostgresRepository) qualify(namespace, table string) string {
+	if repo.schema != "" {
+		namespace = repo.schema
+	}
+
+	if namespace == "" {
+		return nero.QuoteIdent(table)
+	}
+
+	return nero.QuoteIdent(namespace) + "." + nero.QuoteIdent(table)
+}
+
+// edgeTenant returns the tenant ID that scopes the edges to the tenant-scoped schemas
+func (repo *PostgresRepository) edgeTenant(ctx context.Context) (interface{}, error) {
+	return nero.TenantFromContext(ctx)
+}
+
+// scopeEdges appends the predicate of the tenant to the Has<Edge>With
+// predicates of the tenant-scoped targets, including the nested ones
+func (repo *PostgresRepository) scopeEdges(ctx context.Context, preds []*comparison.Predicate) ([]*comparison.Predicate, error) {
+	scoped := make([]*comparison.Predicate, 0, len(preds))
+	for _, pred := range preds {
+		edge, ok := pred.Arg.(*comparison.Edge)
+		if pred.Op != comparison.Exists || !ok {
+			scoped = append(scoped, pred)
+			continue
+		}
+
+		edgePreds, err := repo.scopeEdges(ctx, edge.Preds)
+		if err != nil {
+			return nil, err
+		}
+
+		if edge.TenantField != "" {
+			tenantID, err := repo.edgeTenant(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			edgePreds = append(edgePreds, &comparison.Predicate{
+				Field: edge.TenantField, Op: comparison.Eq, Arg: tenantID,
+			})
+		}
+
+		scopedEdge := *edge
+		scopedEdge.Preds = edgePreds
+		scoped = append(scoped, &comparison.Predicate{Field: pred.Field, Op: pred.Op, Arg: &scopedEdge})
+	}
+
+	return scoped, nil
+}
+
+// scope appends the predicate of the tenant to the Has<Edge>With predicates
+// of the tenant-scoped edges
+func (repo *PostgresRepository) scope(ctx context.Context, preds []*comparison.Predicate) ([]*comparison.Predicate, error) {
+	return repo.scopeEdges(ctx, preds)
+}
+
+// runner returns the transaction carried by the context when
+// the tx-from-context mode is enabled, otherwise it returns the db
+func (repo *PostgresRepository) runner(ctx context.Context) (nero.SQLRunner, error) {
+	if !repo.txFromContext {
+		return nero.AsSQLRunner(repo.db), nil
+	}
+
+	tx, ok := nero.TxFromContext(ctx)
+	if !ok {
+		return nero.AsSQLRunner(repo.db), nil
+	}
+
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return txx, nil
+}
+
+// readRunner returns a replica chosen by the balancer. It falls back to runner
+// when there are no replicas, when primary is true or when the context carries
+// a transaction in the tx-from-context mode.
+func (repo *PostgresRepository) readRunner(ctx context.Context, primary bool) (nero.SQLRunner, error) {
+	if primary || len(repo.replicas) == 0 || repo.balancer == nil {
+		return repo.runner(ctx)
+	}
+
+	if _, ok := nero.TxFromContext(ctx); ok && repo.txFromContext {
+		return repo.runner(ctx)
+	}
+
+	return nero.AsSQLRunner(repo.balancer(repo.replicas)), nil
+}
+
 // Tx begins a new transaction
 func (repo *PostgresRepository) Tx(ctx context.Context) (nero.Tx, error) {
-	return repo.db.BeginTx(ctx, nil)
+	return repo.TxWithOptions(ctx, nil)
+}
+
+// TxWithOptions begins a new transaction with the given options. If the
+// repository's db is already a transaction, a nested transaction is returned.
+func (repo *PostgresRepository) TxWithOptions(ctx context.Context, opts *sql.TxOptions) (nero.Tx, error) {
+	return nero.BeginTx(ctx, repo.db, opts)
+}
+
+// RunInTx runs fn in a transaction. The transaction is committed if fn returns nil
+// and rolled back if fn returns an error or panics. The transaction is retried
+// on serialization failures according to opts, which may be nil.
+func (repo *PostgresRepository) RunInTx(ctx context.Context, opts *nero.TxOptions, fn func(nero.Tx) error) error {
+	return runInTx(ctx, repo.TxWithOptions, repo.isRetryable, opts, fn)
+}
+
+// isRetryable returns true if err is a serialization failure or a deadlock
+func (repo *PostgresRepository) isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+
+	return false
 }
 
 // Create creates a Player
 func (repo *PostgresRepository) Create(ctx context.Context, c *Creator) (string, error) {
-	return repo.create(ctx, repo.db, c)
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return repo.create(ctx, runner, c)
 }
 
 // CreateTx creates a Player in a transaction
 func (repo *PostgresRepository) CreateTx(ctx context.Context, tx nero.Tx, c *Creator) (string, error) {
-	txx, ok := tx.(*sql.Tx)
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return "", errors.New("expecting tx to be *sql.Tx")
+		return "", errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.create(ctx, txx, c)
 }
 
-func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunner, c *Creator) (string, error) {
+// create inserts a Player. If conflict columns are given, the row that has the
+// same values for these columns is updated instead i.e. INSERT ... ON CONFLICT DO UPDATE.
+func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunner, c *Creator, conflict ...string) (string, error) {
+	if err := c.generate(); err != nil {
+		return "", err
+	}
+
 	if err := c.Validate(); err != nil {
 		return "", err
 	}
@@ -88,15 +260,43 @@ func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunne
 		c.race,
 	}
 
+	if !isZero(c.teamID) {
+		columns = append(columns, "\"team_id\"")
+		values = append(values, c.teamID)
+	}
 	if !isZero(c.updatedAt) {
-		columns = append(columns, "updated_at")
+		columns = append(columns, "\"updated_at\"")
 		values = append(values, c.updatedAt)
 	}
 
-	qb := squirrel.Insert("\"players\"").
+	suffix := "RETURNING \"id\""
+	if len(conflict) > 0 {
+		// the key of the existing row is kept so the rows that reference it
+		// aren't broken and the returned key is the one of the existing row
+		keys := []string{
+			"\"id\"",
+		}
+		sets := []string{}
+		for _, col := range columns {
+			if !contains(conflict, col) && !contains(keys, col) {
+				sets = append(sets, col+" = EXCLUDED."+col)
+			}
+		}
+
+		// the row is only returned if it's updated
+		if len(sets) == 0 {
+			sets = append(sets, conflict[0]+" = EXCLUDED."+conflict[0])
+		}
+
+		onConflict := fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s ", strings.Join(conflict, ", "),
+			strings.Join(sets, ", "))
+		suffix = onConflict + suffix
+	}
+
+	qb := squirrel.Insert(repo.table()).
 		Columns(columns...).
 		Values(values...).
-		Suffix("RETURNING \"id\"").
+		Suffix(suffix).
 		PlaceholderFormat(squirrel.Dollar).
 		RunWith(runner)
 	if repo.debug && repo.logger != nil {
@@ -115,14 +315,19 @@ func (repo *PostgresRepository) create(ctx context.Context, runner nero.SQLRunne
 
 // CreateMany batch creates Players
 func (repo *PostgresRepository) CreateMany(ctx context.Context, cs ...*Creator) error {
-	return repo.createMany(ctx, repo.db, cs...)
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return err
+	}
+
+	return repo.createMany(ctx, runner, cs...)
 }
 
 // CreateManyTx batch creates Players in a transaction
 func (repo *PostgresRepository) CreateManyTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
-	txx, ok := tx.(*sql.Tx)
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return errors.New("expecting tx to be *sql.Tx")
+		return errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.createMany(ctx, txx, cs...)
@@ -138,56 +343,170 @@ func (repo *PostgresRepository) createMany(ctx context.Context, runner nero.SQLR
 		"\"name\"",
 		"\"age\"",
 		"\"race\"",
+		"\"team_id\"",
 		"\"updated_at\"",
 	}
 
-	qb := squirrel.Insert("\"players\"").Columns(columns...)
+	// the creators are inserted in chunks so the statements don't exceed the bind parameter limit
+	chunks := chunkCreators(cs, nero.PostgresMaxParams/len(columns))
+	if _, ok := runner.(nero.TxRunner); !ok && len(chunks) > 1 {
+		// the chunks are inserted in a transaction so it's all or nothing
+		return repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+			return repo.CreateManyTx(ctx, tx, cs...)
+		})
+	}
+
+	for _, chunk := range chunks {
+		qb := squirrel.Insert(repo.table()).Columns(columns...)
+		for _, c := range chunk {
+			if err := c.generate(); err != nil {
+				return err
+			}
+
+			if err := c.Validate(); err != nil {
+				return err
+			}
+
+			qb = qb.Values(
+				c.email,
+				c.name,
+				c.age,
+				c.race,
+				c.teamID,
+				c.updatedAt,
+			)
+		}
+
+		qb = qb.Suffix("RETURNING \"id\"").
+			PlaceholderFormat(squirrel.Dollar)
+		if repo.debug && repo.logger != nil {
+			sql, args, err := qb.ToSql()
+			repo.logger.Printf("method: CreateMany, stmt: %q, args: %v, error: %v", sql, args, err)
+		}
+
+		_, err := qb.RunWith(runner).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BulkInsert inserts Players with COPY FROM STDIN, which is faster than CreateMany
+// for a large number of Players. It runs in a transaction, which is the one that's
+// carried by the context in the tx-from-context mode.
+func (repo *PostgresRepository) BulkInsert(ctx context.Context, cs ...*Creator) error {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return err
+	}
+
+	if txx, ok := runner.(nero.TxRunner); ok {
+		return repo.bulkInsert(ctx, txx, cs...)
+	}
+
+	return repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+		return repo.BulkInsertTx(ctx, tx, cs...)
+	})
+}
+
+// BulkInsertTx inserts Players with COPY FROM STDIN in a transaction
+func (repo *PostgresRepository) BulkInsertTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.bulkInsert(ctx, txx, cs...)
+}
+
+func (repo *PostgresRepository) bulkInsert(ctx context.Context, tx nero.TxRunner, cs ...*Creator) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	columns := []string{
+		"email",
+		"name",
+		"age",
+		"race",
+		"team_id",
+		"updated_at",
+	}
+
+	namespace := ""
+	if repo.schema != "" {
+		namespace = repo.schema
+	}
+
+	query := pq.CopyIn("players", columns...)
+	if namespace != "" {
+		query = pq.CopyInSchema(namespace, "players", columns...)
+	}
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: BulkInsert, stmt: %q, rows: %d", query, len(cs))
+	}
+
+	stmt, err := tx.Unwrap().PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
 	for _, c := range cs {
+		if err := c.generate(); err != nil {
+			return err
+		}
+
 		if err := c.Validate(); err != nil {
 			return err
 		}
 
-		qb = qb.Values(
+		_, err = stmt.ExecContext(ctx,
 			c.email,
 			c.name,
 			c.age,
 			c.race,
+			c.teamID,
 			c.updatedAt,
 		)
+		if err != nil {
+			return err
+		}
 	}
 
-	qb = qb.Suffix("RETURNING \"id\"").
-		PlaceholderFormat(squirrel.Dollar)
-	if repo.debug && repo.logger != nil {
-		sql, args, err := qb.ToSql()
-		repo.logger.Printf("method: CreateMany, stmt: %q, args: %v, error: %v", sql, args, err)
-	}
-
-	_, err := qb.RunWith(runner).ExecContext(ctx)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// flush the buffered rows
+	_, err = stmt.ExecContext(ctx)
+	return err
 }
 
 // Query queries Players
 func (repo *PostgresRepository) Query(ctx context.Context, q *Queryer) ([]*player.Player, error) {
-	return repo.query(ctx, repo.db, q)
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.query(ctx, runner, q)
 }
 
 // QueryTx queries Players in a transaction
 func (repo *PostgresRepository) QueryTx(ctx context.Context, tx nero.Tx, q *Queryer) ([]*player.Player, error) {
-	txx, ok := tx.(*sql.Tx)
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return nil, errors.New("expecting tx to be *sql.Tx")
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.query(ctx, txx, q)
 }
 
 func (repo *PostgresRepository) query(ctx context.Context, runner nero.SQLRunner, q *Queryer) ([]*player.Player, error) {
-	qb := repo.buildSelect(q)
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
 	if repo.debug && repo.logger != nil {
 		sql, args, err := qb.ToSql()
 		repo.logger.Printf("method: Query, stmt: %q, args: %v, error: %v", sql, args, err)
@@ -199,23 +518,14 @@ func (repo *PostgresRepository) query(ctx context.Context, runner nero.SQLRunner
 	}
 	defer rows.Close()
 
-	players := []*player.Player{}
-	for rows.Next() {
-		var player player.Player
-		err = rows.Scan(
-			&player.ID,
-			&player.Email,
-			&player.Name,
-			&player.Age,
-			&player.Race,
-			&player.UpdatedAt,
-			&player.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
+	players, err := repo.scan(rows)
+	if err != nil {
+		return nil, err
+	}
 
-		players = append(players, &player)
+	err = repo.loadEdges(ctx, runner, q, players...)
+	if err != nil {
+		return nil, err
 	}
 
 	return players, nil
@@ -223,28 +533,37 @@ func (repo *PostgresRepository) query(ctx context.Context, runner nero.SQLRunner
 
 // QueryOne queries a Player
 func (repo *PostgresRepository) QueryOne(ctx context.Context, q *Queryer) (*player.Player, error) {
-	return repo.queryOne(ctx, repo.db, q)
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.queryOne(ctx, runner, q)
 }
 
 // QueryOneTx queries a Player in a transaction
 func (repo *PostgresRepository) QueryOneTx(ctx context.Context, tx nero.Tx, q *Queryer) (*player.Player, error) {
-	txx, ok := tx.(*sql.Tx)
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return nil, errors.New("expecting tx to be *sql.Tx")
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.queryOne(ctx, txx, q)
 }
 
 func (repo *PostgresRepository) queryOne(ctx context.Context, runner nero.SQLRunner, q *Queryer) (*player.Player, error) {
-	qb := repo.buildSelect(q)
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
 	if repo.debug && repo.logger != nil {
 		sql, args, err := qb.ToSql()
 		repo.logger.Printf("method: QueryOne, stmt: %q, args: %v, error: %v", sql, args, err)
 	}
 
 	var player player.Player
-	err := qb.RunWith(runner).
+	err = qb.RunWith(runner).
 		QueryRowContext(ctx).
 		Scan(
 			&player.ID,
@@ -252,6 +571,7 @@ func (repo *PostgresRepository) queryOne(ctx context.Context, runner nero.SQLRun
 			&player.Name,
 			&player.Age,
 			&player.Race,
+			&player.TeamID,
 			&player.UpdatedAt,
 			&player.CreatedAt,
 		)
@@ -259,166 +579,612 @@ func (repo *PostgresRepository) queryOne(ctx context.Context, runner nero.SQLRun
 		return nil, err
 	}
 
+	err = repo.loadEdges(ctx, runner, q, &player)
+	if err != nil {
+		return nil, err
+	}
+
 	return &player, nil
 }
 
-func (repo *PostgresRepository) buildSelect(q *Queryer) squirrel.SelectBuilder {
-	columns := []string{
+// Exists checks if there's any Player that matches the queryer
+func (repo *PostgresRepository) Exists(ctx context.Context, q *Queryer) (bool, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return false, err
+	}
+
+	return repo.exists(ctx, runner, q)
+}
+
+// ExistsTx checks if there's any Player that matches the queryer in a transaction
+func (repo *PostgresRepository) ExistsTx(ctx context.Context, tx nero.Tx, q *Queryer) (bool, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return false, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.exists(ctx, txx, q)
+}
+
+func (repo *PostgresRepository) exists(ctx context.Context, runner nero.SQLRunner, q *Queryer) (bool, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return false, err
+	}
+
+	eb := squirrel.Select().
+		Column(squirrel.Expr("EXISTS (?)", qb.PlaceholderFormat(squirrel.Question))).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := eb.ToSql()
+		repo.logger.Printf("method: Exists, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var exists bool
+	err = eb.RunWith(runner).QueryRowContext(ctx).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Count counts the Players that match the queryer
+func (repo *PostgresRepository) Count(ctx context.Context, q *Queryer) (int64, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.count(ctx, runner, q)
+}
+
+// CountTx counts the Players that match the queryer in a transaction
+func (repo *PostgresRepository) CountTx(ctx context.Context, tx nero.Tx, q *Queryer) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.count(ctx, txx, q)
+}
+
+// count counts the rows of the select so the limit and
+// the offset of the queryer are taken into account
+func (repo *PostgresRepository) count(ctx context.Context, runner nero.SQLRunner, q *Queryer) (int64, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+
+	eb := squirrel.Select("COUNT(*)").
+		FromSelect(qb.PlaceholderFormat(squirrel.Question), "q").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := eb.ToSql()
+		repo.logger.Printf("method: Count, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var count int64
+	err = eb.RunWith(runner).QueryRowContext(ctx).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo *PostgresRepository) buildSelect(ctx context.Context, q *Queryer) (squirrel.SelectBuilder, error) {
+	qb := squirrel.Select(repo.columns()...).
+		From(repo.table()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if len(q.distinctOn) > 0 {
+		on := []string{}
+		for _, field := range q.distinctOn {
+			on = append(on, fmt.Sprintf("%q", field.String()))
+		}
+		qb = qb.Options("DISTINCT ON (" + strings.Join(on, ", ") + ")")
+	} else if q.distinct {
+		qb = qb.Distinct()
+	}
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range q.predFuncs {
+		preds = predFunc(preds)
+	}
+	preds, err := repo.scope(ctx, preds)
+	if err != nil {
+		return squirrel.SelectBuilder{}, err
+	}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	sorts := []*sort.Sort{}
+	for _, sortFunc := range q.sortFuncs {
+		sorts = sortFunc(sorts)
+	}
+	qb = repo.buildSort(qb, sorts)
+
+	if q.limit > 0 {
+		qb = qb.Limit(uint64(q.limit))
+	}
+
+	if q.offset > 0 {
+		qb = qb.Offset(uint64(q.offset))
+	}
+
+	if q.forUpdate || q.forShare {
+		lock := "FOR UPDATE"
+		if q.forShare {
+			lock = "FOR SHARE"
+		}
+
+		if q.skipLocked {
+			lock += " SKIP LOCKED"
+		} else if q.noWait {
+			lock += " NOWAIT"
+		}
+
+		qb = qb.Suffix(lock)
+	}
+
+	return qb, nil
+}
+
+func (repo *PostgresRepository) columns() []string {
+	return []string{
 		"\"id\"",
 		"\"email\"",
 		"\"name\"",
 		"\"age\"",
 		"\"race\"",
+		"\"team_id\"",
 		"\"updated_at\"",
 		"\"created_at\"",
 	}
+}
+
+func (repo *PostgresRepository) scan(rows *sql.Rows) ([]*player.Player, error) {
+	players := []*player.Player{}
+	for rows.Next() {
+		var player player.Player
+		err := rows.Scan(
+			&player.ID,
+			&player.Email,
+			&player.Name,
+			&player.Age,
+			&player.Race,
+			&player.TeamID,
+			&player.UpdatedAt,
+			&player.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		players = append(players, &player)
+	}
+
+	return players, rows.Err()
+}
+
+func (repo *PostgresRepository) buildPreds(sb squirrel.StatementBuilderType, table string, preds []*comparison.Predicate) squirrel.StatementBuilderType {
+	column := func(field string) string {
+		if table == "" {
+			return fmt.Sprintf("%q", field)
+		}
+		return fmt.Sprintf("%q.%q", table, field)
+	}
+
+	for _, pred := range preds {
+		ph := "?"
+		fieldX, arg := column(pred.Field), pred.Arg
+
+		args := []interface{}{}
+		if fieldY, ok := arg.(comparison.FieldArg); ok { // a field
+			ph = column(fieldY.Column())
+		} else if vals, ok := arg.([]interface{}); ok { // array of values
+			args = append(args, vals...)
+		} else { // single value
+			args = append(args, pgArray(arg))
+		}
+
+		switch pred.Op {
+		case comparison.Eq:
+			sb = sb.Where(fmt.Sprintf("%s = "+ph, fieldX), args...)
+		case comparison.NotEq:
+			sb = sb.Where(fmt.Sprintf("%s <> "+ph, fieldX), args...)
+		case comparison.Gt:
+			sb = sb.Where(fmt.Sprintf("%s > "+ph, fieldX), args...)
+		case comparison.GtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s >= "+ph, fieldX), args...)
+		case comparison.Lt:
+			sb = sb.Where(fmt.Sprintf("%s < "+ph, fieldX), args...)
+		case comparison.LtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s <= "+ph, fieldX), args...)
+		case comparison.IsNull, comparison.IsNotNull:
+			fmtStr := "%s IS NULL"
+			if pred.Op == comparison.IsNotNull {
+				fmtStr = "%s IS NOT NULL"
+			}
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX))
+		case comparison.In, comparison.NotIn:
+			fmtStr := "%s IN (%s)"
+			if pred.Op == comparison.NotIn {
+				fmtStr = "%s NOT IN (%s)"
+			}
+
+			phs := []string{}
+			for range args {
+				phs = append(phs, "?")
+			}
+
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX, strings.Join(phs, ",")), args...)
+		case comparison.Exists:
+			edge, _ := arg.(*comparison.Edge)
+			sb = sb.Where(squirrel.Expr("EXISTS (?)", repo.buildExists(table, edge)))
+		case comparison.PathEq:
+			// the predicates of Has<Edge>With may come from the target schema
+			pv, _ := arg.(*comparison.PathValue)
+			sb = sb.Where(fmt.Sprintf("%s #>> ? = ?", fieldX), pq.Array(strings.Split(pv.Path, ".")), pv.Value)
+		case comparison.Contains:
+			sb = sb.Where(fmt.Sprintf("%s @> ?", fieldX), args...)
+		case comparison.ContainedBy:
+			sb = sb.Where(fmt.Sprintf("%s <@ ?", fieldX), args...)
+		case comparison.Overlap:
+			sb = sb.Where(fmt.Sprintf("%s && ?", fieldX), args...)
+		case comparison.AnyEq:
+			sb = sb.Where(fmt.Sprintf("? = ANY(%s)", fieldX), args...)
+		}
+	}
+
+	return sb
+}
+
+func (repo *PostgresRepository) buildSort(qb squirrel.SelectBuilder, sorts []*sort.Sort) squirrel.SelectBuilder {
+	for _, s := range sorts {
+		field := fmt.Sprintf("%q", s.Field)
+		switch s.Direction {
+		case sort.Asc:
+			qb = qb.OrderBy(field + " ASC")
+		case sort.Desc:
+			qb = qb.OrderBy(field + " DESC")
+		}
+	}
+
+	return qb
+}
+
+// buildExists builds the subquery of the Has<Edge>With predicates. The target
+// table is aliased with the edge name, prefixed with the alias of the outer
+// table when the predicate is nested, so the columns are never ambiguous.
+func (repo *PostgresRepository) buildExists(outer string, edge *comparison.Edge) squirrel.SelectBuilder {
+	alias := edge.Name
+	if outer == "" {
+		outer = "players"
+	} else {
+		alias = outer + "_" + edge.Name
+	}
+
+	qb := squirrel.Select("1").
+		From(repo.qualify(edge.Namespace, edge.Collection) + fmt.Sprintf(" AS %q", alias))
+	if edge.Through == "" {
+		qb = qb.Where(fmt.Sprintf("%q.%q = %q.%q", alias, edge.Column, outer, edge.Ref))
+	} else {
+		through := alias + "_" + edge.Through
+		qb = qb.Join(repo.qualify(edge.ThroughNamespace, edge.Through) +
+			fmt.Sprintf(" AS %q ON %q.%q = %q.%q", through, through, edge.ThroughTo, alias, edge.Column)).
+			Where(fmt.Sprintf("%q.%q = %q.%q", through, edge.ThroughFrom, outer, edge.Ref))
+	}
+
+	return squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), alias, edge.Preds))
+}
+
+// loadEdges eager loads the edges that are requested by the queryer
+func (repo *PostgresRepository) loadEdges(ctx context.Context, runner nero.SQLRunner, q *Queryer, players ...*player.Player) error {
+	if len(players) == 0 {
+		return nil
+	}
+
+	if q.withTeam {
+		if err := repo.loadTeam(ctx, runner, players); err != nil {
+			return errors.Wrap(err, "load team")
+		}
+	}
+
+	if q.withFriends {
+		if err := repo.loadFriends(ctx, runner, players); err != nil {
+			return errors.Wrap(err, "load friends")
+		}
+	}
+
+	return nil
+}
+
+// loadTeam loads the team edge of the Players with a single query
+func (repo *PostgresRepository) loadTeam(ctx context.Context, runner nero.SQLRunner, players []*player.Player) error {
+	columns := []string{
+		"\"teams\".\"id\"",
+		"\"teams\".\"name\"",
+		"\"teams\".\"meta\"",
+		"\"teams\".\"tags\"",
+		"\"teams\".\"created_at\"",
+		"\"teams\".\"tenant_id\"",
+	}
+
+	keys := []interface{}{}
+	seen := map[string]bool{}
+	for _, player := range players {
+		if player.TeamID == nil {
+			continue
+		}
+		key := *player.TeamID
+
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
 	qb := squirrel.Select(columns...).
-		From("\"players\"").
+		From(repo.qualify("", "teams")).
+		Where(squirrel.Eq{"\"teams\".\"id\"": keys}).
 		PlaceholderFormat(squirrel.Dollar)
 
-	preds := []*comparison.Predicate{}
-	for _, predFunc := range q.predFuncs {
-		preds = predFunc(preds)
+	// the rows of the other tenants are never loaded
+	tenantID, err := repo.edgeTenant(ctx)
+	if err != nil {
+		return err
+	}
+	qb = qb.Where("\"teams\".\"tenant_id\" = ?", tenantID)
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Query, edge: team, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	related := map[string]*player.Team{}
+	for rows.Next() {
+		var item player.Team
+		err = rows.Scan(
+			&item.ID,
+			&item.Name,
+			nero.JSON(&item.Meta),
+			pq.Array(&item.Tags),
+			&item.CreatedAt,
+			&item.TenantID,
+		)
+		if err != nil {
+			return err
+		}
+
+		related[item.ID] = &item
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, player := range players {
+		if player.TeamID != nil {
+			player.Team = related[*player.TeamID]
+		}
+	}
+
+	return nil
+}
+
+// loadFriends loads the friends edge of the Players with a single query
+func (repo *PostgresRepository) loadFriends(ctx context.Context, runner nero.SQLRunner, players []*player.Player) error {
+	columns := []string{
+		"\"friendships\".\"player_id\"",
+		"\"players\".\"id\"",
+		"\"players\".\"email\"",
+		"\"players\".\"name\"",
+		"\"players\".\"age\"",
+		"\"players\".\"race\"",
+		"\"players\".\"team_id\"",
+		"\"players\".\"updated_at\"",
+		"\"players\".\"created_at\"",
+	}
+
+	keys := []interface{}{}
+	for _, player := range players {
+		keys = append(keys, player.ID)
+	}
+
+	qb := squirrel.Select(columns...).
+		From(repo.qualify("", "players")).
+		Join(repo.qualify("", "friendships") + " ON \"friendships\".\"friend_id\" = \"players\".\"id\"").
+		Where(squirrel.Eq{"\"friendships\".\"player_id\"": keys}).
+		PlaceholderFormat(squirrel.Dollar)
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Query, edge: friends, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return err
 	}
-	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), preds))
+	defer rows.Close()
 
-	sorts := []*sort.Sort{}
-	for _, sortFunc := range q.sortFuncs {
-		sorts = sortFunc(sorts)
+	related := map[string][]*player.Player{}
+	for rows.Next() {
+		var key string
+		var item player.Player
+		err = rows.Scan(
+			&key,
+			&item.ID,
+			&item.Email,
+			&item.Name,
+			&item.Age,
+			&item.Race,
+			&item.TeamID,
+			&item.UpdatedAt,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		related[key] = append(related[key], &item)
 	}
-	qb = repo.buildSort(qb, sorts)
 
-	if q.limit > 0 {
-		qb = qb.Limit(uint64(q.limit))
+	if err = rows.Err(); err != nil {
+		return err
 	}
 
-	if q.offset > 0 {
-		qb = qb.Offset(uint64(q.offset))
+	for _, player := range players {
+		player.Friends = related[player.ID]
 	}
 
-	return qb
+	return nil
 }
 
-func (repo *PostgresRepository) buildPreds(sb squirrel.StatementBuilderType, preds []*comparison.Predicate) squirrel.StatementBuilderType {
-	for _, pred := range preds {
-		ph := "?"
-		fieldX, arg := pred.Field, pred.Arg
-
-		args := []interface{}{}
-		if fieldY, ok := arg.(Field); ok { // a field
-			ph = fmt.Sprintf("%q", fieldY)
-		} else if vals, ok := arg.([]interface{}); ok { // array of values
-			args = append(args, vals...)
-		} else { // single value
-			args = append(args, arg)
-		}
-
-		switch pred.Op {
-		case comparison.Eq:
-			sb = sb.Where(fmt.Sprintf("%q = "+ph, fieldX), args...)
-		case comparison.NotEq:
-			sb = sb.Where(fmt.Sprintf("%q <> "+ph, fieldX), args...)
-		case comparison.Gt:
-			sb = sb.Where(fmt.Sprintf("%q > "+ph, fieldX), args...)
-		case comparison.GtOrEq:
-			sb = sb.Where(fmt.Sprintf("%q >= "+ph, fieldX), args...)
-		case comparison.Lt:
-			sb = sb.Where(fmt.Sprintf("%q < "+ph, fieldX), args...)
-		case comparison.LtOrEq:
-			sb = sb.Where(fmt.Sprintf("%q <= "+ph, fieldX), args...)
-		case comparison.IsNull, comparison.IsNotNull:
-			fmtStr := "%q IS NULL"
-			if pred.Op == comparison.IsNotNull {
-				fmtStr = "%q IS NOT NULL"
-			}
-			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX))
-		case comparison.In, comparison.NotIn:
-			fmtStr := "%q IN (%s)"
-			if pred.Op == comparison.NotIn {
-				fmtStr = "%q NOT IN (%s)"
-			}
+// QueryByEmail queries a Player by the unique Email index
+func (repo *PostgresRepository) QueryByEmail(ctx context.Context, email string) (*player.Player, error) {
+	return repo.QueryOne(ctx, NewQueryer().Where(
+		EmailEq(email),
+	))
+}
 
-			phs := []string{}
-			for range args {
-				phs = append(phs, "?")
-			}
+// QueryByEmailTx queries a Player by the unique Email index in a transaction
+func (repo *PostgresRepository) QueryByEmailTx(ctx context.Context, tx nero.Tx, email string) (*player.Player, error) {
+	return repo.QueryOneTx(ctx, tx, NewQueryer().Where(
+		EmailEq(email),
+	))
+}
 
-			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX, strings.Join(phs, ",")), args...)
-		}
+// UpsertByEmail creates a Player or updates the one with the same Email
+func (repo *PostgresRepository) UpsertByEmail(ctx context.Context, c *Creator) (string, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return "", err
 	}
 
-	return sb
+	return repo.create(ctx, runner, c, "\"email\"")
 }
 
-func (repo *PostgresRepository) buildSort(qb squirrel.SelectBuilder, sorts []*sort.Sort) squirrel.SelectBuilder {
-	for _, s := range sorts {
-		field := fmt.Sprintf("%q", s.Field)
-		switch s.Direction {
-		case sort.Asc:
-			qb = qb.OrderBy(field + " ASC")
-		case sort.Desc:
-			qb = qb.OrderBy(field + " DESC")
-		}
+// UpsertByEmailTx creates a Player or updates the one with the same Email in a transaction
+func (repo *PostgresRepository) UpsertByEmailTx(ctx context.Context, tx nero.Tx, c *Creator) (string, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return "", errors.New("expecting tx to implement nero.TxRunner")
 	}
 
-	return qb
+	return repo.create(ctx, txx, c, "\"email\"")
 }
 
 // Update updates a Player or many Players
 func (repo *PostgresRepository) Update(ctx context.Context, u *Updater) (int64, error) {
-	return repo.update(ctx, repo.db, u)
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.update(ctx, runner, u)
 }
 
 // UpdateTx updates a Player many Players in a transaction
 func (repo *PostgresRepository) UpdateTx(ctx context.Context, tx nero.Tx, u *Updater) (int64, error) {
-	txx, ok := tx.(*sql.Tx)
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return 0, errors.New("expecting tx to be *sql.Tx")
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.update(ctx, txx, u)
 }
 
-func (repo *PostgresRepository) update(ctx context.Context, runner nero.SQLRunner, u *Updater) (int64, error) {
-	qb := squirrel.Update("\"players\"").
+func (repo *PostgresRepository) buildUpdate(ctx context.Context, u *Updater) (squirrel.UpdateBuilder, bool, error) {
+	qb := squirrel.Update(repo.table()).
 		PlaceholderFormat(squirrel.Dollar)
 
 	cnt := 0
 
-	if !isZero(u.email) {
+	if u.fields.has(FieldEmail) {
 		qb = qb.Set("\"email\"", u.email)
 		cnt++
 	}
 
-	if !isZero(u.name) {
+	if u.fields.has(FieldName) {
 		qb = qb.Set("\"name\"", u.name)
 		cnt++
 	}
 
-	if !isZero(u.age) {
+	if u.fields.has(FieldAge) {
 		qb = qb.Set("\"age\"", u.age)
 		cnt++
 	}
 
-	if !isZero(u.race) {
+	if u.fields.has(FieldRace) {
 		qb = qb.Set("\"race\"", u.race)
 		cnt++
 	}
 
-	if !isZero(u.updatedAt) {
+	if u.fields.has(FieldTeamID) {
+		qb = qb.Set("\"team_id\"", u.teamID)
+		cnt++
+	}
+
+	if u.fields.has(FieldUpdatedAt) {
 		qb = qb.Set("\"updated_at\"", u.updatedAt)
 		cnt++
 	}
 
+	for _, e := range u.exprs {
+		col := fmt.Sprintf("%q", e.field)
+		if e.delta != nil {
+			qb = qb.Set(col, squirrel.Expr(col+" + ?", e.delta))
+		} else {
+			qb = qb.Set(col, squirrel.Expr(e.expr, e.args...))
+		}
+		cnt++
+	}
+
 	if cnt == 0 {
-		return 0, nil
+		return qb, false, nil
 	}
 
 	preds := []*comparison.Predicate{}
 	for _, predFunc := range u.predFuncs {
 		preds = predFunc(preds)
 	}
-	qb = squirrel.UpdateBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), preds))
+	preds, err := repo.scope(ctx, preds)
+	if err != nil {
+		return qb, false, err
+	}
+	qb = squirrel.UpdateBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, true, nil
+}
+
+func (repo *PostgresRepository) update(ctx context.Context, runner nero.SQLRunner, u *Updater) (int64, error) {
+	if err := u.Validate(); err != nil {
+		return 0, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, nil
+	}
 
 	if repo.debug && repo.logger != nil {
 		sql, args, err := qb.ToSql()
@@ -438,30 +1204,261 @@ func (repo *PostgresRepository) update(ctx context.Context, runner nero.SQLRunne
 	return rowsAffected, nil
 }
 
+// UpdateReturning updates a Player or many Players and returns the updated Players
+func (repo *PostgresRepository) UpdateReturning(ctx context.Context, u *Updater) ([]*player.Player, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.updateReturning(ctx, runner, u)
+}
+
+// UpdateReturningTx updates a Player or many Players in a transaction and returns the updated Players
+func (repo *PostgresRepository) UpdateReturningTx(ctx context.Context, tx nero.Tx, u *Updater) ([]*player.Player, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.updateReturning(ctx, txx, u)
+}
+
+func (repo *PostgresRepository) updateReturning(ctx context.Context, runner nero.SQLRunner, u *Updater) ([]*player.Player, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, nil
+	}
+	qb = qb.Suffix("RETURNING " + strings.Join(repo.columns(), ","))
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: UpdateReturning, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repo.scan(rows)
+}
+
+// UpdateMany updates many Players with different values in a single statement
+func (repo *PostgresRepository) UpdateMany(ctx context.Context, rus []*RowUpdate) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.updateMany(ctx, runner, rus)
+}
+
+// UpdateManyTx updates many Players with different values in a single statement in a transaction
+func (repo *PostgresRepository) UpdateManyTx(ctx context.Context, tx nero.Tx, rus []*RowUpdate) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.updateMany(ctx, txx, rus)
+}
+
+// updateMany updates the Players with an UPDATE ... FROM (VALUES ...) statement. The
+// values are preceded by an empty select of the table so they get the types of the columns,
+// and the columns that aren't set by a row update are flagged so they keep their value.
+func (repo *PostgresRepository) updateMany(ctx context.Context, runner nero.SQLRunner, rus []*RowUpdate) (int64, error) {
+	if len(rus) == 0 {
+		return 0, nil
+	}
+
+	for _, ru := range rus {
+		if err := ru.Validate(); err != nil {
+			return 0, err
+		}
+	}
+
+	columns := []string{
+		"\"id\"",
+	}
+	sets := []string{}
+	rows := [][]interface{}{}
+	for _, ru := range rus {
+		rows = append(rows, []interface{}{ru.key})
+	}
+
+	if anyHas(rus, FieldEmail) {
+		columns = append(columns, "\"email\"", "false AS \"set_email\"")
+		sets = append(sets, "\"email\" = CASE WHEN \"v\".\"set_email\" "+
+			"THEN \"v\".\"email\" ELSE \"players\".\"email\" END")
+		for i, ru := range rus {
+			rows[i] = append(rows[i], ru.email, ru.fields.has(FieldEmail))
+		}
+	}
+
+	if anyHas(rus, FieldName) {
+		columns = append(columns, "\"name\"", "false AS \"set_name\"")
+		sets = append(sets, "\"name\" = CASE WHEN \"v\".\"set_name\" "+
+			"THEN \"v\".\"name\" ELSE \"players\".\"name\" END")
+		for i, ru := range rus {
+			rows[i] = append(rows[i], ru.name, ru.fields.has(FieldName))
+		}
+	}
+
+	if anyHas(rus, FieldAge) {
+		columns = append(columns, "\"age\"", "false AS \"set_age\"")
+		sets = append(sets, "\"age\" = CASE WHEN \"v\".\"set_age\" "+
+			"THEN \"v\".\"age\" ELSE \"players\".\"age\" END")
+		for i, ru := range rus {
+			rows[i] = append(rows[i], ru.age, ru.fields.has(FieldAge))
+		}
+	}
+
+	if anyHas(rus, FieldRace) {
+		columns = append(columns, "\"race\"", "false AS \"set_race\"")
+		sets = append(sets, "\"race\" = CASE WHEN \"v\".\"set_race\" "+
+			"THEN \"v\".\"race\" ELSE \"players\".\"race\" END")
+		for i, ru := range rus {
+			rows[i] = append(rows[i], ru.race, ru.fields.has(FieldRace))
+		}
+	}
+
+	if anyHas(rus, FieldTeamID) {
+		columns = append(columns, "\"team_id\"", "false AS \"set_team_id\"")
+		sets = append(sets, "\"team_id\" = CASE WHEN \"v\".\"set_team_id\" "+
+			"THEN \"v\".\"team_id\" ELSE \"players\".\"team_id\" END")
+		for i, ru := range rus {
+			rows[i] = append(rows[i], ru.teamID, ru.fields.has(FieldTeamID))
+		}
+	}
+
+	if anyHas(rus, FieldUpdatedAt) {
+		columns = append(columns, "\"updated_at\"", "false AS \"set_updated_at\"")
+		sets = append(sets, "\"updated_at\" = CASE WHEN \"v\".\"set_updated_at\" "+
+			"THEN \"v\".\"updated_at\" ELSE \"players\".\"updated_at\" END")
+		for i, ru := range rus {
+			rows[i] = append(rows[i], ru.updatedAt, ru.fields.has(FieldUpdatedAt))
+		}
+	}
+
+	if len(sets) == 0 {
+		return 0, nil
+	}
+
+	// the rows are updated in chunks so the statements don't exceed the bind parameter limit
+	size := nero.PostgresMaxParams / (len(rows[0]) + 1)
+	if _, ok := runner.(nero.TxRunner); !ok && len(rows) > size {
+		// the chunks are updated in a transaction so it's all or nothing
+		var rowsAffected int64
+		err := repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+			var err error
+			rowsAffected, err = repo.UpdateManyTx(ctx, tx, rus)
+			return err
+		})
+		return rowsAffected, err
+	}
+
+	var rowsAffected int64
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		values := []string{}
+		args := []interface{}{}
+		for _, row := range rows[start:end] {
+			phs := []string{}
+			for range row {
+				phs = append(phs, "?")
+			}
+
+			values = append(values, "("+strings.Join(phs, ", ")+")")
+			args = append(args, row...)
+		}
+
+		where := []string{
+			"\"players\".\"id\" = \"v\".\"id\"",
+		}
+		stmt, err := squirrel.Dollar.ReplacePlaceholders(fmt.Sprintf(
+			"UPDATE %s SET %s FROM (SELECT %s FROM %s WHERE false UNION ALL VALUES %s) AS \"v\" WHERE %s",
+			repo.table(), strings.Join(sets, ", "), strings.Join(columns, ", "), repo.table(),
+			strings.Join(values, ", "), strings.Join(where, " AND ")))
+		if repo.debug && repo.logger != nil {
+			repo.logger.Printf("method: UpdateMany, stmt: %q, args: %v, error: %v", stmt, args, err)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		res, err := runner.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected += n
+	}
+
+	return rowsAffected, nil
+}
+
 // Delete deletes a Player or many Players
 func (repo *PostgresRepository) Delete(ctx context.Context, d *Deleter) (int64, error) {
-	return repo.delete(ctx, repo.db, d)
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.delete(ctx, runner, d)
 }
 
 // Delete deletes a Player or many Players in a transaction
 func (repo *PostgresRepository) DeleteTx(ctx context.Context, tx nero.Tx, d *Deleter) (int64, error) {
-	txx, ok := tx.(*sql.Tx)
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return 0, errors.New("expecting tx to be *sql.Tx")
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.delete(ctx, txx, d)
 }
 
-func (repo *PostgresRepository) delete(ctx context.Context, runner nero.SQLRunner, d *Deleter) (int64, error) {
-	qb := squirrel.Delete("\"players\"").
+func (repo *PostgresRepository) buildDelete(ctx context.Context, d *Deleter) (squirrel.DeleteBuilder, error) {
+	qb := squirrel.Delete(repo.table()).
 		PlaceholderFormat(squirrel.Dollar)
 
 	preds := []*comparison.Predicate{}
 	for _, predFunc := range d.predFuncs {
 		preds = predFunc(preds)
 	}
-	qb = squirrel.DeleteBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), preds))
+	preds, err := repo.scope(ctx, preds)
+	if err != nil {
+		return qb, err
+	}
+	qb = squirrel.DeleteBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, nil
+}
+
+func (repo *PostgresRepository) delete(ctx context.Context, runner nero.SQLRunner, d *Deleter) (int64, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return 0, err
+	}
 
 	if repo.debug && repo.logger != nil {
 		sql, args, err := qb.ToSql()
@@ -481,16 +1478,62 @@ func (repo *PostgresRepository) delete(ctx context.Context, runner nero.SQLRunne
 	return rowsAffected, nil
 }
 
+// DeleteReturning deletes a Player or many Players and returns the deleted Players
+func (repo *PostgresRepository) DeleteReturning(ctx context.Context, d *Deleter) ([]*player.Player, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.deleteReturning(ctx, runner, d)
+}
+
+// DeleteReturningTx deletes a Player or many Players in a transaction and returns the deleted Players
+func (repo *PostgresRepository) DeleteReturningTx(ctx context.Context, tx nero.Tx, d *Deleter) ([]*player.Player, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.deleteReturning(ctx, txx, d)
+}
+
+func (repo *PostgresRepository) deleteReturning(ctx context.Context, runner nero.SQLRunner, d *Deleter) ([]*player.Player, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	qb = qb.Suffix("RETURNING " + strings.Join(repo.columns(), ","))
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: DeleteReturning, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repo.scan(rows)
+}
+
 // Aggregate runs an aggregate query
 func (repo *PostgresRepository) Aggregate(ctx context.Context, a *Aggregator) error {
-	return repo.aggregate(ctx, repo.db, a)
+	runner, err := repo.readRunner(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	return repo.aggregate(ctx, runner, a)
 }
 
 // Aggregate runs an aggregate query in a transaction
 func (repo *PostgresRepository) AggregateTx(ctx context.Context, tx nero.Tx, a *Aggregator) error {
-	txx, ok := tx.(*sql.Tx)
+	txx, ok := tx.(nero.TxRunner)
 	if !ok {
-		return errors.New("expecting tx to be *sql.Tx")
+		return errors.New("expecting tx to implement nero.TxRunner")
 	}
 
 	return repo.aggregate(ctx, txx, a)
@@ -521,7 +1564,7 @@ func (repo *PostgresRepository) aggregate(ctx context.Context, runner nero.SQLRu
 		}
 	}
 
-	qb := squirrel.Select(columns...).From("\"players\"").
+	qb := squirrel.Select(columns...).From(repo.table()).
 		PlaceholderFormat(squirrel.Dollar)
 
 	groupBys := []string{}
@@ -534,7 +1577,11 @@ func (repo *PostgresRepository) aggregate(ctx context.Context, runner nero.SQLRu
 	for _, predFunc := range a.predFuncs {
 		preds = predFunc(preds)
 	}
-	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), preds))
+	preds, err := repo.scope(ctx, preds)
+	if err != nil {
+		return err
+	}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
 
 	sorts := []*sort.Sort{}
 	for _, sortFunc := range a.sortFuncs {
@@ -576,3 +1623,18 @@ func (repo *PostgresRepository) aggregate(ctx context.Context, runner nero.SQLRu
 
 	return nil
 }
+
+// pgArray wraps the slices with pq.Array so they're sent as arrays,
+// except the []byte and the driver.Valuer values that are sent as is
+func pgArray(v interface{}) interface{} {
+	if _, ok := v.(driver.Valuer); ok {
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		return pq.Array(v)
+	}
+
+	return v
+}
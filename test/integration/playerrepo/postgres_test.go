@@ -4,12 +4,18 @@ package playerrepo_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"log"
 	"testing"
 
 	_ "github.com/lib/pq"
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/test/integration/friendshiprepo"
+	"github.com/sf9v/nero/test/integration/player"
 	"github.com/sf9v/nero/test/integration/playerrepo"
+	"github.com/sf9v/nero/test/integration/teamrepo"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -31,6 +37,7 @@ func TestPostgresRepository(t *testing.T) {
 	repo := playerrepo.NewPostgresRepository(db).Debug().
 		WithLogger(log.New(&bytes.Buffer{}, "", 0))
 	newRepoTestRunner(repo)(t)
+	newDistinctOnTestRunner(repo)(t)
 	require.NoError(t, dropTable(db))
 
 	// tx methods
@@ -38,6 +45,94 @@ func TestPostgresRepository(t *testing.T) {
 	repo = playerrepo.NewPostgresRepository(db).Debug().
 		WithLogger(log.New(&bytes.Buffer{}, "", 0))
 	newRepoTestRunnerTx(repo)(t)
+
+	// row-level locking
+	ctx := context.Background()
+	_, err = repo.Create(ctx, playerrepo.NewCreator().Email("lock@gg.io").
+		Name("lock").Age(20).Race(player.RaceHuman))
+	require.NoError(t, err)
+
+	tx1, err := repo.Tx(ctx)
+	require.NoError(t, err)
+	defer tx1.Rollback()
+	tx2, err := repo.Tx(ctx)
+	require.NoError(t, err)
+	defer tx2.Rollback()
+
+	players, err := repo.QueryTx(ctx, tx1, playerrepo.NewQueryer().
+		ForUpdate().SkipLocked().Limit(1))
+	require.NoError(t, err)
+	require.Len(t, players, 1)
+
+	// the locked row is skipped by the other transaction
+	skipped, err := repo.QueryTx(ctx, tx2, playerrepo.NewQueryer().
+		Where(playerrepo.IDEq(players[0].ID)).ForUpdate().SkipLocked())
+	require.NoError(t, err)
+	assert.Len(t, skipped, 0)
+
+	_, err = repo.QueryTx(ctx, tx2, playerrepo.NewQueryer().
+		Where(playerrepo.IDEq(players[0].ID)).ForShare().NoWait())
+	assert.Error(t, err)
+	require.NoError(t, tx1.Rollback())
+
+	// bulk inserts
+	require.NoError(t, dropTable(db))
+	require.NoError(t, createPgTable(db))
+	newBulkTestRunner(repo)(t)
+
+	// edges
+	require.NoError(t, dropTable(db))
+	require.NoError(t, createPgTable(db))
+	require.NoError(t, createPgEdgeTables(db))
+	newEdgeTestRunner(db, playerrepo.NewPostgresRepository(db),
+		teamrepo.NewPostgresRepository(db))(t)
+	newCompositeKeyTestRunner(friendshiprepo.NewPostgresRepository(db))(t)
+
+	// json fields
+	teamRepo := teamrepo.NewPostgresRepository(db)
+	newJSONTestRunner(teamRepo)(t)
+	newArrayTestRunner(teamRepo)(t)
+	newArrayPredTestRunner(teamRepo)(t)
+	teams, err := teamRepo.Query(nero.ContextWithTenant(ctx, "acme"), teamrepo.NewQueryer().
+		Where(teamrepo.MetaPathEq("region", "na")))
+	require.NoError(t, err)
+	require.Len(t, teams, 1)
+	assert.Equal(t, "JSON", teams[0].Name)
+	newTeamUpsertTestRunner(teamRepo)(t)
+	newTenantTestRunner(teamRepo)(t)
+	require.NoError(t, dropEdgeTables(db))
+
+	// read-only transaction
+	roTx, err := repo.TxWithOptions(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+		ReadOnly:  true,
+	})
+	require.NoError(t, err)
+	_, err = repo.UpdateTx(ctx, roTx, playerrepo.NewUpdater().Name("read-only"))
+	assert.Error(t, err)
+	require.NoError(t, roTx.Rollback())
+
+	// schema
+	_, err = db.Exec(`CREATE SCHEMA game`)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE game.players (LIKE players INCLUDING ALL)`)
+	require.NoError(t, err)
+
+	gameRepo := repo.WithSchema("game")
+	_, err = gameRepo.Create(ctx, playerrepo.NewCreator().Email("game@gg.io").
+		Name("game").Age(20).Race(player.RaceHuman))
+	require.NoError(t, err)
+	players, err = gameRepo.Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("game")))
+	require.NoError(t, err)
+	assert.Len(t, players, 1)
+	players, err = repo.Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("game")))
+	require.NoError(t, err)
+	assert.Len(t, players, 0)
+	_, err = db.Exec(`DROP SCHEMA game CASCADE`)
+	require.NoError(t, err)
+
 	require.NoError(t, dropTable(db))
 }
 
@@ -48,12 +143,46 @@ func createPgTable(db *sql.DB) error {
 		"name" VARCHAR(50) NOT NULL,
 		age INTEGER NOT NULL,
 		"race" VARCHAR(20) NOT NULL,
+		team_id VARCHAR(26),
 		updated_at TIMESTAMP,
 		created_at TIMESTAMP DEFAULT now()
 	)`)
 	return err
 }
 
+func createPgEdgeTables(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE teams (
+		id VARCHAR(26) PRIMARY KEY,
+		"name" VARCHAR(50) NOT NULL,
+		meta JSONB,
+		tags TEXT[],
+		tenant_id VARCHAR(50) NOT NULL,
+		created_at TIMESTAMP DEFAULT now(),
+		UNIQUE (tenant_id, "name")
+	)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE friendships (
+		player_id bigint NOT NULL,
+		friend_id bigint NOT NULL,
+		created_at TIMESTAMP DEFAULT now(),
+		PRIMARY KEY (player_id, friend_id)
+	)`)
+	return err
+}
+
+func dropEdgeTables(db *sql.DB) error {
+	_, err := db.Exec(`drop table teams`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`drop table friendships`)
+	return err
+}
+
 func dropTable(db *sql.DB) error {
 	_, err := db.Exec(`drop table players`)
 	return err
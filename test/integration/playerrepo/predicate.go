@@ -322,6 +322,80 @@ func RaceNotIn(races ...player.Race) comparison.PredFunc {
 	}
 }
 
+// TeamIDEq equal operator on TeamID field
+func TeamIDEq(teamID *string) comparison.PredFunc {
+	return func(preds []*comparison.Predicate) []*comparison.Predicate {
+		return append(preds, &comparison.Predicate{
+			Field: "team_id",
+			Op:    comparison.Eq,
+			Arg:   teamID,
+		})
+	}
+}
+
+// TeamIDNotEq not equal operator on TeamID field
+func TeamIDNotEq(teamID *string) comparison.PredFunc {
+	return func(preds []*comparison.Predicate) []*comparison.Predicate {
+		return append(preds, &comparison.Predicate{
+			Field: "team_id",
+			Op:    comparison.NotEq,
+			Arg:   teamID,
+		})
+	}
+}
+
+// TeamIDIsNull is null operator on TeamID field
+func TeamIDIsNull() comparison.PredFunc {
+	return func(preds []*comparison.Predicate) []*comparison.Predicate {
+		return append(preds, &comparison.Predicate{
+			Field: "team_id",
+			Op:    comparison.IsNull,
+		})
+	}
+}
+
+// TeamIDIsNotNull is not null operator on TeamID field
+func TeamIDIsNotNull() comparison.PredFunc {
+	return func(preds []*comparison.Predicate) []*comparison.Predicate {
+		return append(preds, &comparison.Predicate{
+			Field: "team_id",
+			Op:    comparison.IsNotNull,
+		})
+	}
+}
+
+// TeamIDIn in operator on TeamID field
+func TeamIDIn(teamIDS ...*string) comparison.PredFunc {
+	args := []interface{}{}
+	for _, v := range teamIDS {
+		args = append(args, v)
+	}
+
+	return func(preds []*comparison.Predicate) []*comparison.Predicate {
+		return append(preds, &comparison.Predicate{
+			Field: "team_id",
+			Op:    comparison.In,
+			Arg:   args,
+		})
+	}
+}
+
+// TeamIDNotIn not in operator on TeamID field
+func TeamIDNotIn(teamIDS ...*string) comparison.PredFunc {
+	args := []interface{}{}
+	for _, v := range teamIDS {
+		args = append(args, v)
+	}
+
+	return func(preds []*comparison.Predicate) []*comparison.Predicate {
+		return append(preds, &comparison.Predicate{
+			Field: "team_id",
+			Op:    comparison.NotIn,
+			Arg:   args,
+		})
+	}
+}
+
 // UpdatedAtEq equal operator on UpdatedAt field
 func UpdatedAtEq(updatedAt *time.Time) comparison.PredFunc {
 	return func(preds []*comparison.Predicate) []*comparison.Predicate {
@@ -470,6 +544,69 @@ func CreatedAtNotIn(createdAts ...*time.Time) comparison.PredFunc {
 	}
 }
 
+// HasTeam checks if the team edge has related rows
+func HasTeam() comparison.PredFunc {
+	return HasTeamWith()
+}
+
+// HasTeamWith checks if the team edge has related rows that match the
+// predicates. The predicates are the ones from the repository of the target schema.
+func HasTeamWith(predFuncs ...comparison.PredFunc) comparison.PredFunc {
+	return func(preds []*comparison.Predicate) []*comparison.Predicate {
+		edgePreds := []*comparison.Predicate{}
+		for _, predFunc := range predFuncs {
+			edgePreds = predFunc(edgePreds)
+		}
+
+		return append(preds, &comparison.Predicate{
+			Field: "team",
+			Op:    comparison.Exists,
+			Arg: &comparison.Edge{
+				Name:        "team",
+				Namespace:   "",
+				Collection:  "teams",
+				Column:      "id",
+				Ref:         "team_id",
+				TenantField: "tenant_id",
+				Preds:       edgePreds,
+			},
+		})
+	}
+}
+
+// HasFriends checks if the friends edge has related rows
+func HasFriends() comparison.PredFunc {
+	return HasFriendsWith()
+}
+
+// HasFriendsWith checks if the friends edge has related rows that match the
+// predicates. The predicates are the ones from the repository of the target schema.
+func HasFriendsWith(predFuncs ...comparison.PredFunc) comparison.PredFunc {
+	return func(preds []*comparison.Predicate) []*comparison.Predicate {
+		edgePreds := []*comparison.Predicate{}
+		for _, predFunc := range predFuncs {
+			edgePreds = predFunc(edgePreds)
+		}
+
+		return append(preds, &comparison.Predicate{
+			Field: "friends",
+			Op:    comparison.Exists,
+			Arg: &comparison.Edge{
+				Name:             "friends",
+				Namespace:        "",
+				Collection:       "players",
+				Column:           "id",
+				Ref:              "id",
+				ThroughNamespace: "",
+				Through:          "friendships",
+				ThroughFrom:      "player_id",
+				ThroughTo:        "friend_id",
+				Preds:            edgePreds,
+			},
+		})
+	}
+}
+
 // FieldXEqFieldY fieldX equal fieldY
 //
 // Note: fieldX and fieldY must be of the same type
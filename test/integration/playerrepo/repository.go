@@ -3,6 +3,7 @@ package playerrepo
 
 import (
 	"context"
+	"database/sql"
 	"reflect"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/sf9v/nero/comparison"
 	"github.com/sf9v/nero/sort"
 	"github.com/sf9v/nero/test/integration/player"
+	"github.com/sf9v/nero/validation"
 )
 
 // Repository is an interface that wraps the methods
@@ -20,6 +22,10 @@ import (
 type Repository interface {
 	// Tx begins a new transaction
 	Tx(context.Context) (nero.Tx, error)
+	// TxWithOptions begins a new transaction with the given options
+	TxWithOptions(context.Context, *sql.TxOptions) (nero.Tx, error)
+	// RunInTx runs a function in a transaction and retries it on serialization failures
+	RunInTx(context.Context, *nero.TxOptions, func(nero.Tx) error) error
 	// Create creates a Player
 	Create(context.Context, *Creator) (id string, err error)
 	// CreateTx creates a Player in a transaction
@@ -28,6 +34,10 @@ type Repository interface {
 	CreateMany(context.Context, ...*Creator) error
 	// CreateManyTx batch creates Players in a transaction
 	CreateManyTx(context.Context, nero.Tx, ...*Creator) error
+	// BulkInsert inserts a large number of Players
+	BulkInsert(context.Context, ...*Creator) error
+	// BulkInsertTx inserts a large number of Players in a transaction
+	BulkInsertTx(context.Context, nero.Tx, ...*Creator) error
 	// Query queries Players
 	Query(context.Context, *Queryer) ([]*player.Player, error)
 	// QueryTx queries Players in a transaction
@@ -36,18 +46,46 @@ type Repository interface {
 	QueryOne(context.Context, *Queryer) (*player.Player, error)
 	// QueryOneTx queries a Player in a transaction
 	QueryOneTx(context.Context, nero.Tx, *Queryer) (*player.Player, error)
+	// Exists checks if there's any Player that matches the queryer
+	Exists(context.Context, *Queryer) (bool, error)
+	// ExistsTx checks if there's any Player that matches the queryer in a transaction
+	ExistsTx(context.Context, nero.Tx, *Queryer) (bool, error)
+	// Count counts the Players that match the queryer
+	Count(context.Context, *Queryer) (int64, error)
+	// CountTx counts the Players that match the queryer in a transaction
+	CountTx(context.Context, nero.Tx, *Queryer) (int64, error)
 	// Update updates a Player or many Players
 	Update(context.Context, *Updater) (rowsAffected int64, err error)
 	// UpdateTx updates a Player many Players in a transaction
 	UpdateTx(context.Context, nero.Tx, *Updater) (rowsAffected int64, err error)
+	// UpdateReturning updates a Player or many Players and returns the updated Players
+	UpdateReturning(context.Context, *Updater) ([]*player.Player, error)
+	// UpdateReturningTx updates a Player or many Players in a transaction and returns the updated Players
+	UpdateReturningTx(context.Context, nero.Tx, *Updater) ([]*player.Player, error)
+	// UpdateMany updates many Players with different values
+	UpdateMany(context.Context, []*RowUpdate) (rowsAffected int64, err error)
+	// UpdateManyTx updates many Players with different values in a transaction
+	UpdateManyTx(context.Context, nero.Tx, []*RowUpdate) (rowsAffected int64, err error)
 	// Delete deletes a Player or many Players
 	Delete(context.Context, *Deleter) (rowsAffected int64, err error)
 	// Delete deletes a Player or many Players in a transaction
 	DeleteTx(context.Context, nero.Tx, *Deleter) (rowsAffected int64, err error)
+	// DeleteReturning deletes a Player or many Players and returns the deleted Players
+	DeleteReturning(context.Context, *Deleter) ([]*player.Player, error)
+	// DeleteReturningTx deletes a Player or many Players in a transaction and returns the deleted Players
+	DeleteReturningTx(context.Context, nero.Tx, *Deleter) ([]*player.Player, error)
 	// Aggregate runs an aggregate query
 	Aggregate(context.Context, *Aggregator) error
 	// Aggregate runs an aggregate query in a transaction
 	AggregateTx(context.Context, nero.Tx, *Aggregator) error
+	// QueryByEmail queries a Player by the unique Email index
+	QueryByEmail(context.Context, string) (*player.Player, error)
+	// QueryByEmailTx queries a Player by the unique Email index in a transaction
+	QueryByEmailTx(context.Context, nero.Tx, string) (*player.Player, error)
+	// UpsertByEmail creates a Player or updates the one with the same Email
+	UpsertByEmail(context.Context, *Creator) (id string, err error)
+	// UpsertByEmailTx creates a Player or updates the one with the same Email in a transaction
+	UpsertByEmailTx(context.Context, nero.Tx, *Creator) (id string, err error)
 }
 
 // Creator is a create builder
@@ -56,7 +94,9 @@ type Creator struct {
 	name      string
 	age       int
 	race      player.Race
+	teamID    *string
 	updatedAt *time.Time
+	fields    fieldSet
 }
 
 // NewCreator returns a Creator
@@ -67,61 +107,175 @@ func NewCreator() *Creator {
 // Email sets the Email field
 func (c *Creator) Email(email string) *Creator {
 	c.email = email
+	c.fields.add(FieldEmail)
 	return c
 }
 
 // Name sets the Name field
 func (c *Creator) Name(name string) *Creator {
 	c.name = name
+	c.fields.add(FieldName)
 	return c
 }
 
 // Age sets the Age field
 func (c *Creator) Age(age int) *Creator {
 	c.age = age
+	c.fields.add(FieldAge)
 	return c
 }
 
 // Race sets the Race field
 func (c *Creator) Race(race player.Race) *Creator {
 	c.race = race
+	c.fields.add(FieldRace)
+	return c
+}
+
+// TeamID sets the TeamID field
+func (c *Creator) TeamID(teamID *string) *Creator {
+	c.teamID = teamID
+	c.fields.add(FieldTeamID)
 	return c
 }
 
 // UpdatedAt sets the UpdatedAt field
 func (c *Creator) UpdatedAt(updatedAt *time.Time) *Creator {
 	c.updatedAt = updatedAt
+	c.fields.add(FieldUpdatedAt)
 	return c
 }
 
-// Validate validates the fields
+// Validate validates the fields. The required fields must be set, their zero
+// values are accepted when they are set explicitly.
 func (c *Creator) Validate() error {
 	var err error
-	if isZero(c.email) {
+	if !c.fields.has(FieldEmail) {
 		err = multierror.Append(err, nero.NewErrRequiredField("email"))
 	}
 
-	if isZero(c.name) {
+	if !isZero(c.email) || c.fields.has(FieldEmail) {
+		if e := validateEmail(c.email); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+
+	if !c.fields.has(FieldName) {
 		err = multierror.Append(err, nero.NewErrRequiredField("name"))
 	}
 
-	if isZero(c.age) {
-		err = multierror.Append(err, nero.NewErrRequiredField("age"))
+	if !isZero(c.name) || c.fields.has(FieldName) {
+		if e := validateName(c.name); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+
+	if !isZero(c.age) || c.fields.has(FieldAge) {
+		if e := validateAge(c.age); e != nil {
+			err = multierror.Append(err, e)
+		}
 	}
 
-	if isZero(c.race) {
+	if !c.fields.has(FieldRace) {
 		err = multierror.Append(err, nero.NewErrRequiredField("race"))
 	}
 
+	if !isZero(c.race) || c.fields.has(FieldRace) {
+		if e := validateRace(c.race); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+
+	return err
+}
+
+// RaceValues returns the allowed values of the Race field
+func RaceValues() []player.Race {
+	return []player.Race{
+		player.Race("human"),
+		player.Race("charr"),
+		player.Race("norn"),
+		player.Race("sylvari"),
+		player.Race("titan"),
+	}
+}
+
+// isValidRace returns true if v is one of the allowed values of the Race field
+func isValidRace(v player.Race) bool {
+	for _, value := range RaceValues() {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateEmail validates a Email value
+func validateEmail(v string) error {
+	var err error
+	if !validation.IsEmail(string(v)) {
+		err = multierror.Append(err, &nero.ErrValidation{Field: "email", Rule: "email", Value: v})
+	}
+
+	return err
+}
+
+// validateName validates a Name value
+func validateName(v string) error {
+	var err error
+	if validation.Len(v) > 50 {
+		err = multierror.Append(err, &nero.ErrValidation{Field: "name", Rule: "maxlen", Value: v})
+	}
+
+	return err
+}
+
+// validateAge validates a Age value
+func validateAge(v int) error {
+	var err error
+	if v < 0 {
+		err = multierror.Append(err, &nero.ErrValidation{Field: "age", Rule: "min", Value: v})
+	}
+
+	return err
+}
+
+// validateRace validates a Race value
+func validateRace(v player.Race) error {
+	var err error
+	if !isValidRace(v) {
+		err = multierror.Append(err, nero.NewErrInvalidValue("race", v))
+	}
+
 	return err
 }
 
+// generate sets the fields that were not set to their default values, and
+// the empty fields that have a generator function e.g. the client-supplied identity
+func (c *Creator) generate() error {
+	if !c.fields.has(FieldAge) {
+		c.age = int(18)
+	}
+
+	return nil
+}
+
 // Queryer is a query builder
 type Queryer struct {
-	limit     uint
-	offset    uint
-	predFuncs []comparison.PredFunc
-	sortFuncs []sort.SortFunc
+	limit  uint
+	offset uint
+	forUpdate,
+	forShare,
+	skipLocked,
+	noWait,
+	primary,
+	distinct bool
+	distinctOn  []Field
+	withTeam    bool
+	withFriends bool
+	predFuncs   []comparison.PredFunc
+	sortFuncs   []sort.SortFunc
 }
 
 // NewQueryer returns a Queryer
@@ -153,49 +307,226 @@ func (q *Queryer) Offset(offset uint) *Queryer {
 	return q
 }
 
+// Distinct removes the duplicate rows i.e. SELECT DISTINCT ...
+func (q *Queryer) Distinct() *Queryer {
+	q.distinct = true
+	return q
+}
+
+// DistinctOn keeps the first row of each set of rows where the fields are equal i.e.
+// SELECT DISTINCT ON (...) ..., e.g. the latest row per player when it's sorted by
+// the player and then by the creation time in descending order. The fields must be
+// the leftmost ones of the sort. Back-ends that don't support it (e.g. SQLite)
+// return a *nero.ErrUnsupported error.
+func (q *Queryer) DistinctOn(fields ...Field) *Queryer {
+	q.distinctOn = append(q.distinctOn, fields...)
+	return q
+}
+
+// ForUpdate locks the selected rows for update i.e. SELECT ... FOR UPDATE.
+//
+// Row-level locking is only meaningful inside a transaction. Back-ends
+// that don't support row-level locks (e.g. SQLite) return a
+// *nero.ErrUnsupported error when any of the locking options is set.
+func (q *Queryer) ForUpdate() *Queryer {
+	q.forUpdate, q.forShare = true, false
+	return q
+}
+
+// ForShare locks the selected rows in share mode i.e. SELECT ... FOR SHARE.
+// See ForUpdate for the back-end support policy.
+func (q *Queryer) ForShare() *Queryer {
+	q.forShare, q.forUpdate = true, false
+	return q
+}
+
+// SkipLocked skips the rows that are already locked instead of waiting for them.
+// It only takes effect with ForUpdate or ForShare.
+func (q *Queryer) SkipLocked() *Queryer {
+	q.skipLocked, q.noWait = true, false
+	return q
+}
+
+// NoWait fails immediately instead of waiting for locked rows.
+// It only takes effect with ForUpdate or ForShare.
+func (q *Queryer) NoWait() *Queryer {
+	q.noWait, q.skipLocked = true, false
+	return q
+}
+
+// Primary sends the query to the primary db even if the repository has replicas,
+// e.g. for reading the rows that were just written. Locking queries are always
+// sent to the primary db.
+func (q *Queryer) Primary() *Queryer {
+	q.primary = true
+	return q
+}
+
+// WithTeam eager loads the team edge into the Team
+// field. The related rows are loaded with a single query for all the results.
+func (q *Queryer) WithTeam() *Queryer {
+	q.withTeam = true
+	return q
+}
+
+// WithFriends eager loads the friends edge into the Friends
+// field. The related rows are loaded with a single query for all the results.
+func (q *Queryer) WithFriends() *Queryer {
+	q.withFriends = true
+	return q
+}
+
 // Updater is an update builder
 type Updater struct {
 	email     string
 	name      string
 	age       int
 	race      player.Race
+	teamID    *string
 	updatedAt *time.Time
+	fields    fieldSet
+	exprs     []*updateExpr
 	predFuncs []comparison.PredFunc
 }
 
+// updateExpr is an update expression
+type updateExpr struct {
+	field Field
+	// delta is the increment added by the AddX methods
+	delta interface{}
+	// expr and args are set by SetExpr
+	expr string
+	args []interface{}
+}
+
 // NewUpdater returns an Updater
 func NewUpdater() *Updater {
 	return &Updater{}
 }
 
 // Email sets the Email field
-func (c *Updater) Email(email string) *Updater {
-	c.email = email
-	return c
+func (u *Updater) Email(email string) *Updater {
+	u.email = email
+	u.set(FieldEmail)
+	return u
 }
 
 // Name sets the Name field
-func (c *Updater) Name(name string) *Updater {
-	c.name = name
-	return c
+func (u *Updater) Name(name string) *Updater {
+	u.name = name
+	u.set(FieldName)
+	return u
 }
 
 // Age sets the Age field
-func (c *Updater) Age(age int) *Updater {
-	c.age = age
-	return c
+func (u *Updater) Age(age int) *Updater {
+	u.age = age
+	u.set(FieldAge)
+	return u
+}
+
+// AddAge atomically adds n to the Age field
+func (u *Updater) AddAge(n int) *Updater {
+	u.setExpr(&updateExpr{field: FieldAge, delta: n})
+	return u
 }
 
 // Race sets the Race field
-func (c *Updater) Race(race player.Race) *Updater {
-	c.race = race
-	return c
+func (u *Updater) Race(race player.Race) *Updater {
+	u.race = race
+	u.set(FieldRace)
+	return u
+}
+
+// TeamID sets the TeamID field
+func (u *Updater) TeamID(teamID *string) *Updater {
+	u.teamID = teamID
+	u.set(FieldTeamID)
+	return u
+}
+
+// ClearTeamID sets the TeamID field to null
+func (u *Updater) ClearTeamID() *Updater {
+	u.teamID = nil
+	u.set(FieldTeamID)
+	return u
 }
 
 // UpdatedAt sets the UpdatedAt field
-func (c *Updater) UpdatedAt(updatedAt *time.Time) *Updater {
-	c.updatedAt = updatedAt
-	return c
+func (u *Updater) UpdatedAt(updatedAt *time.Time) *Updater {
+	u.updatedAt = updatedAt
+	u.set(FieldUpdatedAt)
+	return u
+}
+
+// ClearUpdatedAt sets the UpdatedAt field to null
+func (u *Updater) ClearUpdatedAt() *Updater {
+	u.updatedAt = nil
+	u.set(FieldUpdatedAt)
+	return u
+}
+
+// SetExpr sets the field to a raw SQL expression e.g. SetExpr(FieldScore, "score * ?", 2).
+// The expression is evaluated by the database so the update is atomic.
+func (u *Updater) SetExpr(field Field, expr string, args ...interface{}) *Updater {
+	u.setExpr(&updateExpr{field: field, expr: expr, args: args})
+	return u
+}
+
+// set marks the field as set to its value. A field is assigned once per update,
+// so the value replaces the previous expression of the field.
+func (u *Updater) set(field Field) {
+	u.fields.add(field)
+	u.removeExpr(field)
+}
+
+// setExpr sets the expression of the field, which replaces
+// the previous value or expression of the field
+func (u *Updater) setExpr(e *updateExpr) {
+	u.fields.remove(e.field)
+	u.removeExpr(e.field)
+	u.exprs = append(u.exprs, e)
+}
+
+// removeExpr removes the expression of the field
+func (u *Updater) removeExpr(field Field) {
+	exprs := u.exprs[:0]
+	for _, e := range u.exprs {
+		if e.field != field {
+			exprs = append(exprs, e)
+		}
+	}
+	u.exprs = exprs
+}
+
+// Validate validates the fields that are set
+func (u *Updater) Validate() error {
+	var err error
+	if u.fields.has(FieldEmail) {
+		if e := validateEmail(u.email); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+
+	if u.fields.has(FieldName) {
+		if e := validateName(u.name); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+
+	if u.fields.has(FieldAge) {
+		if e := validateAge(u.age); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+
+	if u.fields.has(FieldRace) {
+		if e := validateRace(u.race); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+
+	return err
 }
 
 // Where applies predicates
@@ -204,6 +535,100 @@ func (u *Updater) Where(predFuncs ...comparison.PredFunc) *Updater {
 	return u
 }
 
+// RowUpdate is the update of a single Player that's identified by its key. Unlike
+// the Updater, each of the Players that are passed to UpdateMany gets its own values.
+type RowUpdate struct {
+	key       string
+	email     string
+	name      string
+	age       int
+	race      player.Race
+	teamID    *string
+	updatedAt *time.Time
+	fields    fieldSet
+}
+
+// NewRowUpdate returns a RowUpdate of the Player with the given key
+func NewRowUpdate(key string) *RowUpdate {
+	return &RowUpdate{key: key}
+}
+
+// Email sets the Email field
+func (ru *RowUpdate) Email(email string) *RowUpdate {
+	ru.email = email
+	ru.fields.add(FieldEmail)
+	return ru
+}
+
+// Name sets the Name field
+func (ru *RowUpdate) Name(name string) *RowUpdate {
+	ru.name = name
+	ru.fields.add(FieldName)
+	return ru
+}
+
+// Age sets the Age field
+func (ru *RowUpdate) Age(age int) *RowUpdate {
+	ru.age = age
+	ru.fields.add(FieldAge)
+	return ru
+}
+
+// Race sets the Race field
+func (ru *RowUpdate) Race(race player.Race) *RowUpdate {
+	ru.race = race
+	ru.fields.add(FieldRace)
+	return ru
+}
+
+// TeamID sets the TeamID field
+func (ru *RowUpdate) TeamID(teamID *string) *RowUpdate {
+	ru.teamID = teamID
+	ru.fields.add(FieldTeamID)
+	return ru
+}
+
+// ClearTeamID sets the TeamID field to null
+func (ru *RowUpdate) ClearTeamID() *RowUpdate {
+	ru.teamID = nil
+	ru.fields.add(FieldTeamID)
+	return ru
+}
+
+// UpdatedAt sets the UpdatedAt field
+func (ru *RowUpdate) UpdatedAt(updatedAt *time.Time) *RowUpdate {
+	ru.updatedAt = updatedAt
+	ru.fields.add(FieldUpdatedAt)
+	return ru
+}
+
+// ClearUpdatedAt sets the UpdatedAt field to null
+func (ru *RowUpdate) ClearUpdatedAt() *RowUpdate {
+	ru.updatedAt = nil
+	ru.fields.add(FieldUpdatedAt)
+	return ru
+}
+
+// Validate validates the fields that are set
+func (ru *RowUpdate) Validate() error {
+	return ru.updater().Validate()
+}
+
+// updater returns the Updater of the Player with the values of the row update
+func (ru *RowUpdate) updater() *Updater {
+	u := &Updater{
+		email:     ru.email,
+		name:      ru.name,
+		age:       ru.age,
+		race:      ru.race,
+		teamID:    ru.teamID,
+		updatedAt: ru.updatedAt,
+		fields:    ru.fields,
+	}
+
+	return u.Where(IDEq(ru.key))
+}
+
 // Deleter is a delete builder
 type Deleter struct {
 	predFuncs []comparison.PredFunc
@@ -259,6 +684,56 @@ func (a *Aggregator) GroupBy(fields ...Field) *Aggregator {
 	return a
 }
 
+// runInTx runs fn in a transaction and commits it if fn succeeds,
+// otherwise the transaction is rolled back. The whole transaction is
+// retried when the returned error is retryable.
+func runInTx(ctx context.Context, beginTx func(context.Context, *sql.TxOptions) (nero.Tx, error),
+	isRetryable func(error) bool, opts *nero.TxOptions, fn func(nero.Tx) error) error {
+	if opts == nil {
+		opts = &nero.TxOptions{}
+	}
+
+	txOpts := &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+	for retry := 1; ; retry++ {
+		err := runTx(ctx, func(ctx context.Context) (nero.Tx, error) {
+			return beginTx(ctx, txOpts)
+		}, fn)
+		if err == nil || retry > opts.MaxRetries || !isRetryable(err) {
+			return err
+		}
+
+		if opts.Backoff != nil {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(opts.Backoff(retry)):
+			}
+		}
+	}
+}
+
+// runTx runs fn in a transaction and rolls it back on error or panic
+func runTx(ctx context.Context, beginTx func(context.Context) (nero.Tx, error), fn func(nero.Tx) error) error {
+	tx, err := beginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		return rollback(tx, err)
+	}
+
+	return tx.Commit()
+}
+
 // rollback performs a rollback
 func rollback(tx nero.Tx, err error) error {
 	rerr := tx.Rollback()
@@ -268,6 +743,68 @@ func rollback(tx nero.Tx, err error) error {
 	return err
 }
 
+// fieldSet is a bitset of fields
+type fieldSet []uint64
+
+// add adds the field to the set
+func (s *fieldSet) add(f Field) {
+	i := int(f) / 64
+	for len(*s) <= i {
+		*s = append(*s, 0)
+	}
+	(*s)[i] |= 1 << (uint(f) % 64)
+}
+
+// remove removes the field from the set
+func (s fieldSet) remove(f Field) {
+	i := int(f) / 64
+	if i < len(s) {
+		s[i] &^= 1 << (uint(f) % 64)
+	}
+}
+
+// has returns true if the field is in the set
+func (s fieldSet) has(f Field) bool {
+	i := int(f) / 64
+	return i < len(s) && s[i]&(1<<(uint(f)%64)) != 0
+}
+
+// contains returns true if the column is in the list
+func contains(columns []string, column string) bool {
+	for _, col := range columns {
+		if col == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// chunkCreators splits the creators into chunks of at most size creators
+func chunkCreators(cs []*Creator, size int) [][]*Creator {
+	if size < 1 {
+		size = 1
+	}
+
+	chunks := [][]*Creator{}
+	for size < len(cs) {
+		cs, chunks = cs[size:], append(chunks, cs[:size])
+	}
+
+	return append(chunks, cs)
+}
+
+// anyHas returns true if the field is set by any of the row updates
+func anyHas(rus []*RowUpdate, f Field) bool {
+	for _, ru := range rus {
+		if ru.fields.has(f) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // isZero checks if v is a zero-value
 func isZero(v interface{}) bool {
 	return reflect.ValueOf(v).IsZero()
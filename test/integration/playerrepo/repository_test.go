@@ -1,13 +1,31 @@
 package playerrepo
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	multierror "github.com/hashicorp/go-multierror"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/test/integration/player"
 )
 
-type errTx struct{}
+// nopSavepoints implements the nero.Tx savepoint methods
+type nopSavepoints struct{}
+
+func (nopSavepoints) Savepoint(string) error  { return nil }
+func (nopSavepoints) RollbackTo(string) error { return nil }
+func (nopSavepoints) Release(string) error    { return nil }
+
+type errTx struct {
+	nopSavepoints
+}
 
 func (et *errTx) Commit() error {
 	return nil
@@ -17,7 +35,9 @@ func (et *errTx) Rollback() error {
 	return errors.New("tx error")
 }
 
-type okTx struct{}
+type okTx struct {
+	nopSavepoints
+}
 
 func (ot *okTx) Commit() error {
 	return nil
@@ -36,3 +56,176 @@ func Test_rollback(t *testing.T) {
 	err = rollback(o, errors.New("an error"))
 	assert.Equal(t, "an error", err.Error())
 }
+
+func Test_fieldSet(t *testing.T) {
+	var fs fieldSet
+	assert.False(t, fs.has(FieldAge))
+
+	fs.add(FieldAge)
+	fs.add(Field(130))
+	assert.True(t, fs.has(FieldAge))
+	assert.True(t, fs.has(Field(130)))
+	assert.False(t, fs.has(FieldName))
+	assert.False(t, fs.has(Field(129)))
+	assert.Len(t, fs, 3)
+}
+
+func Test_validateEnum(t *testing.T) {
+	assert.Len(t, RaceValues(), 5)
+	assert.True(t, isValidRace(player.RaceHuman))
+	assert.False(t, isValidRace(player.Race("orc")))
+
+	c := NewCreator().Email("enum@gg.io").Name("enum").Age(20).Race(player.Race("orc"))
+	var errInvalid *nero.ErrInvalidValue
+	assert.True(t, errors.As(c.Validate(), &errInvalid))
+	assert.NoError(t, c.Race(player.RaceNorn).Validate())
+
+	// the zero value is reported as a required field instead
+	err := NewCreator().Email("enum@gg.io").Name("enum").Age(20).Validate()
+	assert.False(t, errors.As(err, &errInvalid))
+
+	u := NewUpdater().Race(player.Race("orc"))
+	assert.True(t, errors.As(u.Validate(), &errInvalid))
+	assert.NoError(t, NewUpdater().Race(player.RaceTitan).Validate())
+	assert.NoError(t, NewUpdater().Name("enum").Validate())
+}
+
+func Test_validateRules(t *testing.T) {
+	c := NewCreator().Email("not an email").Name(strings.Repeat("a", 51)).
+		Age(-1).Race(player.RaceNorn)
+	err := c.Validate()
+	var merr *multierror.Error
+	require.True(t, errors.As(err, &merr))
+	require.Len(t, merr.Errors, 3)
+
+	rules := []string{}
+	for _, err := range merr.Errors {
+		var errValidation *nero.ErrValidation
+		require.True(t, errors.As(err, &errValidation))
+		rules = append(rules, errValidation.Field+":"+errValidation.Rule)
+	}
+	assert.Equal(t, []string{"email:email", "name:maxlen", "age:min"}, rules)
+
+	assert.NoError(t, c.Email("rules@gg.io").Name("rules").Age(20).Validate())
+
+	var errValidation *nero.ErrValidation
+	assert.True(t, errors.As(NewUpdater().Age(-1).Validate(), &errValidation))
+	assert.NoError(t, NewUpdater().Age(0).Validate())
+}
+
+func TestCreator_generate(t *testing.T) {
+	c := NewCreator().Email("default@gg.io").Name("default").Race(player.RaceNorn)
+	assert.NoError(t, c.Validate())
+	assert.NoError(t, c.generate())
+	assert.Equal(t, 18, c.age)
+
+	// zero is a valid value once it's set
+	c = NewCreator().Email("default@gg.io").Name("default").Race(player.RaceNorn).Age(0)
+	assert.NoError(t, c.Validate())
+	assert.NoError(t, c.generate())
+	assert.Equal(t, 0, c.age)
+
+	// including the zero values of the required fields
+	c = NewCreator().Email("zero@gg.io").Name("").Race(player.RaceNorn)
+	assert.NoError(t, c.Validate())
+
+	var errRequired *nero.ErrRequiredField
+	assert.True(t, errors.As(NewCreator().Validate(), &errRequired))
+	assert.True(t, errors.As(NewCreator().Email("zero@gg.io").Race(player.RaceNorn).Validate(), &errRequired))
+}
+
+type countTx struct {
+	nopSavepoints
+	commits, rollbacks int
+}
+
+func (ct *countTx) Commit() error {
+	ct.commits++
+	return nil
+}
+
+func (ct *countTx) Rollback() error {
+	ct.rollbacks++
+	return nil
+}
+
+func Test_runInTx(t *testing.T) {
+	ctx := context.Background()
+	errRetry := errors.New("retry")
+	isRetryable := func(err error) bool {
+		return errors.Is(err, errRetry)
+	}
+
+	var tx *countTx
+	begins := 0
+	beginTx := func(context.Context, *sql.TxOptions) (nero.Tx, error) {
+		begins++
+		tx = &countTx{}
+		return tx, nil
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		begins = 0
+		err := runInTx(ctx, beginTx, isRetryable, nil, func(nero.Tx) error {
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, begins)
+		assert.Equal(t, 1, tx.commits)
+		assert.Equal(t, 0, tx.rollbacks)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		begins = 0
+		err := runInTx(ctx, beginTx, isRetryable, nil, func(nero.Tx) error {
+			return errors.New("an error")
+		})
+		assert.EqualError(t, err, "an error")
+		assert.Equal(t, 1, begins)
+		assert.Equal(t, 0, tx.commits)
+		assert.Equal(t, 1, tx.rollbacks)
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		assert.PanicsWithValue(t, "a panic", func() {
+			_ = runInTx(ctx, beginTx, isRetryable, nil, func(nero.Tx) error {
+				panic("a panic")
+			})
+		})
+		assert.Equal(t, 0, tx.commits)
+		assert.Equal(t, 1, tx.rollbacks)
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		begins = 0
+		opts := &nero.TxOptions{
+			MaxRetries: 2,
+			Backoff:    nero.ConstantBackoff(time.Millisecond),
+		}
+		err := runInTx(ctx, beginTx, isRetryable, opts, func(nero.Tx) error {
+			return errRetry
+		})
+		assert.True(t, errors.Is(err, errRetry))
+		assert.Equal(t, 3, begins)
+
+		begins = 0
+		err = runInTx(ctx, beginTx, isRetryable, opts, func(nero.Tx) error {
+			if begins < 2 {
+				return errRetry
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, begins)
+		assert.Equal(t, 1, tx.commits)
+	})
+
+	t.Run("Begin error", func(t *testing.T) {
+		err := runInTx(ctx, func(context.Context, *sql.TxOptions) (nero.Tx, error) {
+			return nil, errors.New("begin error")
+		}, isRetryable, nil, func(nero.Tx) error {
+			return nil
+		})
+		assert.EqualError(t, err, "begin error")
+	})
+}
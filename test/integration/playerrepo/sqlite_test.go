@@ -4,12 +4,19 @@ package playerrepo_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/comparison"
+	"github.com/sf9v/nero/test/integration/friendshiprepo"
+	"github.com/sf9v/nero/test/integration/player"
 	"github.com/sf9v/nero/test/integration/playerrepo"
+	"github.com/sf9v/nero/test/integration/teamrepo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -43,20 +50,210 @@ func TestSQLiteRepository(t *testing.T) {
 	repo = playerrepo.NewSQLiteRepository(db).Debug().
 		WithLogger(log.New(&bytes.Buffer{}, "", 0))
 	newRepoTestRunnerTx(repo)(t)
+
+	// tx from context
+	ctx := context.Background()
+	_, err = repo.Create(ctx, playerrepo.NewCreator().Email("ctx@gg.io").
+		Name("ctx").Age(20).Race(player.RaceHuman))
+	require.NoError(t, err)
+
+	repo = repo.WithTxFromContext()
+	tx, err := repo.Tx(ctx)
+	require.NoError(t, err)
+	txCtx := nero.ContextWithTx(ctx, tx)
+	_, err = repo.Update(txCtx, playerrepo.NewUpdater().Name("ctx updated"))
+	require.NoError(t, err)
+	players, err := repo.Query(txCtx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("ctx updated")))
+	require.NoError(t, err)
+	assert.Len(t, players, 1)
+	require.NoError(t, tx.Rollback())
+
+	players, err = repo.Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("ctx")))
+	require.NoError(t, err)
+	assert.Len(t, players, 1)
+
+	// *sql.Conn as base handle
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	connRepo := playerrepo.NewSQLiteRepository(conn)
+	_, err = connRepo.Create(ctx, playerrepo.NewCreator().Email("conn@gg.io").
+		Name("conn").Age(20).Race(player.RaceHuman))
+	require.NoError(t, err)
+	players, err = connRepo.Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("conn")))
+	require.NoError(t, err)
+	assert.Len(t, players, 1)
+	require.NoError(t, conn.Close())
+
+	// *sql.Tx as base handle, Tx begins a nested transaction
+	sqlTx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	txRepo := playerrepo.NewSQLiteRepository(sqlTx)
+	nestedTx, err := txRepo.Tx(ctx)
+	require.NoError(t, err)
+	_, err = txRepo.CreateTx(ctx, nestedTx, playerrepo.NewCreator().
+		Email("nested@gg.io").Name("nested").Age(20).Race(player.RaceHuman))
+	require.NoError(t, err)
+	require.NoError(t, nestedTx.Rollback())
+	players, err = txRepo.Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("nested")))
+	require.NoError(t, err)
+	assert.Len(t, players, 0)
+
+	nestedTx, err = txRepo.Tx(ctx)
+	require.NoError(t, err)
+	_, err = txRepo.CreateTx(ctx, nestedTx, playerrepo.NewCreator().
+		Email("nested@gg.io").Name("nested").Age(20).Race(player.RaceHuman))
+	require.NoError(t, err)
+	require.NoError(t, nestedTx.Commit())
+	require.NoError(t, sqlTx.Commit())
+	players, err = repo.Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("nested")))
+	require.NoError(t, err)
+	assert.Len(t, players, 1)
+
+	// replicas
+	replica, err := sql.Open("sqlite3", "file:replica.db?mode=memory&cache=shared")
+	require.NoError(t, err)
+	defer replica.Close()
+	require.NoError(t, createSqliteTable(replica))
+
+	replicaRepo := playerrepo.NewSQLiteRepository(db).WithReplicas(replica)
+	_, err = replicaRepo.Create(ctx, playerrepo.NewCreator().Email("replica@gg.io").
+		Name("replica").Age(20).Race(player.RaceHuman))
+	require.NoError(t, err)
+	players, err = replicaRepo.Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("replica")))
+	require.NoError(t, err)
+	assert.Len(t, players, 0)
+	players, err = replicaRepo.Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("replica")).Primary())
+	require.NoError(t, err)
+	assert.Len(t, players, 1)
+	require.NoError(t, dropTable(replica))
+
+	// attached database as the schema
+	conn, err = db.Conn(ctx)
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "ATTACH DATABASE 'file:game.db?mode=memory&cache=shared' AS game")
+	require.NoError(t, err)
+	require.NoError(t, createSqliteTableIn(conn, "game"))
+
+	gameRepo := playerrepo.NewSQLiteRepository(conn).WithSchema("game")
+	_, err = gameRepo.Create(ctx, playerrepo.NewCreator().Email("game@gg.io").
+		Name("game").Age(20).Race(player.RaceHuman))
+	require.NoError(t, err)
+	players, err = gameRepo.Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("game")))
+	require.NoError(t, err)
+	assert.Len(t, players, 1)
+	players, err = playerrepo.NewSQLiteRepository(conn).Query(ctx, playerrepo.NewQueryer().
+		Where(playerrepo.NameEq("game")))
+	require.NoError(t, err)
+	assert.Len(t, players, 0)
+	rowsAffected, err := gameRepo.Delete(ctx, playerrepo.NewDeleter())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rowsAffected)
+	require.NoError(t, conn.Close())
+
+	// bulk inserts
+	require.NoError(t, dropTable(db))
+	require.NoError(t, createSqliteTable(db))
+	newBulkTestRunner(repo)(t)
+
+	// edges
+	require.NoError(t, dropTable(db))
+	require.NoError(t, createSqliteTable(db))
+	require.NoError(t, createSqliteEdgeTables(db))
+	newEdgeTestRunner(db, playerrepo.NewSQLiteRepository(db),
+		teamrepo.NewSQLiteRepository(db))(t)
+	newCompositeKeyTestRunner(friendshiprepo.NewSQLiteRepository(db))(t)
+	newJSONTestRunner(teamrepo.NewSQLiteRepository(db))(t)
+	newArrayTestRunner(teamrepo.NewSQLiteRepository(db))(t)
+	newTeamUpsertTestRunner(teamrepo.NewSQLiteRepository(db))(t)
+	newTenantTestRunner(teamrepo.NewSQLiteRepository(db))(t)
+
+	// the slices are stored as JSON, so the array operators are not supported
+	for _, predFunc := range []comparison.PredFunc{
+		teamrepo.TagsContains([]string{"eu"}),
+		teamrepo.TagsContainedBy([]string{"eu"}),
+		teamrepo.TagsOverlap([]string{"eu"}),
+		teamrepo.TagsAnyEq("eu"),
+	} {
+		_, err := teamrepo.NewSQLiteRepository(db).Query(
+			nero.ContextWithTenant(ctx, "acme"), teamrepo.NewQueryer().Where(predFunc))
+		var errUnsupported *nero.ErrUnsupported
+		assert.True(t, errors.As(err, &errUnsupported))
+	}
+	require.NoError(t, dropEdgeTables(db))
+
+	// row-level locking is not supported
+	_, err = repo.Query(ctx, playerrepo.NewQueryer().
+		ForUpdate().SkipLocked())
+	var errUnsupported *nero.ErrUnsupported
+	assert.True(t, errors.As(err, &errUnsupported))
+
+	// nor DISTINCT ON
+	_, err = repo.Query(ctx, playerrepo.NewQueryer().
+		DistinctOn(playerrepo.FieldRace))
+	assert.True(t, errors.As(err, &errUnsupported))
+
+	// sqlite has no array type
+	_, err = repo.Query(ctx, playerrepo.NewQueryer().Where(
+		func(preds []*comparison.Predicate) []*comparison.Predicate {
+			return append(preds, &comparison.Predicate{
+				Field: "name", Op: comparison.AnyEq, Arg: "x",
+			})
+		}))
+	assert.True(t, errors.As(err, &errUnsupported))
+
 	require.NoError(t, dropTable(db))
 }
 
 func createSqliteTable(db *sql.DB) error {
-	_, err := db.Exec(`
-		CREATE TABLE players (
+	return createSqliteTableIn(db, "main")
+}
+
+func createSqliteTableIn(db nero.DB, schema string) error {
+	_, err := db.ExecContext(context.Background(), `
+		CREATE TABLE `+nero.QuoteIdent(schema)+`.players (
 		id INTEGER PRIMARY KEY,
 		email TEXT NOT NULL UNIQUE,
 		"name" TEXT NOT NULL,
 		age INTEGER NOT NULL,
 		race TEXT NOT NULL,
+		team_id TEXT NULL,
 		updated_at DATETIME NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`)
 
 	return err
 }
+
+func createSqliteEdgeTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE teams (
+		id TEXT PRIMARY KEY,
+		"name" TEXT NOT NULL,
+		meta TEXT NULL,
+		tags TEXT NULL,
+		tenant_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (tenant_id, "name")
+	)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE friendships (
+		player_id INTEGER NOT NULL,
+		friend_id INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (player_id, friend_id)
+	)`)
+
+	return err
+}
@@ -0,0 +1,77 @@
+package teamrepo
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sf9v/nero/comparison"
+)
+
+func TestArrayPreds(t *testing.T) {
+	tags := []string{"eu", "pro"}
+	tests := []struct {
+		name     string
+		predFunc comparison.PredFunc
+		wantSQL  string
+		wantArg  interface{}
+	}{
+		{
+			name:     "Contains",
+			predFunc: TagsContains(tags),
+			wantSQL:  `SELECT "id" FROM "teams" WHERE "tags" @> $1`,
+			wantArg:  tags,
+		},
+		{
+			name:     "ContainedBy",
+			predFunc: TagsContainedBy(tags),
+			wantSQL:  `SELECT "id" FROM "teams" WHERE "tags" <@ $1`,
+			wantArg:  tags,
+		},
+		{
+			name:     "Overlap",
+			predFunc: TagsOverlap(tags),
+			wantSQL:  `SELECT "id" FROM "teams" WHERE "tags" && $1`,
+			wantArg:  tags,
+		},
+		{
+			name:     "AnyEq",
+			predFunc: TagsAnyEq("eu"),
+			wantSQL:  `SELECT "id" FROM "teams" WHERE $1 = ANY("tags")`,
+			wantArg:  "eu",
+		},
+	}
+
+	toSQL := func(t *testing.T, sb squirrel.StatementBuilderType) (string, []interface{}) {
+		sql, args, err := sb.PlaceholderFormat(squirrel.Dollar).
+			Select("\"id\"").From("\"teams\"").ToSql()
+		require.NoError(t, err)
+		require.Len(t, args, 1)
+		return sql, args
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			preds := tc.predFunc(nil)
+
+			// the slices are sent as pq arrays with lib/pq
+			sql, args := toSQL(t, (&PostgresRepository{}).
+				buildPreds(squirrel.StatementBuilder, "", preds))
+			assert.Equal(t, tc.wantSQL, sql)
+			if s, ok := tc.wantArg.([]string); ok {
+				assert.Equal(t, pq.Array(s), args[0])
+			} else {
+				assert.Equal(t, tc.wantArg, args[0])
+			}
+
+			// and as is with pgx
+			sql, args = toSQL(t, (&PgxRepository{}).
+				buildPreds(squirrel.StatementBuilder, "", preds))
+			assert.Equal(t, tc.wantSQL, sql)
+			assert.Equal(t, tc.wantArg, args[0])
+		})
+	}
+}
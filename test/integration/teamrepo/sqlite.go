@@ -0,0 +1,1483 @@
+// Code generated by nero, DO NOT EDIT.
+package teamrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/sf9v/nero"
+	"github.com/sf9v/nero/aggregate"
+	"github.com/sf9v/nero/comparison"
+	"github.com/sf9v/nero/sort"
+	"github.com/sf9v/nero/test/integration/player"
+)
+
+// SQLiteRepository is a repository that uses SQLite3 as data store
+type SQLiteRepository struct {
+	db              nero.DB
+	logger          nero.Logger
+	debug           bool
+	txFromContext   bool
+	replicas        []nero.DB
+	balancer        nero.Balancer
+	schema          string
+	tenantExtractor nero.TenantExtractor
+}
+
+var _ Repository = (*SQLiteRepository)(nil)
+
+// NewSQLiteRepository returns a new SQLiteRepository where db
+// can be a *sql.DB, a *sql.Conn, a *sql.Tx or any nero.DB
+func NewSQLiteRepository(db nero.DB) *SQLiteRepository {
+	return &SQLiteRepository{db: db}
+}
+
+// Debug enables debug mode
+func (repo *SQLiteRepository) Debug() *SQLiteRepository {
+	l := log.New(os.Stdout, "[nero] ", log.LstdFlags|log.Lmicroseconds|log.Lmsgprefix)
+	r := *repo
+	r.debug = true
+	r.logger = l
+	return &r
+}
+
+// WithLogger overrides the default logger
+func (repo *SQLiteRepository) WithLogger(logger nero.Logger) *SQLiteRepository {
+	repo.logger = logger
+	return repo
+}
+
+// WithTxFromContext enables the tx-from-context mode where the non-Tx methods
+// run in the transaction carried by the context (see nero.ContextWithTx), if any
+func (repo *SQLiteRepository) WithTxFromContext() *SQLiteRepository {
+	repo.txFromContext = true
+	return repo
+}
+
+// WithReplicas sets the read-only replicas. Query, QueryOne and Aggregate are
+// sent to a replica chosen by the balancer (round-robin by default), while the
+// writes and the queries in a transaction are sent to the primary db.
+func (repo *SQLiteRepository) WithReplicas(dbs ...nero.DB) *SQLiteRepository {
+	repo.replicas = dbs
+	if repo.balancer == nil {
+		repo.balancer = nero.RoundRobinBalancer()
+	}
+	return repo
+}
+
+// WithBalancer overrides the balancer used for picking the replicas
+func (repo *SQLiteRepository) WithBalancer(balancer nero.Balancer) *SQLiteRepository {
+	repo.balancer = balancer
+	return repo
+}
+
+// WithSchema returns a copy of the repository that qualifies the tables with the
+// schema e.g. a per-tenant schema on PostgreSQL or an attached database on SQLite
+func (repo *SQLiteRepository) WithSchema(schema string) *SQLiteRepository {
+	r := *repo
+	r.schema = schema
+	return &r
+}
+
+// table returns the quoted name of the teams table
+func (repo *SQLiteRepository) table() string {
+	return repo.qualify("", "teams")
+}
+
+// qualify quotes the table name and qualifies it with the schema i.e. "schema"."table".
+// The schema that's set by WithSchema takes precedence over the namespace.
+func (repo *SQLiteRepository) qualify(namespace, table string) string {
+	if repo.schema != "" {
+		namespace = repo.schema
+	}
+
+	if namespace == "" {
+		return nero.QuoteIdent(table)
+	}
+
+	return nero.QuoteIdent(namespace) + "." + nero.QuoteIdent(table)
+}
+
+// WithTenantExtractor overrides the function that extracts the tenant ID
+// from the context, which is nero.TenantFromContext by default
+func (repo *SQLiteRepository) WithTenantExtractor(extractor nero.TenantExtractor) *SQLiteRepository {
+	repo.tenantExtractor = extractor
+	return repo
+}
+
+// tenant returns the tenant ID that's carried by the context
+func (repo *SQLiteRepository) tenant(ctx context.Context) (string, error) {
+	extract := repo.tenantExtractor
+	if extract == nil {
+		extract = nero.TenantFromContext
+	}
+
+	v, err := extract(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	tenantID, ok := v.(string)
+	if !ok {
+		return "", errors.Errorf("expecting tenant ID to be a string, got %T", v)
+	}
+
+	return tenantID, nil
+}
+
+// setTenant sets the TenantID field of the creator to the tenant ID
+func (repo *SQLiteRepository) setTenant(ctx context.Context, c *Creator) error {
+	tenantID, err := repo.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.tenantID = tenantID
+	return nil
+}
+
+// edgeTenant returns the tenant ID that scopes the edges to the tenant-scoped schemas
+func (repo *SQLiteRepository) edgeTenant(ctx context.Context) (interface{}, error) {
+	return repo.tenant(ctx)
+}
+
+// scopeEdges appends the predicate of the tenant to the Has<Edge>With
+// predicates of the tenant-scoped targets, including the nested ones
+func (repo *SQLiteRepository) scopeEdges(ctx context.Context, preds []*comparison.Predicate) ([]*comparison.Predicate, error) {
+	scoped := make([]*comparison.Predicate, 0, len(preds))
+	for _, pred := range preds {
+		edge, ok := pred.Arg.(*comparison.Edge)
+		if pred.Op != comparison.Exists || !ok {
+			scoped = append(scoped, pred)
+			continue
+		}
+
+		edgePreds, err := repo.scopeEdges(ctx, edge.Preds)
+		if err != nil {
+			return nil, err
+		}
+
+		if edge.TenantField != "" {
+			tenantID, err := repo.edgeTenant(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			edgePreds = append(edgePreds, &comparison.Predicate{
+				Field: edge.TenantField, Op: comparison.Eq, Arg: tenantID,
+			})
+		}
+
+		scopedEdge := *edge
+		scopedEdge.Preds = edgePreds
+		scoped = append(scoped, &comparison.Predicate{Field: pred.Field, Op: pred.Op, Arg: &scopedEdge})
+	}
+
+	return scoped, nil
+}
+
+// scope appends the predicate of the tenant to the predicates so the
+// statements never read or write the rows of the other tenants. The
+// Has<Edge>With predicates of the tenant-scoped edges are scoped too.
+func (repo *SQLiteRepository) scope(ctx context.Context, preds []*comparison.Predicate) ([]*comparison.Predicate, error) {
+	tenantID, err := repo.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	preds = append(preds, &comparison.Predicate{
+		Field: "tenant_id", Op: comparison.Eq, Arg: tenantID,
+	})
+
+	return repo.scopeEdges(ctx, preds)
+}
+
+// runner returns the transaction carried by the context when
+// the tx-from-context mode is enabled, otherwise it returns the db
+func (repo *SQLiteRepository) runner(ctx context.Context) (nero.SQLRunner, error) {
+	if !repo.txFromContext {
+		return nero.AsSQLRunner(repo.db), nil
+	}
+
+	tx, ok := nero.TxFromContext(ctx)
+	if !ok {
+		return nero.AsSQLRunner(repo.db), nil
+	}
+
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return txx, nil
+}
+
+// readRunner returns a replica chosen by the balancer. It falls back to runner
+// when there are no replicas, when primary is true or when the context carries
+// a transaction in the tx-from-context mode.
+func (repo *SQLiteRepository) readRunner(ctx context.Context, primary bool) (nero.SQLRunner, error) {
+	if primary || len(repo.replicas) == 0 || repo.balancer == nil {
+		return repo.runner(ctx)
+	}
+
+	if _, ok := nero.TxFromContext(ctx); ok && repo.txFromContext {
+		return repo.runner(ctx)
+	}
+
+	return nero.AsSQLRunner(repo.balancer(repo.replicas)), nil
+}
+
+// Tx begins a new transaction
+func (repo *SQLiteRepository) Tx(ctx context.Context) (nero.Tx, error) {
+	return repo.TxWithOptions(ctx, nil)
+}
+
+// TxWithOptions begins a new transaction with the given options. If the
+// repository's db is already a transaction, a nested transaction is returned.
+func (repo *SQLiteRepository) TxWithOptions(ctx context.Context, opts *sql.TxOptions) (nero.Tx, error) {
+	return nero.BeginTx(ctx, repo.db, opts)
+}
+
+// RunInTx runs fn in a transaction. The transaction is committed if fn returns nil
+// and rolled back if fn returns an error or panics. The transaction is retried
+// on serialization failures according to opts, which may be nil.
+func (repo *SQLiteRepository) RunInTx(ctx context.Context, opts *nero.TxOptions, fn func(nero.Tx) error) error {
+	return runInTx(ctx, repo.TxWithOptions, repo.isRetryable, opts, fn)
+}
+
+// isRetryable returns true if the database or table is locked by another connection
+func (repo *SQLiteRepository) isRetryable(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy ||
+			sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	return false
+}
+
+// Create creates a Team
+func (repo *SQLiteRepository) Create(ctx context.Context, c *Creator) (string, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return repo.create(ctx, runner, c)
+}
+
+// CreateTx creates a Team in a transaction
+func (repo *SQLiteRepository) CreateTx(ctx context.Context, tx nero.Tx, c *Creator) (string, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return "", errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.create(ctx, txx, c)
+}
+
+// create inserts a Team. If conflict columns are given, the row that has the
+// same values for these columns is updated instead i.e. INSERT ... ON CONFLICT DO UPDATE.
+func (repo *SQLiteRepository) create(ctx context.Context, runner nero.SQLRunner, c *Creator, conflict ...string) (string, error) {
+	if err := repo.setTenant(ctx, c); err != nil {
+		return "", err
+	}
+
+	if err := c.generate(); err != nil {
+		return "", err
+	}
+
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+
+	columns := []string{
+		"\"id\"",
+		"\"name\"",
+		"\"tenant_id\"",
+	}
+
+	values := []interface{}{
+		c.id,
+		c.name,
+		c.tenantID,
+	}
+
+	if !isZero(c.meta) {
+		columns = append(columns, "\"meta\"")
+		values = append(values, nero.JSON(c.meta))
+	}
+	if !isZero(c.tags) {
+		columns = append(columns, "\"tags\"")
+		values = append(values, nero.JSON(c.tags))
+	}
+
+	suffix := "RETURNING \"id\""
+	if len(conflict) > 0 {
+		// the unique indexes are per tenant, so the row of another
+		// tenant is never in conflict
+		if !contains(conflict, "\"tenant_id\"") {
+			conflict = append([]string{"\"tenant_id\""}, conflict...)
+		}
+
+		// the key of the existing row is kept so the rows that reference it
+		// aren't broken and the returned key is the one of the existing row
+		keys := []string{
+			"\"id\"",
+		}
+		sets := []string{}
+		for _, col := range columns {
+			if !contains(conflict, col) && !contains(keys, col) {
+				sets = append(sets, col+" = EXCLUDED."+col)
+			}
+		}
+
+		// the row is only returned if it's updated
+		if len(sets) == 0 {
+			sets = append(sets, conflict[0]+" = EXCLUDED."+conflict[0])
+		}
+
+		onConflict := fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s ", strings.Join(conflict, ", "),
+			strings.Join(sets, ", "))
+		suffix = onConflict + suffix
+	}
+
+	qb := squirrel.Insert(repo.table()).Columns(columns...).
+		Values(values...).
+		Suffix(suffix).
+		RunWith(runner)
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Create, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var id string
+	err := qb.QueryRowContext(ctx).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// CreateMany batch creates Teams
+func (repo *SQLiteRepository) CreateMany(ctx context.Context, cs ...*Creator) error {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return err
+	}
+
+	return repo.createMany(ctx, runner, cs...)
+}
+
+// CreateManyTx batch creates Teams in a transaction
+func (repo *SQLiteRepository) CreateManyTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.createMany(ctx, txx, cs...)
+}
+
+func (repo *SQLiteRepository) createMany(ctx context.Context, runner nero.SQLRunner, cs ...*Creator) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	columns := []string{
+		"\"id\"",
+		"\"name\"",
+		"\"meta\"",
+		"\"tags\"",
+		"\"tenant_id\"",
+	}
+
+	// the creators are inserted in chunks so the statements don't exceed the bind parameter limit
+	chunks := chunkCreators(cs, nero.SQLiteMaxParams/len(columns))
+	if _, ok := runner.(nero.TxRunner); !ok && len(chunks) > 1 {
+		// the chunks are inserted in a transaction so it's all or nothing
+		return repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+			return repo.CreateManyTx(ctx, tx, cs...)
+		})
+	}
+
+	for _, chunk := range chunks {
+		qb := squirrel.Insert(repo.table()).Columns(columns...)
+		for _, c := range chunk {
+			if err := repo.setTenant(ctx, c); err != nil {
+				return err
+			}
+
+			if err := c.generate(); err != nil {
+				return err
+			}
+
+			if err := c.Validate(); err != nil {
+				return err
+			}
+
+			qb = qb.Values(
+				c.id,
+				c.name,
+				nero.JSON(c.meta),
+				nero.JSON(c.tags),
+				c.tenantID,
+			)
+		}
+
+		if repo.debug && repo.logger != nil {
+			sql, args, err := qb.ToSql()
+			repo.logger.Printf("method: CreateMany, stmt: %q, args: %v, error: %v", sql, args, err)
+		}
+
+		_, err := qb.RunWith(runner).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BulkInsert inserts Teams with a prepared statement, which is faster than CreateMany
+// for a large number of Teams. It runs in a transaction, which is the one that's
+// carried by the context in the tx-from-context mode.
+func (repo *SQLiteRepository) BulkInsert(ctx context.Context, cs ...*Creator) error {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return err
+	}
+
+	if txx, ok := runner.(nero.TxRunner); ok {
+		return repo.bulkInsert(ctx, txx, cs...)
+	}
+
+	return repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+		return repo.BulkInsertTx(ctx, tx, cs...)
+	})
+}
+
+// BulkInsertTx inserts Teams with a prepared statement in a transaction
+func (repo *SQLiteRepository) BulkInsertTx(ctx context.Context, tx nero.Tx, cs ...*Creator) error {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.bulkInsert(ctx, txx, cs...)
+}
+
+func (repo *SQLiteRepository) bulkInsert(ctx context.Context, tx nero.TxRunner, cs ...*Creator) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	columns := []string{
+		"\"id\"",
+		"\"name\"",
+		"\"meta\"",
+		"\"tags\"",
+		"\"tenant_id\"",
+	}
+
+	phs := []string{}
+	for range columns {
+		phs = append(phs, "?")
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", repo.table(),
+		strings.Join(columns, ", "), strings.Join(phs, ", "))
+	if repo.debug && repo.logger != nil {
+		repo.logger.Printf("method: BulkInsert, stmt: %q, rows: %d", query, len(cs))
+	}
+
+	stmt, err := tx.Unwrap().PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, c := range cs {
+		if err := repo.setTenant(ctx, c); err != nil {
+			return err
+		}
+
+		if err := c.generate(); err != nil {
+			return err
+		}
+
+		if err := c.Validate(); err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			c.id,
+			c.name,
+			nero.JSON(c.meta),
+			nero.JSON(c.tags),
+			c.tenantID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Query queries Teams
+func (repo *SQLiteRepository) Query(ctx context.Context, q *Queryer) ([]*player.Team, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.query(ctx, runner, q)
+}
+
+// QueryTx queries Teams in a transaction
+func (repo *SQLiteRepository) QueryTx(ctx context.Context, tx nero.Tx, q *Queryer) ([]*player.Team, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.query(ctx, txx, q)
+}
+
+func (repo *SQLiteRepository) query(ctx context.Context, runner nero.SQLRunner, q *Queryer) ([]*player.Team, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Query, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams, err := repo.scan(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.loadEdges(ctx, runner, q, teams...)
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// QueryOne queries a Team
+func (repo *SQLiteRepository) QueryOne(ctx context.Context, q *Queryer) (*player.Team, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.queryOne(ctx, runner, q)
+}
+
+// QueryOneTx queries a Team in a transaction
+func (repo *SQLiteRepository) QueryOneTx(ctx context.Context, tx nero.Tx, q *Queryer) (*player.Team, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.queryOne(ctx, txx, q)
+}
+
+func (repo *SQLiteRepository) queryOne(ctx context.Context, runner nero.SQLRunner, q *Queryer) (*player.Team, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: QueryOne, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var team player.Team
+	err = qb.RunWith(runner).
+		QueryRowContext(ctx).
+		Scan(
+			&team.ID,
+			&team.Name,
+			nero.JSON(&team.Meta),
+			nero.JSON(&team.Tags),
+			sqliteTime{&team.CreatedAt},
+			&team.TenantID,
+		)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.loadEdges(ctx, runner, q, &team)
+	if err != nil {
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// Exists checks if there's any Team that matches the queryer
+func (repo *SQLiteRepository) Exists(ctx context.Context, q *Queryer) (bool, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return false, err
+	}
+
+	return repo.exists(ctx, runner, q)
+}
+
+// ExistsTx checks if there's any Team that matches the queryer in a transaction
+func (repo *SQLiteRepository) ExistsTx(ctx context.Context, tx nero.Tx, q *Queryer) (bool, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return false, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.exists(ctx, txx, q)
+}
+
+func (repo *SQLiteRepository) exists(ctx context.Context, runner nero.SQLRunner, q *Queryer) (bool, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return false, err
+	}
+
+	eb := squirrel.Select().
+		Column(squirrel.Expr("EXISTS (?)", qb.PlaceholderFormat(squirrel.Question)))
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := eb.ToSql()
+		repo.logger.Printf("method: Exists, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var exists bool
+	err = eb.RunWith(runner).QueryRowContext(ctx).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Count counts the Teams that match the queryer
+func (repo *SQLiteRepository) Count(ctx context.Context, q *Queryer) (int64, error) {
+	runner, err := repo.readRunner(ctx, q.primary || q.forUpdate || q.forShare)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.count(ctx, runner, q)
+}
+
+// CountTx counts the Teams that match the queryer in a transaction
+func (repo *SQLiteRepository) CountTx(ctx context.Context, tx nero.Tx, q *Queryer) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.count(ctx, txx, q)
+}
+
+// count counts the rows of the select so the limit and
+// the offset of the queryer are taken into account
+func (repo *SQLiteRepository) count(ctx context.Context, runner nero.SQLRunner, q *Queryer) (int64, error) {
+	qb, err := repo.buildSelect(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+
+	eb := squirrel.Select("COUNT(*)").
+		FromSelect(qb.PlaceholderFormat(squirrel.Question), "q")
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := eb.ToSql()
+		repo.logger.Printf("method: Count, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	var count int64
+	err = eb.RunWith(runner).QueryRowContext(ctx).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo *SQLiteRepository) buildSelect(ctx context.Context, q *Queryer) (squirrel.SelectBuilder, error) {
+	// sqlite locks the whole database on write so it has no row-level locks
+	if q.forUpdate || q.forShare || q.skipLocked || q.noWait {
+		return squirrel.SelectBuilder{}, nero.NewErrUnsupported("row-level locking", "sqlite")
+	}
+
+	if len(q.distinctOn) > 0 {
+		return squirrel.SelectBuilder{}, nero.NewErrUnsupported("DISTINCT ON", "sqlite")
+	}
+
+	qb := squirrel.Select(repo.columns()...).From(repo.table())
+	if q.distinct {
+		qb = qb.Distinct()
+	}
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range q.predFuncs {
+		preds = predFunc(preds)
+	}
+	preds, err := repo.scope(ctx, preds)
+	if err != nil {
+		return squirrel.SelectBuilder{}, err
+	}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	sorts := []*sort.Sort{}
+	for _, sortFunc := range q.sortFuncs {
+		sorts = sortFunc(sorts)
+	}
+	qb = repo.buildSort(qb, sorts)
+
+	if q.limit > 0 {
+		qb = qb.Limit(uint64(q.limit))
+	}
+
+	if q.offset > 0 {
+		qb = qb.Offset(uint64(q.offset))
+	}
+
+	return qb, nil
+}
+
+func (repo *SQLiteRepository) columns() []string {
+	return []string{
+		"\"id\"",
+		"\"name\"",
+		"\"meta\"",
+		"\"tags\"",
+		"\"created_at\"",
+		"\"tenant_id\"",
+	}
+}
+
+func (repo *SQLiteRepository) scan(rows *sql.Rows) ([]*player.Team, error) {
+	teams := []*player.Team{}
+	for rows.Next() {
+		var team player.Team
+		err := rows.Scan(
+			&team.ID,
+			&team.Name,
+			nero.JSON(&team.Meta),
+			nero.JSON(&team.Tags),
+			sqliteTime{&team.CreatedAt},
+			&team.TenantID,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		teams = append(teams, &team)
+	}
+
+	return teams, rows.Err()
+}
+
+func (repo *SQLiteRepository) buildPreds(sb squirrel.StatementBuilderType, table string, preds []*comparison.Predicate) squirrel.StatementBuilderType {
+	column := func(field string) string {
+		if table == "" {
+			return fmt.Sprintf("%q", field)
+		}
+		return fmt.Sprintf("%q.%q", table, field)
+	}
+
+	for _, pred := range preds {
+		ph := "?"
+		fieldX, arg := column(pred.Field), pred.Arg
+
+		args := []interface{}{}
+		if fieldY, ok := arg.(comparison.FieldArg); ok { // a field
+			ph = column(fieldY.Column())
+		} else if vals, ok := arg.([]interface{}); ok { // array of values
+			args = append(args, vals...)
+		} else { // single value
+			args = append(args, arg)
+		}
+
+		switch pred.Op {
+		case comparison.Eq:
+			sb = sb.Where(fmt.Sprintf("%s = "+ph, fieldX), args...)
+		case comparison.NotEq:
+			sb = sb.Where(fmt.Sprintf("%s <> "+ph, fieldX), args...)
+		case comparison.Gt:
+			sb = sb.Where(fmt.Sprintf("%s > "+ph, fieldX), args...)
+		case comparison.GtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s >= "+ph, fieldX), args...)
+		case comparison.Lt:
+			sb = sb.Where(fmt.Sprintf("%s < "+ph, fieldX), args...)
+		case comparison.LtOrEq:
+			sb = sb.Where(fmt.Sprintf("%s <= "+ph, fieldX), args...)
+		case comparison.IsNull, comparison.IsNotNull:
+			fmtStr := "%s IS NULL"
+			if pred.Op == comparison.IsNotNull {
+				fmtStr = "%s IS NOT NULL"
+			}
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX))
+		case comparison.In, comparison.NotIn:
+			fmtStr := "%s IN (%s)"
+			if pred.Op == comparison.NotIn {
+				fmtStr = "%s NOT IN (%s)"
+			}
+
+			phs := []string{}
+			for range args {
+				phs = append(phs, "?")
+			}
+
+			sb = sb.Where(fmt.Sprintf(fmtStr, fieldX, strings.Join(phs, ",")), args...)
+		case comparison.Exists:
+			edge, _ := arg.(*comparison.Edge)
+			sb = sb.Where(squirrel.Expr("EXISTS (?)", repo.buildExists(table, edge)))
+		case comparison.PathEq:
+			// the predicates of Has<Edge>With may come from the target schema
+			pv, _ := arg.(*comparison.PathValue)
+			sb = sb.Where(fmt.Sprintf("json_extract(%s, ?) = ?", fieldX), "$."+pv.Path, pv.Value)
+		case comparison.Contains, comparison.ContainedBy, comparison.Overlap, comparison.AnyEq:
+			// sqlite has no array type
+			sb = sb.Where(unsupportedPred{feature: "array predicates"})
+		}
+	}
+
+	return sb
+}
+
+func (repo *SQLiteRepository) buildSort(qb squirrel.SelectBuilder, sorts []*sort.Sort) squirrel.SelectBuilder {
+	for _, s := range sorts {
+		field := fmt.Sprintf("%q", s.Field)
+		switch s.Direction {
+		case sort.Asc:
+			qb = qb.OrderBy(field + " ASC")
+		case sort.Desc:
+			qb = qb.OrderBy(field + " DESC")
+		}
+	}
+
+	return qb
+}
+
+// buildExists builds the subquery of the Has<Edge>With predicates. The target
+// table is aliased with the edge name, prefixed with the alias of the outer
+// table when the predicate is nested, so the columns are never ambiguous.
+func (repo *SQLiteRepository) buildExists(outer string, edge *comparison.Edge) squirrel.SelectBuilder {
+	alias := edge.Name
+	if outer == "" {
+		outer = "teams"
+	} else {
+		alias = outer + "_" + edge.Name
+	}
+
+	qb := squirrel.Select("1").
+		From(repo.qualify(edge.Namespace, edge.Collection) + fmt.Sprintf(" AS %q", alias))
+	if edge.Through == "" {
+		qb = qb.Where(fmt.Sprintf("%q.%q = %q.%q", alias, edge.Column, outer, edge.Ref))
+	} else {
+		through := alias + "_" + edge.Through
+		qb = qb.Join(repo.qualify(edge.ThroughNamespace, edge.Through) +
+			fmt.Sprintf(" AS %q ON %q.%q = %q.%q", through, through, edge.ThroughTo, alias, edge.Column)).
+			Where(fmt.Sprintf("%q.%q = %q.%q", through, edge.ThroughFrom, outer, edge.Ref))
+	}
+
+	return squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), alias, edge.Preds))
+}
+
+// loadEdges eager loads the edges that are requested by the queryer
+func (repo *SQLiteRepository) loadEdges(ctx context.Context, runner nero.SQLRunner, q *Queryer, teams ...*player.Team) error {
+	if len(teams) == 0 {
+		return nil
+	}
+
+	if q.withPlayers {
+		if err := repo.loadPlayers(ctx, runner, teams); err != nil {
+			return errors.Wrap(err, "load players")
+		}
+	}
+
+	return nil
+}
+
+// loadPlayers loads the players edge of the Teams with a single query
+func (repo *SQLiteRepository) loadPlayers(ctx context.Context, runner nero.SQLRunner, teams []*player.Team) error {
+	columns := []string{
+		"\"players\".\"id\"",
+		"\"players\".\"email\"",
+		"\"players\".\"name\"",
+		"\"players\".\"age\"",
+		"\"players\".\"race\"",
+		"\"players\".\"team_id\"",
+		"\"players\".\"updated_at\"",
+		"\"players\".\"created_at\"",
+	}
+
+	keys := []interface{}{}
+	for _, team := range teams {
+		keys = append(keys, team.ID)
+	}
+
+	qb := squirrel.Select(columns...).
+		From(repo.qualify("", "players")).
+		Where(squirrel.Eq{"\"players\".\"team_id\"": keys})
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Query, edge: players, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	related := map[string][]*player.Player{}
+	for rows.Next() {
+		var item player.Player
+		err = rows.Scan(
+			&item.ID,
+			&item.Email,
+			&item.Name,
+			&item.Age,
+			&item.Race,
+			&item.TeamID,
+			sqliteTime{&item.UpdatedAt},
+			sqliteTime{&item.CreatedAt},
+		)
+		if err != nil {
+			return err
+		}
+
+		if item.TeamID == nil {
+			continue
+		}
+		key := *item.TeamID
+		related[key] = append(related[key], &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		team.Players = related[team.ID]
+	}
+
+	return nil
+}
+
+// QueryByName queries a Team by the unique Name index
+func (repo *SQLiteRepository) QueryByName(ctx context.Context, name string) (*player.Team, error) {
+	return repo.QueryOne(ctx, NewQueryer().Where(
+		NameEq(name),
+	))
+}
+
+// QueryByNameTx queries a Team by the unique Name index in a transaction
+func (repo *SQLiteRepository) QueryByNameTx(ctx context.Context, tx nero.Tx, name string) (*player.Team, error) {
+	return repo.QueryOneTx(ctx, tx, NewQueryer().Where(
+		NameEq(name),
+	))
+}
+
+// UpsertByName creates a Team or updates the one with the same Name
+func (repo *SQLiteRepository) UpsertByName(ctx context.Context, c *Creator) (string, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return repo.create(ctx, runner, c, "\"name\"")
+}
+
+// UpsertByNameTx creates a Team or updates the one with the same Name in a transaction
+func (repo *SQLiteRepository) UpsertByNameTx(ctx context.Context, tx nero.Tx, c *Creator) (string, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return "", errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.create(ctx, txx, c, "\"name\"")
+}
+
+// Update updates a Team or many Teams
+func (repo *SQLiteRepository) Update(ctx context.Context, u *Updater) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.update(ctx, runner, u)
+}
+
+// UpdateTx updates a Team many Teams in a transaction
+func (repo *SQLiteRepository) UpdateTx(ctx context.Context, tx nero.Tx, u *Updater) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.update(ctx, txx, u)
+}
+
+func (repo *SQLiteRepository) buildUpdate(ctx context.Context, u *Updater) (squirrel.UpdateBuilder, bool, error) {
+	qb := squirrel.Update(repo.table())
+
+	cnt := 0
+
+	if u.fields.has(FieldName) {
+		qb = qb.Set("\"name\"", u.name)
+		cnt++
+	}
+
+	if u.fields.has(FieldMeta) {
+		qb = qb.Set("\"meta\"", nero.JSON(u.meta))
+		cnt++
+	}
+
+	if u.fields.has(FieldTags) {
+		qb = qb.Set("\"tags\"", nero.JSON(u.tags))
+		cnt++
+	}
+
+	for _, e := range u.exprs {
+		if e.field == FieldTenantID {
+			return qb, false, errors.New("the tenant field can't be updated")
+		}
+
+		col := fmt.Sprintf("%q", e.field)
+		if e.delta != nil {
+			qb = qb.Set(col, squirrel.Expr(col+" + ?", e.delta))
+		} else {
+			qb = qb.Set(col, squirrel.Expr(e.expr, e.args...))
+		}
+		cnt++
+	}
+
+	if cnt == 0 {
+		return qb, false, nil
+	}
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range u.predFuncs {
+		preds = predFunc(preds)
+	}
+	preds, err := repo.scope(ctx, preds)
+	if err != nil {
+		return qb, false, err
+	}
+	qb = squirrel.UpdateBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, true, nil
+}
+
+func (repo *SQLiteRepository) update(ctx context.Context, runner nero.SQLRunner, u *Updater) (int64, error) {
+	if err := u.Validate(); err != nil {
+		return 0, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return 0, nil
+	}
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Update, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	res, err := qb.RunWith(runner).ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// UpdateReturning updates a Team or many Teams and returns the updated Teams
+func (repo *SQLiteRepository) UpdateReturning(ctx context.Context, u *Updater) ([]*player.Team, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.updateReturning(ctx, runner, u)
+}
+
+// UpdateReturningTx updates a Team or many Teams in a transaction and returns the updated Teams
+func (repo *SQLiteRepository) UpdateReturningTx(ctx context.Context, tx nero.Tx, u *Updater) ([]*player.Team, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.updateReturning(ctx, txx, u)
+}
+
+func (repo *SQLiteRepository) updateReturning(ctx context.Context, runner nero.SQLRunner, u *Updater) ([]*player.Team, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+
+	qb, ok, err := repo.buildUpdate(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, nil
+	}
+	qb = qb.Suffix("RETURNING " + strings.Join(repo.columns(), ","))
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: UpdateReturning, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repo.scan(rows)
+}
+
+// UpdateMany updates many Teams with different values. The Teams
+// are updated one by one in a transaction, which is the one that's carried by the
+// context in the tx-from-context mode.
+func (repo *SQLiteRepository) UpdateMany(ctx context.Context, rus []*RowUpdate) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if txx, ok := runner.(nero.TxRunner); ok {
+		return repo.updateMany(ctx, txx, rus)
+	}
+
+	var rowsAffected int64
+	err = repo.RunInTx(ctx, nil, func(tx nero.Tx) error {
+		var err error
+		rowsAffected, err = repo.UpdateManyTx(ctx, tx, rus)
+		return err
+	})
+	return rowsAffected, err
+}
+
+// UpdateManyTx updates many Teams with different values in a transaction
+func (repo *SQLiteRepository) UpdateManyTx(ctx context.Context, tx nero.Tx, rus []*RowUpdate) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.updateMany(ctx, txx, rus)
+}
+
+func (repo *SQLiteRepository) updateMany(ctx context.Context, runner nero.SQLRunner, rus []*RowUpdate) (int64, error) {
+	var rowsAffected int64
+	for _, ru := range rus {
+		n, err := repo.update(ctx, runner, ru.updater())
+		if err != nil {
+			return 0, err
+		}
+
+		rowsAffected += n
+	}
+
+	return rowsAffected, nil
+}
+
+// Delete deletes a Team or many Teams
+func (repo *SQLiteRepository) Delete(ctx context.Context, d *Deleter) (int64, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return repo.delete(ctx, runner, d)
+}
+
+// Delete deletes a Team or many Teams in a transaction
+func (repo *SQLiteRepository) DeleteTx(ctx context.Context, tx nero.Tx, d *Deleter) (int64, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return 0, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.delete(ctx, txx, d)
+}
+
+func (repo *SQLiteRepository) buildDelete(ctx context.Context, d *Deleter) (squirrel.DeleteBuilder, error) {
+	qb := squirrel.Delete(repo.table())
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range d.predFuncs {
+		preds = predFunc(preds)
+	}
+	preds, err := repo.scope(ctx, preds)
+	if err != nil {
+		return qb, err
+	}
+	qb = squirrel.DeleteBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	return qb, nil
+}
+
+func (repo *SQLiteRepository) delete(ctx context.Context, runner nero.SQLRunner, d *Deleter) (int64, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return 0, err
+	}
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Delete, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	res, err := qb.RunWith(runner).ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// DeleteReturning deletes a Team or many Teams and returns the deleted Teams
+func (repo *SQLiteRepository) DeleteReturning(ctx context.Context, d *Deleter) ([]*player.Team, error) {
+	runner, err := repo.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.deleteReturning(ctx, runner, d)
+}
+
+// DeleteReturningTx deletes a Team or many Teams in a transaction and returns the deleted Teams
+func (repo *SQLiteRepository) DeleteReturningTx(ctx context.Context, tx nero.Tx, d *Deleter) ([]*player.Team, error) {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return nil, errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.deleteReturning(ctx, txx, d)
+}
+
+func (repo *SQLiteRepository) deleteReturning(ctx context.Context, runner nero.SQLRunner, d *Deleter) ([]*player.Team, error) {
+	qb, err := repo.buildDelete(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	qb = qb.Suffix("RETURNING " + strings.Join(repo.columns(), ","))
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: DeleteReturning, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repo.scan(rows)
+}
+
+// Aggregate runs an aggregate query
+func (repo *SQLiteRepository) Aggregate(ctx context.Context, a *Aggregator) error {
+	runner, err := repo.readRunner(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	return repo.aggregate(ctx, runner, a)
+}
+
+// Aggregate runs an aggregate query in a transaction
+func (repo *SQLiteRepository) AggregateTx(ctx context.Context, tx nero.Tx, a *Aggregator) error {
+	txx, ok := tx.(nero.TxRunner)
+	if !ok {
+		return errors.New("expecting tx to implement nero.TxRunner")
+	}
+
+	return repo.aggregate(ctx, txx, a)
+}
+
+func (repo *SQLiteRepository) aggregate(ctx context.Context, runner nero.SQLRunner, a *Aggregator) error {
+	aggs := []*aggregate.Aggregate{}
+	for _, aggFunc := range a.aggFuncs {
+		aggs = aggFunc(aggs)
+	}
+	columns := []string{}
+	for _, agg := range aggs {
+		field := agg.Field
+		qf := fmt.Sprintf("%q", field)
+		switch agg.Op {
+		case aggregate.Avg:
+			columns = append(columns, "AVG("+qf+") avg_"+field)
+		case aggregate.Count:
+			columns = append(columns, "COUNT("+qf+") count_"+field)
+		case aggregate.Max:
+			columns = append(columns, "MAX("+qf+") max_"+field)
+		case aggregate.Min:
+			columns = append(columns, "MIN("+qf+") min_"+field)
+		case aggregate.Sum:
+			columns = append(columns, "SUM("+qf+") sum_"+field)
+		case aggregate.None:
+			columns = append(columns, qf)
+		}
+	}
+
+	qb := squirrel.Select(columns...).From(repo.table())
+
+	groupBys := []string{}
+	for _, groupBy := range a.groupBys {
+		groupBys = append(groupBys, fmt.Sprintf("%q", groupBy.String()))
+	}
+	qb = qb.GroupBy(groupBys...)
+
+	preds := []*comparison.Predicate{}
+	for _, predFunc := range a.predFuncs {
+		preds = predFunc(preds)
+	}
+	preds, err := repo.scope(ctx, preds)
+	if err != nil {
+		return err
+	}
+	qb = squirrel.SelectBuilder(repo.buildPreds(squirrel.StatementBuilderType(qb), "", preds))
+
+	sorts := []*sort.Sort{}
+	for _, sortFunc := range a.sortFuncs {
+		sorts = sortFunc(sorts)
+	}
+	qb = repo.buildSort(qb, sorts)
+
+	if repo.debug && repo.logger != nil {
+		sql, args, err := qb.ToSql()
+		repo.logger.Printf("method: Aggregate, stmt: %q, args: %v, error: %v", sql, args, err)
+	}
+
+	rows, err := qb.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	v := reflect.ValueOf(a.v).Elem()
+	t := reflect.TypeOf(v.Interface()).Elem()
+	if len(columns) != t.NumField() {
+		return errors.Errorf("column count (%v) and destination struct field count (%v) doesn't match", len(columns), t.NumField())
+	}
+
+	for rows.Next() {
+		ve := reflect.New(t).Elem()
+		dest := make([]interface{}, ve.NumField())
+		for i := 0; i < ve.NumField(); i++ {
+			dest[i] = ve.Field(i).Addr().Interface()
+		}
+
+		err = rows.Scan(dest...)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.Append(v, ve))
+	}
+
+	return nil
+}
+
+// sqliteTime scans a time value that may have been returned as text
+// e.g. from a RETURNING clause where the column type is not known
+type sqliteTime struct {
+	// dest is either a *time.Time or **time.Time
+	dest interface{}
+}
+
+// Scan implements sql.Scanner
+func (st sqliteTime) Scan(src interface{}) error {
+	var t time.Time
+	switch v := src.(type) {
+	case nil:
+		if dest, ok := st.dest.(**time.Time); ok {
+			*dest = nil
+		}
+		return nil
+	case time.Time:
+		t = v
+	case string, []byte:
+		s := strings.TrimSuffix(fmt.Sprintf("%s", v), "Z")
+		var err error
+		for _, layout := range sqlite3.SQLiteTimestampFormats {
+			if t, err = time.ParseInLocation(layout, s, time.UTC); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "parse time %q", s)
+		}
+	default:
+		return errors.Errorf("unsupported time value %T", src)
+	}
+
+	switch dest := st.dest.(type) {
+	case *time.Time:
+		*dest = t
+	case **time.Time:
+		*dest = &t
+	}
+
+	return nil
+}
+
+// unsupportedPred is a predicate that fails to render with an
+// *nero.ErrUnsupported error
+type unsupportedPred struct {
+	feature string
+}
+
+// ToSql implements squirrel.Sqlizer
+func (p unsupportedPred) ToSql() (string, []interface{}, error) {
+	return "", nil, nero.NewErrUnsupported(p.feature, "sqlite")
+}
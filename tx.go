@@ -1,7 +1,209 @@
 package nero
 
-// Tx is an interface that wraps the Commit and Rollback method
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Tx is an interface that wraps the Commit, Rollback and savepoint methods
 type Tx interface {
 	Commit() error
 	Rollback() error
+	// Savepoint creates a savepoint with the given name
+	Savepoint(name string) error
+	// RollbackTo rolls back to the savepoint with the given name
+	RollbackTo(name string) error
+	// Release releases the savepoint with the given name
+	Release(name string) error
+}
+
+// SQLTx is a Tx that wraps *sql.Tx and implements the
+// savepoint methods using the standard SQL statements
+type SQLTx struct {
+	*sql.Tx
+}
+
+var (
+	_ Tx       = (*SQLTx)(nil)
+	_ TxRunner = (*SQLTx)(nil)
+)
+
+// NewSQLTx returns a SQLTx
+func NewSQLTx(tx *sql.Tx) *SQLTx {
+	return &SQLTx{Tx: tx}
+}
+
+// Unwrap returns the underlying *sql.Tx
+func (tx *SQLTx) Unwrap() *sql.Tx {
+	return tx.Tx
+}
+
+// Savepoint creates a savepoint
+func (tx *SQLTx) Savepoint(name string) error {
+	return savepoint(tx, name)
+}
+
+// RollbackTo rolls back to a savepoint
+func (tx *SQLTx) RollbackTo(name string) error {
+	return rollbackTo(tx, name)
+}
+
+// Release releases a savepoint
+func (tx *SQLTx) Release(name string) error {
+	return release(tx, name)
+}
+
+// NestedTx is a transaction that is nested in another transaction using a
+// savepoint. Commit releases the savepoint and Rollback rolls back to it,
+// while the outer transaction is left to its owner.
+type NestedTx struct {
+	TxRunner
+	name string
+}
+
+var _ Tx = (*NestedTx)(nil)
+
+// nestedTxSeq is used for generating unique savepoint names
+var nestedTxSeq uint64
+
+// NewNestedTx creates a savepoint in tx and returns a NestedTx
+func NewNestedTx(ctx context.Context, tx TxRunner) (*NestedTx, error) {
+	name := fmt.Sprintf("nero_nested_tx_%d", atomic.AddUint64(&nestedTxSeq, 1))
+	_, err := tx.ExecContext(ctx, "SAVEPOINT "+QuoteIdent(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &NestedTx{TxRunner: tx, name: name}, nil
+}
+
+// Commit releases the savepoint of the nested transaction
+func (tx *NestedTx) Commit() error {
+	return release(tx, tx.name)
+}
+
+// Rollback rolls back and releases the savepoint of the nested transaction
+func (tx *NestedTx) Rollback() error {
+	err := rollbackTo(tx, tx.name)
+	if err != nil {
+		return err
+	}
+
+	return release(tx, tx.name)
+}
+
+// Savepoint creates a savepoint
+func (tx *NestedTx) Savepoint(name string) error {
+	return savepoint(tx, name)
+}
+
+// RollbackTo rolls back to a savepoint
+func (tx *NestedTx) RollbackTo(name string) error {
+	return rollbackTo(tx, name)
+}
+
+// Release releases a savepoint
+func (tx *NestedTx) Release(name string) error {
+	return release(tx, name)
+}
+
+// BeginTx begins a transaction on db. If db is already a transaction,
+// a NestedTx is returned, in which case opts must be nil or the default
+// options since the outer transaction's options can't be changed.
+func BeginTx(ctx context.Context, db DB, opts *sql.TxOptions) (Tx, error) {
+	var outer TxRunner
+	switch v := db.(type) {
+	case TxRunner:
+		outer = v
+	case *sql.Tx:
+		outer = NewSQLTx(v)
+	case TxBeginner:
+		tx, err := v.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return NewSQLTx(tx), nil
+	default:
+		return nil, errors.New("db doesn't support transactions")
+	}
+
+	if opts != nil && (opts.Isolation != sql.LevelDefault || opts.ReadOnly) {
+		return nil, errors.New("tx options are not supported in a nested transaction")
+	}
+
+	return NewNestedTx(ctx, outer)
+}
+
+func savepoint(runner SQLRunner, name string) error {
+	_, err := runner.Exec("SAVEPOINT " + QuoteIdent(name))
+	return err
+}
+
+func rollbackTo(runner SQLRunner, name string) error {
+	_, err := runner.Exec("ROLLBACK TO SAVEPOINT " + QuoteIdent(name))
+	return err
+}
+
+func release(runner SQLRunner, name string) error {
+	_, err := runner.Exec("RELEASE SAVEPOINT " + QuoteIdent(name))
+	return err
+}
+
+// txKey is the context key of the transaction
+type txKey struct{}
+
+// ContextWithTx returns a copy of ctx that carries the transaction.
+// Repositories with the tx-from-context mode enabled run their
+// non-Tx methods in this transaction.
+func ContextWithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx, if any
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(Tx)
+	return tx, ok
+}
+
+// TxOptions is the options for running a function in a transaction
+type TxOptions struct {
+	// Isolation is the transaction isolation level, defaults to the driver's default
+	Isolation sql.IsolationLevel
+	// ReadOnly is the read-only flag
+	ReadOnly bool
+	// MaxRetries is the maximum number of retries on serialization failures
+	MaxRetries int
+	// Backoff is the wait duration before each retry, defaults to no wait
+	Backoff Backoff
+}
+
+// Backoff returns the wait duration before the nth retry, starting at 1
+type Backoff func(retry int) time.Duration
+
+// ConstantBackoff returns a Backoff that always waits for d
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a Backoff that starts at base
+// and doubles on each retry until it reaches max
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(retry int) time.Duration {
+		d := base
+		for i := 1; i < retry && d < max; i++ {
+			d *= 2
+		}
+
+		if d > max {
+			return max
+		}
+
+		return d
+	}
 }
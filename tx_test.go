@@ -0,0 +1,115 @@
+package nero_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sf9v/nero"
+)
+
+func TestBackoff(t *testing.T) {
+	constant := nero.ConstantBackoff(time.Second)
+	assert.Equal(t, time.Second, constant(1))
+	assert.Equal(t, time.Second, constant(10))
+
+	exponential := nero.ExponentialBackoff(10*time.Millisecond, time.Second)
+	assert.Equal(t, 10*time.Millisecond, exponential(1))
+	assert.Equal(t, 20*time.Millisecond, exponential(2))
+	assert.Equal(t, 40*time.Millisecond, exponential(3))
+	assert.Equal(t, time.Second, exponential(10))
+	assert.Equal(t, time.Second, exponential(1000))
+}
+
+func TestSQLTx(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE items (name TEXT)")
+	require.NoError(t, err)
+
+	sqlTx, err := db.Begin()
+	require.NoError(t, err)
+
+	tx := nero.NewSQLTx(sqlTx)
+	_, err = tx.Exec("INSERT INTO items VALUES ('a')")
+	require.NoError(t, err)
+	require.NoError(t, tx.Savepoint(`my "savepoint"`))
+
+	_, err = tx.Exec("INSERT INTO items VALUES ('b')")
+	require.NoError(t, err)
+	require.NoError(t, tx.RollbackTo(`my "savepoint"`))
+	require.NoError(t, tx.Release(`my "savepoint"`))
+	assert.Error(t, tx.Release(`my "savepoint"`))
+	assert.Equal(t, sqlTx, tx.Unwrap())
+	require.NoError(t, tx.Commit())
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM items").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestContextWithTx(t *testing.T) {
+	ctx := context.Background()
+	_, ok := nero.TxFromContext(ctx)
+	assert.False(t, ok)
+
+	tx := nero.NewSQLTx(nil)
+	got, ok := nero.TxFromContext(nero.ContextWithTx(ctx, tx))
+	assert.True(t, ok)
+	assert.Equal(t, tx, got)
+}
+
+func TestBeginTx(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE items (name TEXT)")
+	require.NoError(t, err)
+
+	count := func(runner nero.DB) int {
+		var count int
+		err := runner.QueryRowContext(ctx, "SELECT COUNT(*) FROM items").Scan(&count)
+		require.NoError(t, err)
+		return count
+	}
+
+	// *sql.DB begins a new transaction
+	tx, err := nero.BeginTx(ctx, db, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &nero.SQLTx{}, tx)
+
+	// a transaction begins a nested transaction
+	outer := tx.(*nero.SQLTx)
+	inner, err := nero.BeginTx(ctx, outer.Unwrap(), nil)
+	require.NoError(t, err)
+	_, err = inner.(nero.TxRunner).Exec("INSERT INTO items VALUES ('a')")
+	require.NoError(t, err)
+	require.NoError(t, inner.Rollback())
+	assert.Equal(t, 0, count(outer))
+
+	inner, err = nero.BeginTx(ctx, outer, nil)
+	require.NoError(t, err)
+	_, err = inner.(nero.TxRunner).Exec("INSERT INTO items VALUES ('b')")
+	require.NoError(t, err)
+	require.NoError(t, inner.Commit())
+	assert.Equal(t, 1, count(outer))
+
+	_, err = nero.BeginTx(ctx, outer, &sql.TxOptions{ReadOnly: true})
+	assert.Error(t, err)
+	require.NoError(t, outer.Commit())
+	assert.Equal(t, 1, count(db))
+
+	// a db without BeginTx doesn't support transactions
+	_, err = nero.BeginTx(ctx, struct{ nero.DB }{db}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+// Package validation provides the field validation rules that can be used
+// with nero.FieldBuilder.Validate. The rules are rendered into the generated
+// Creator.Validate and Updater.Validate methods.
+package validation
+
+import (
+	"net/mail"
+	"reflect"
+	"unicode/utf8"
+)
+
+// List of rule names
+const (
+	RuleMin    = "min"
+	RuleMax    = "max"
+	RuleMinLen = "minlen"
+	RuleMaxLen = "maxlen"
+	RuleMatch  = "match"
+	RuleEmail  = "email"
+	RuleOneOf  = "oneof"
+	RuleFunc   = "func"
+)
+
+// Rule is a field validation rule
+type Rule struct {
+	name string
+	args []interface{}
+}
+
+// Name returns the rule name e.g. "min"
+func (r *Rule) Name() string {
+	return r.name
+}
+
+// Args returns the rule arguments
+func (r *Rule) Args() []interface{} {
+	return r.args[:]
+}
+
+// Arg returns the first rule argument or nil
+func (r *Rule) Arg() interface{} {
+	if len(r.args) == 0 {
+		return nil
+	}
+
+	return r.args[0]
+}
+
+// Min checks if a number is greater than or equal to n
+func Min(n float64) *Rule {
+	return &Rule{name: RuleMin, args: []interface{}{n}}
+}
+
+// Max checks if a number is less than or equal to n
+func Max(n float64) *Rule {
+	return &Rule{name: RuleMax, args: []interface{}{n}}
+}
+
+// MinLen checks if the length of a string, slice or map is at least n.
+// The length of a string is its number of runes.
+func MinLen(n int) *Rule {
+	return &Rule{name: RuleMinLen, args: []interface{}{n}}
+}
+
+// MaxLen checks if the length of a string, slice or map is at most n.
+// The length of a string is its number of runes.
+func MaxLen(n int) *Rule {
+	return &Rule{name: RuleMaxLen, args: []interface{}{n}}
+}
+
+// Match checks if a string matches the regular expression
+func Match(pattern string) *Rule {
+	return &Rule{name: RuleMatch, args: []interface{}{pattern}}
+}
+
+// Email checks if a string is an email address e.g. "me@example.com"
+func Email() *Rule {
+	return &Rule{name: RuleEmail}
+}
+
+// OneOf checks if a value is one of values. The values must be convertible
+// to the field type.
+func OneOf(values ...interface{}) *Rule {
+	return &Rule{name: RuleOneOf, args: values}
+}
+
+// Func checks a value with a custom function of the form func(v T) error,
+// where T is the field type, or the element type of a pointer field. Like
+// the generators, it must be a top-level function and the name of its
+// package must be the same as the last element of its import path.
+func Func(fn interface{}) *Rule {
+	return &Rule{name: RuleFunc, args: []interface{}{fn}}
+}
+
+// Len returns the length of a string, slice, array or map. The length of
+// a string is its number of runes.
+func Len(v interface{}) int {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(rv.String())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len()
+	}
+
+	return 0
+}
+
+// IsEmail returns true if s is an email address without a display name
+func IsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
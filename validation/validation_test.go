@@ -0,0 +1,76 @@
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sf9v/nero/validation"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule     *validation.Rule
+		wantName string
+		wantArgs []interface{}
+	}{
+		{
+			rule:     validation.Min(18),
+			wantName: "min",
+			wantArgs: []interface{}{float64(18)},
+		},
+		{
+			rule:     validation.Max(1.5),
+			wantName: "max",
+			wantArgs: []interface{}{1.5},
+		},
+		{
+			rule:     validation.MinLen(1),
+			wantName: "minlen",
+			wantArgs: []interface{}{1},
+		},
+		{
+			rule:     validation.MaxLen(50),
+			wantName: "maxlen",
+			wantArgs: []interface{}{50},
+		},
+		{
+			rule:     validation.Match("^[a-z]+$"),
+			wantName: "match",
+			wantArgs: []interface{}{"^[a-z]+$"},
+		},
+		{
+			rule:     validation.Email(),
+			wantName: "email",
+		},
+		{
+			rule:     validation.OneOf("a", "b"),
+			wantName: "oneof",
+			wantArgs: []interface{}{"a", "b"},
+		},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.wantName, tc.rule.Name())
+		assert.Equal(t, tc.wantArgs, tc.rule.Args())
+	}
+
+	assert.Nil(t, validation.Email().Arg())
+	assert.Equal(t, 50, validation.MaxLen(50).Arg())
+	assert.Equal(t, "func", validation.Func(validation.IsEmail).Name())
+}
+
+func TestLen(t *testing.T) {
+	assert.Equal(t, 5, validation.Len("héllo"))
+	assert.Equal(t, 2, validation.Len([]int{1, 2}))
+	assert.Equal(t, 1, validation.Len(map[string]int{"a": 1}))
+	assert.Equal(t, 3, validation.Len([3]int{}))
+	assert.Equal(t, 0, validation.Len(1))
+}
+
+func TestIsEmail(t *testing.T) {
+	assert.True(t, validation.IsEmail("me@example.com"))
+	assert.False(t, validation.IsEmail("Me <me@example.com>"))
+	assert.False(t, validation.IsEmail("me"))
+	assert.False(t, validation.IsEmail(""))
+}